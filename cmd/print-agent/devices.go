@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// USB and CUPS printers on Linux counters, alongside LAN (TCP :9100) and serial/Bluetooth:
+//
+//	usb:/dev/usb/lp0, usb:lp0, /dev/usb/lp0   raw write to the usblp device node
+//	ipp://localhost/printers/Thermal          Print-Job to a CUPS (or IPP) queue
+//	ipps://…                                  the same over TLS
+//
+// The CUPS queue must be a raw queue so the ESC/POS bytes reach the printer unfiltered.
+
+var usbDevRe = regexp.MustCompile(`^/dev/(usb/)?lp\d+$`)
+
+var ippHTTPClient = &http.Client{Timeout: 20 * time.Second}
+
+// usbDevicePath returns the device node when host names a raw USB printer. Only usblp
+// nodes (/dev/usb/lpN, /dev/lpN) are accepted, so a printer host can't point the agent at
+// an arbitrary file.
+func usbDevicePath(host string) (string, bool) {
+	path := strings.TrimSpace(host)
+	if strings.HasPrefix(strings.ToLower(path), "usb:") {
+		path = strings.TrimSpace(path[len("usb:"):])
+		if path == "" {
+			return "", false
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/dev/usb/" + path
+		}
+	}
+	path = filepath.Clean(path)
+	if !usbDevRe.MatchString(path) {
+		return "", false
+	}
+	return path, true
+}
+
+// ippPrinterURL returns the queue URL when host is an ipp:// or ipps:// printer.
+func ippPrinterURL(host string) (string, bool) {
+	h := strings.TrimSpace(host)
+	lower := strings.ToLower(h)
+	if strings.HasPrefix(lower, "ipp://") || strings.HasPrefix(lower, "ipps://") {
+		return h, true
+	}
+	return "", false
+}
+
+// ippHostPort is the TCP address behind an IPP URL (port 631 unless given).
+func ippHostPort(printerURL string) (string, error) {
+	u, err := url.Parse(printerURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid IPP printer URL %q", printerURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "631"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// printESCPOSUSB writes the slip to a usblp device node. A printer that is switched off
+// or out of paper can block the write, so it is abandoned after 15s.
+func printESCPOSUSB(path string, payload []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return &printerUnreachableError{err: fmt.Errorf("open %s: %w (is the agent's user in the lp group?)", path, err)}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.Write(payload)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		return nil
+	case <-time.After(15 * time.Second):
+		_ = f.Close()
+		return fmt.Errorf("write %s: timed out (printer off or out of paper?)", path)
+	}
+}
+
+// printIPP submits the slip as a raw Print-Job to a CUPS or IPP queue.
+func printIPP(printerURL string, payload []byte) error {
+	addr, err := ippHostPort(printerURL)
+	if err != nil {
+		return err
+	}
+	u, _ := url.Parse(printerURL)
+	endpoint := *u
+	endpoint.Scheme = "http"
+	if strings.EqualFold(u.Scheme, "ipps") {
+		endpoint.Scheme = "https"
+	}
+	endpoint.Host = addr
+
+	body := ippPrintJobRequest(printerURL, payload)
+	resp, err := ippHTTPClient.Post(endpoint.String(), "application/ipp", bytes.NewReader(body))
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return &printerUnreachableError{err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ipp %s: HTTP %s", printerURL, resp.Status)
+	}
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(resp.Body, head); err != nil {
+		return fmt.Errorf("ipp %s: short response: %w", printerURL, err)
+	}
+	// status-code 0x0000-0x00ff is successful (some with ignored attributes).
+	if status := binary.BigEndian.Uint16(head[2:4]); status > 0x00ff {
+		return fmt.Errorf("ipp %s: status 0x%04x", printerURL, status)
+	}
+	return nil
+}
+
+// ippPrintJobRequest encodes an IPP/1.1 Print-Job request carrying payload as the document.
+func ippPrintJobRequest(printerURL string, payload []byte) []byte {
+	var body bytes.Buffer
+	body.Write([]byte{0x01, 0x01})                       // IPP/1.1
+	body.Write([]byte{0x00, 0x02})                       // Print-Job
+	_ = binary.Write(&body, binary.BigEndian, uint32(1)) // request-id
+	body.WriteByte(0x01)                                 // operation-attributes-tag
+	writeIPPAttr(&body, 0x47, "attributes-charset", "utf-8")
+	writeIPPAttr(&body, 0x48, "attributes-natural-language", "en")
+	writeIPPAttr(&body, 0x45, "printer-uri", printerURL)
+	writeIPPAttr(&body, 0x42, "requesting-user-name", "billgenie")
+	writeIPPAttr(&body, 0x42, "job-name", "BillGenie slip")
+	writeIPPAttr(&body, 0x49, "document-format", "application/octet-stream")
+	body.WriteByte(0x03) // end-of-attributes-tag
+	body.Write(payload)
+	return body.Bytes()
+}
+
+func writeIPPAttr(b *bytes.Buffer, tag byte, name, value string) {
+	b.WriteByte(tag)
+	_ = binary.Write(b, binary.BigEndian, uint16(len(name)))
+	b.WriteString(name)
+	_ = binary.Write(b, binary.BigEndian, uint16(len(value)))
+	b.WriteString(value)
+}
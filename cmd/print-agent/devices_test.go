@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestUSBDevicePath(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{"usb:/dev/usb/lp0", "/dev/usb/lp0", true},
+		{" USB: lp1 ", "/dev/usb/lp1", true},
+		{"/dev/usb/lp2", "/dev/usb/lp2", true},
+		{"/dev/lp0", "/dev/lp0", true},
+		{"usb:/dev/lp3", "/dev/lp3", true},
+		{"usb:/dev/usb/./lp4", "/dev/usb/lp4", true},
+		{"usb:", "", false},
+		{"usb:/etc/passwd", "", false},
+		{"usb:../../etc/passwd", "", false},
+		{"usb:lp0/../../../etc/cron.d/x", "", false},
+		{"usb:/dev/usb/../sda", "", false},
+		{"usb:/dev/usb/lp0x", "", false},
+		{"/dev/usb/../lp0/../sda", "", false},
+		{"192.168.1.50", "", false},
+		{"COM3", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := usbDevicePath(tt.host)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("usbDevicePath(%q) = %q, %v; want %q, %v", tt.host, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIPPPrintJobRequest(t *testing.T) {
+	payload := []byte{0x1b, 0x40, 'h', 'i'}
+	req := ippPrintJobRequest("ipp://localhost/printers/Thermal", payload)
+
+	var want bytes.Buffer
+	want.Write([]byte{0x01, 0x01, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x01})
+	for _, attr := range []struct {
+		tag         byte
+		name, value string
+	}{
+		{0x47, "attributes-charset", "utf-8"},
+		{0x48, "attributes-natural-language", "en"},
+		{0x45, "printer-uri", "ipp://localhost/printers/Thermal"},
+		{0x42, "requesting-user-name", "billgenie"},
+		{0x42, "job-name", "BillGenie slip"},
+		{0x49, "document-format", "application/octet-stream"},
+	} {
+		want.WriteByte(attr.tag)
+		_ = binary.Write(&want, binary.BigEndian, uint16(len(attr.name)))
+		want.WriteString(attr.name)
+		_ = binary.Write(&want, binary.BigEndian, uint16(len(attr.value)))
+		want.WriteString(attr.value)
+	}
+	want.WriteByte(0x03)
+	want.Write(payload)
+
+	if !bytes.Equal(req, want.Bytes()) {
+		t.Fatalf("request:\n% x\nwant:\n% x", req, want.Bytes())
+	}
+}
+
+func TestIPPHostPort(t *testing.T) {
+	tests := []struct {
+		url, want string
+		ok        bool
+	}{
+		{"ipp://localhost/printers/Thermal", "localhost:631", true},
+		{"ipps://cups.lan:8631/printers/Bar", "cups.lan:8631", true},
+		{"ipp:///printers/x", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ippHostPort(tt.url)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ippHostPort(%q) = %q, %v; want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
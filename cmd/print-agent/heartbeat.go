@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// agentVersion is reported with every heartbeat so the dashboard can flag outdated agents.
+const agentVersion = "1.10.0"
+
+const defaultHeartbeatInterval = 30 * time.Second
+
+var agentStartedAt = time.Now()
+
+type probeTarget struct {
+	Target    string `json:"target"`
+	StationID string `json:"station_id,omitempty"`
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+}
+
+type printerStatus struct {
+	Target    string `json:"target"`
+	StationID string `json:"station_id,omitempty"`
+	Host      string `json:"host"`
+	Port      int    `json:"port,omitempty"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type heartbeatResponse struct {
+	Printers                 []probeTarget `json:"printers"`
+	TopFeedLines             int           `json:"top_feed_lines"`
+	BottomFeedLines          int           `json:"bottom_feed_lines"`
+	HeartbeatIntervalSeconds int           `json:"heartbeat_interval_seconds"`
+}
+
+// runHeartbeats reports version, uptime and printer reachability until stop fires.
+// The server answers with the printers to probe on the next beat.
+func runHeartbeats(apiURL, agentKey, agentID string, stop <-chan struct{}) {
+	var targets []probeTarget
+	interval := defaultHeartbeatInterval
+	for {
+		resp, err := sendHeartbeat(apiURL, agentKey, agentID, probePrinters(targets))
+		if err != nil {
+			log.Printf("heartbeat error: %v", err)
+		} else {
+			targets = resp.Printers
+			if localSpool != nil {
+				localSpool.rememberPrinters(spoolPrinters{
+					Printers:        resp.Printers,
+					TopFeedLines:    resp.TopFeedLines,
+					BottomFeedLines: resp.BottomFeedLines,
+				})
+			}
+			if resp.HeartbeatIntervalSeconds >= 10 {
+				interval = time.Duration(resp.HeartbeatIntervalSeconds) * time.Second
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func sendHeartbeat(apiURL, agentKey, agentID string, printers []printerStatus) (*heartbeatResponse, error) {
+	payload := map[string]interface{}{
+		"agent_id":       agentID,
+		"version":        agentVersion,
+		"uptime_seconds": int64(time.Since(agentStartedAt).Seconds()),
+		"printers":       printers,
+	}
+	if localSpool != nil {
+		payload["spool_url"] = localSpool.URL()
+		payload["spool_pending"] = localSpool.journal.Len()
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/print-agent/heartbeat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Print-Agent-Key", agentKey)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("heartbeat %s: %s", resp.Status, string(raw))
+	}
+	var out heartbeatResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func probePrinters(targets []probeTarget) []printerStatus {
+	statuses := make([]printerStatus, 0, len(targets))
+	for _, t := range targets {
+		status := printerStatus{Target: t.Target, StationID: t.StationID, Host: t.Host, Port: t.Port}
+		started := time.Now()
+		if err := probePrinter(t.Host, t.Port); err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Reachable = true
+			status.LatencyMs = time.Since(started).Milliseconds()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// probePrinter checks a printer without printing: a TCP connect for LAN and IPP printers,
+// and for USB and serial/Bluetooth targets that the device exists (opening RFCOMM would
+// wake the printer). It takes no printer lock, so a slow printer never holds up probes.
+func probePrinter(host string, port int) error {
+	if strings.TrimSpace(host) == "" {
+		return fmt.Errorf("empty printer host")
+	}
+	if path, ok := usbDevicePath(host); ok {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("USB printer %s not found (is it plugged in and powered on?)", path)
+		}
+		return nil
+	}
+	if printerURL, ok := ippPrinterURL(host); ok {
+		addr, err := ippHostPort(printerURL)
+		if err != nil {
+			return err
+		}
+		conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	if portName, ok := serialPortName(host); ok {
+		if strings.HasPrefix(portName, "/dev/") {
+			if _, err := os.Stat(portName); err != nil {
+				return fmt.Errorf("serial device %s not found", portName)
+			}
+			return nil
+		}
+		ports, err := serial.GetPortsList()
+		if err != nil {
+			return fmt.Errorf("list serial ports: %w", err)
+		}
+		for _, p := range ports {
+			if strings.EqualFold(strings.TrimPrefix(p, `\\.\`), strings.TrimPrefix(portName, `\\.\`)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("serial port %s not present (is the Bluetooth printer paired?)", portName)
+	}
+
+	if port <= 0 {
+		port = 9100
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(port)), 3*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -1,6 +1,7 @@
 // Print agent: listens for BillGenieCloud SSE wake events, claims queued KOT/bill
-// jobs, and prints over LAN ESC/POS (TCP :9100) or Bluetooth-classic printers
-// exposed as serial ports (Windows COM after OS pairing, or /dev/cu.* on macOS/Linux).
+// jobs, and prints over LAN ESC/POS (TCP :9100), Bluetooth-classic printers exposed as
+// serial ports (Windows COM after OS pairing, or /dev/cu.* on macOS/Linux), USB printer
+// device nodes (/dev/usb/lp*) or CUPS/IPP queues on Linux.
 //
 // Usage:
 //
@@ -13,6 +14,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -22,6 +24,7 @@ import (
 	"os/signal"
 	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -41,6 +44,18 @@ type printJob struct {
 	PrinterPort     int    `json:"printer_port"`
 	TopFeedLines    int    `json:"top_feed_lines"`
 	BottomFeedLines int    `json:"bottom_feed_lines"`
+	OpenDrawer      bool   `json:"open_drawer"`
+	Buzzer          bool   `json:"buzzer"`
+	PaperWidthMm    int    `json:"paper_width_mm"`
+	RasterMode      string `json:"raster_mode"`
+}
+
+// slipOptions carry per-job printer details set by the server.
+type slipOptions struct {
+	PaperWidthMm int    // 58 or 80; sizes raster logos and raster text
+	OpenDrawer   bool   // ESC p: kick the cash drawer wired to the printer
+	Buzzer       bool   // ESC B: sound the printer's built-in buzzer
+	RasterMode   string // "", non_ascii or all: which lines to draw as images
 }
 
 var (
@@ -60,13 +75,26 @@ func main() {
 		log.Fatal("Set BILLGENIE_API_URL and BILLGENIE_PRINT_AGENT_KEY")
 	}
 
-	log.Printf("Print agent starting → %s (agent_id=%s)", apiURL, agentID)
+	log.Printf("Print agent %s starting → %s (agent_id=%s)", agentVersion, apiURL, agentID)
 	log.Printf("Supports TCP (LAN/Wi-Fi) and serial/Bluetooth COM ports (e.g. COM5, serial:COM5)")
 	log.Printf("Mode: event-driven SSE (no polling)")
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
+	spool, err := startLocalSpool(agentID)
+	if err != nil {
+		log.Fatalf("LAN spool: %v", err)
+	}
+	localSpool = spool
+
+	done := make(chan struct{})
+	defer close(done)
+	go runHeartbeats(apiURL, agentKey, agentID, done)
+	if localSpool != nil {
+		go localSpool.runSpoolSync(apiURL, agentKey, agentID, done)
+	}
+
 	wake := make(chan struct{}, 1)
 	go func() {
 		backoff := time.Second
@@ -188,6 +216,7 @@ func claimAndPrint(apiURL, agentKey, agentID string) error {
 	body, _ := json.Marshal(map[string]interface{}{
 		"agent_id": agentID,
 		"limit":    5,
+		"markup":   !plainTextMode,
 	})
 	req, err := http.NewRequest(http.MethodPost, apiURL+"/print-agent/jobs/claim", bytes.NewReader(body))
 	if err != nil {
@@ -213,12 +242,13 @@ func claimAndPrint(apiURL, agentKey, agentID string) error {
 	for _, job := range claimed.Jobs {
 		target := describePrintTarget(job.PrinterHost, job.PrinterPort)
 		log.Printf("printing %s job %s → %s", job.JobType, job.ID, target)
-		if err := printESCPOS(job.PrinterHost, job.PrinterPort, job.PayloadText, job.TopFeedLines, job.BottomFeedLines); err != nil {
+		if err := printESCPOS(job.PrinterHost, job.PrinterPort, job.PayloadText, job.TopFeedLines, job.BottomFeedLines,
+			slipOptions{PaperWidthMm: job.PaperWidthMm, OpenDrawer: job.OpenDrawer, Buzzer: job.Buzzer, RasterMode: job.RasterMode}); err != nil {
 			log.Printf("print failed: %v", err)
-			_ = reportJob(apiURL, agentKey, job.ID, true, err.Error())
+			_ = reportJob(apiURL, agentKey, agentID, job.ID, true, err.Error(), errors.As(err, new(*printerUnreachableError)))
 			continue
 		}
-		_ = reportJob(apiURL, agentKey, job.ID, false, "")
+		_ = reportJob(apiURL, agentKey, agentID, job.ID, false, "", false)
 	}
 	// If the batch was full, drain remaining jobs immediately.
 	if len(claimed.Jobs) >= 5 {
@@ -227,22 +257,24 @@ func claimAndPrint(apiURL, agentKey, agentID string) error {
 	return nil
 }
 
-func reportJob(apiURL, agentKey, jobID string, failed bool, errMsg string) error {
+// reportJob confirms or fails a job. unreachable tells the server nothing reached the
+// printer, so it may reroute the job to the next printer in its failover list. agentID
+// must match the claim; the server ignores reports for jobs since handed to someone else.
+func reportJob(apiURL, agentKey, agentID, jobID string, failed bool, errMsg string, unreachable bool) error {
 	path := "/print-agent/jobs/" + jobID + "/complete"
-	var body io.Reader
+	payload := map[string]interface{}{"agent_id": agentID}
 	if failed {
 		path = "/print-agent/jobs/" + jobID + "/fail"
-		b, _ := json.Marshal(map[string]string{"error": errMsg})
-		body = bytes.NewReader(b)
+		payload["error"] = errMsg
+		payload["unreachable"] = unreachable
 	}
-	req, err := http.NewRequest(http.MethodPost, apiURL+path, body)
+	b, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, apiURL+path, bytes.NewReader(b))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("X-Print-Agent-Key", agentKey)
-	if failed {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	req.Header.Set("Content-Type", "application/json")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
@@ -252,6 +284,12 @@ func reportJob(apiURL, agentKey, jobID string, failed bool, errMsg string) error
 }
 
 func describePrintTarget(host string, port int) string {
+	if path, ok := usbDevicePath(host); ok {
+		return "usb:" + path
+	}
+	if printerURL, ok := ippPrinterURL(host); ok {
+		return printerURL
+	}
 	if portName, ok := serialPortName(host); ok {
 		return "serial:" + portName
 	}
@@ -295,36 +333,6 @@ func clampFeed(n int) int {
 	return n
 }
 
-// expandBillGenieQRMarkers replaces <<<BILLGENIE_QR>>>...<<<END_QR>>> blocks with ESC/POS QR bytes.
-func expandBillGenieQRMarkers(text string) []byte {
-	const start = "<<<BILLGENIE_QR>>>"
-	const end = "<<<END_QR>>>"
-	var out bytes.Buffer
-	rest := text
-	for {
-		i := strings.Index(rest, start)
-		if i < 0 {
-			out.WriteString(rest)
-			break
-		}
-		out.WriteString(rest[:i])
-		rest = rest[i+len(start):]
-		j := strings.Index(rest, end)
-		if j < 0 {
-			out.WriteString(start)
-			out.WriteString(rest)
-			break
-		}
-		payload := strings.TrimSpace(rest[:j])
-		rest = rest[j+len(end):]
-		if payload != "" {
-			out.Write(buildESCPOSQRCode(payload, 5))
-			out.WriteByte('\n')
-		}
-	}
-	return out.Bytes()
-}
-
 // buildESCPOSQRCode encodes data as an Epson-compatible QR symbol (model 2).
 func buildESCPOSQRCode(data string, moduleSize byte) []byte {
 	if moduleSize < 1 {
@@ -350,7 +358,7 @@ func buildESCPOSQRCode(data string, moduleSize byte) []byte {
 	return buf.Bytes()
 }
 
-func buildESCPOSPayload(text string, topFeed, bottomFeed int) []byte {
+func buildESCPOSPayload(text string, topFeed, bottomFeed int, opts slipOptions) []byte {
 	topFeed = clampFeed(topFeed)
 	bottomFeed = clampFeed(bottomFeed)
 	var buf bytes.Buffer
@@ -360,7 +368,10 @@ func buildESCPOSPayload(text string, topFeed, bottomFeed int) []byte {
 	}
 	normalized := strings.ReplaceAll(text, "\r\n", "\n")
 	normalized = strings.ReplaceAll(normalized, "\r", "\n")
-	body := expandBillGenieQRMarkers(normalized)
+	if plainTextMode {
+		normalized = stripSlipMarkup(normalized)
+	}
+	body := renderSlipMarkup(normalized, opts)
 	buf.Write(body)
 	if len(body) == 0 || body[len(body)-1] != '\n' {
 		buf.WriteByte('\n')
@@ -371,16 +382,55 @@ func buildESCPOSPayload(text string, topFeed, bottomFeed int) []byte {
 	}
 	// GS V 1 = partial cut — safer on many 58mm models than full cut (GS V 0).
 	buf.Write([]byte{0x1d, 0x56, 0x01})
+	if opts.OpenDrawer {
+		// ESC p 0 t1 t2 — pulse drawer pin 2 for 50ms on / 500ms off.
+		buf.Write([]byte{0x1b, 0x70, 0x00, 0x19, 0xfa})
+	}
+	if opts.Buzzer {
+		// ESC B n t — 3 beeps of 100ms (t is in 50ms units); ignored by printers without one.
+		buf.Write([]byte{0x1b, 0x42, 0x03, 0x02})
+	}
 	return buf.Bytes()
 }
 
-// printESCPOS sends plain text with init + cut to a LAN or serial/Bluetooth printer.
-func printESCPOS(host string, port int, text string, topFeed, bottomFeed int) error {
+// printerUnreachableError is a failure to connect to the printer at all: nothing was sent,
+// so the job can safely print somewhere else.
+type printerUnreachableError struct {
+	err error
+}
+
+func (e *printerUnreachableError) Error() string { return e.err.Error() }
+func (e *printerUnreachableError) Unwrap() error { return e.err }
+
+// printerLocks holds a mutex per printer address: many thermal printers accept a single
+// connection at a time, so two slips for the same printer go one after the other, while
+// different printers print in parallel.
+var printerLocks sync.Map // describePrintTarget → *sync.Mutex
+
+func lockPrinter(host string, port int) (unlock func()) {
+	v, _ := printerLocks.LoadOrStore(describePrintTarget(host, port), new(sync.Mutex))
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// printESCPOS sends plain text with init + cut (plus any drawer/buzzer pulse) to a LAN,
+// USB, CUPS/IPP or serial/Bluetooth printer.
+func printESCPOS(host string, port int, text string, topFeed, bottomFeed int, opts slipOptions) error {
 	if host == "" {
 		return fmt.Errorf("empty printer host")
 	}
-	payload := buildESCPOSPayload(text, topFeed, bottomFeed)
+	payload := buildESCPOSPayload(text, topFeed, bottomFeed, opts)
 
+	unlock := lockPrinter(host, port)
+	defer unlock()
+
+	if path, ok := usbDevicePath(host); ok {
+		return printESCPOSUSB(path, payload)
+	}
+	if printerURL, ok := ippPrinterURL(host); ok {
+		return printIPP(printerURL, payload)
+	}
 	if portName, ok := serialPortName(host); ok {
 		return printESCPOSSerial(portName, payload)
 	}
@@ -391,7 +441,7 @@ func printESCPOS(host string, port int, text string, topFeed, bottomFeed int) er
 	addr := fmt.Sprintf("%s:%d", host, port)
 	conn, err := net.DialTimeout("tcp", addr, 8*time.Second)
 	if err != nil {
-		return err
+		return &printerUnreachableError{err: err}
 	}
 	defer conn.Close()
 	_ = conn.SetDeadline(time.Now().Add(15 * time.Second))
@@ -412,7 +462,7 @@ func printESCPOSSerial(portName string, payload []byte) error {
 		mode.BaudRate = 115200
 		port, err = serial.Open(portName, mode)
 		if err != nil {
-			return fmt.Errorf("open serial %s: %w (pair the Bluetooth printer in Windows and use its COM port)", portName, err)
+			return &printerUnreachableError{err: fmt.Errorf("open serial %s: %w (pair the Bluetooth printer in Windows and use its COM port)", portName, err)}
 		}
 	}
 	defer port.Close()
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// plainTextMode (BILLGENIE_PLAIN_TEXT=1) prints every slip without styling, for printers
+// that misread ESC/POS style commands. The server is told so it strips markup too.
+var plainTextMode = os.Getenv("BILLGENIE_PLAIN_TEXT") == "1"
+
+const (
+	qrStart      = "<<<BILLGENIE_QR>>>"
+	qrEnd        = "<<<END_QR>>>"
+	barcodeStart = "<<<BARCODE>>>"
+	barcodeEnd   = "<<<END_BARCODE>>>"
+	logoStart    = "<<<LOGO>>>"
+	logoEnd      = "<<<END_LOGO>>>"
+)
+
+var (
+	slipTagRe      = regexp.MustCompile(`<<<(/?)(B|U|INV|DH|DW|2X)>>>`)
+	slipStyleRe    = regexp.MustCompile(`<<</?(?:B|U|INV|DH|DW|2X)>>>|<<<(?:CENTER|RIGHT)>>>`)
+	slipLogoRe     = regexp.MustCompile(`(?s)<<<LOGO>>>.*?<<<END_LOGO>>>\n?`)
+	slipBarcodeRe  = regexp.MustCompile(`(?s)<<<BARCODE>>>(.*?)<<<END_BARCODE>>>`)
+	logoCache      = map[string][]byte{}
+	logoCacheMu    sync.Mutex
+	logoHTTPClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// stripSlipMarkup mirrors the server's StripPrintMarkup for plain-text mode.
+func stripSlipMarkup(text string) string {
+	text = slipLogoRe.ReplaceAllString(text, "")
+	text = slipBarcodeRe.ReplaceAllString(text, "$1")
+	return slipStyleRe.ReplaceAllString(text, "")
+}
+
+// renderSlipMarkup turns a slip with <<<...>>> markers into ESC/POS bytes: QR, barcode and
+// logo blocks first, then per-line alignment and inline styles for the text between them.
+func renderSlipMarkup(text string, opts slipOptions) []byte {
+	var out bytes.Buffer
+	rest := text
+	for {
+		i, start, end := nextSlipBlock(rest)
+		if i < 0 {
+			writeStyledText(&out, rest, opts)
+			break
+		}
+		writeStyledText(&out, rest[:i], opts)
+		rest = rest[i+len(start):]
+		j := strings.Index(rest, end)
+		if j < 0 {
+			out.WriteString(start)
+			writeStyledText(&out, rest, opts)
+			break
+		}
+		payload := strings.TrimSpace(rest[:j])
+		rest = strings.TrimPrefix(rest[j+len(end):], "\n")
+		if payload == "" {
+			continue
+		}
+		switch start {
+		case qrStart:
+			out.Write(buildESCPOSQRCode(payload, 5))
+			out.WriteByte('\n')
+		case barcodeStart:
+			out.Write(buildESCPOSBarcode(payload))
+		case logoStart:
+			out.Write(buildESCPOSLogo(payload, opts.PaperWidthMm))
+		}
+	}
+	// Leave the printer in its default state for the cut and the next job.
+	out.Write(slipStyle{}.commands())
+	out.Write([]byte{0x1b, 0x61, 0x00})
+	return out.Bytes()
+}
+
+func nextSlipBlock(text string) (index int, start, end string) {
+	index = -1
+	for _, block := range [][2]string{{qrStart, qrEnd}, {barcodeStart, barcodeEnd}, {logoStart, logoEnd}} {
+		if i := strings.Index(text, block[0]); i >= 0 && (index < 0 || i < index) {
+			index, start, end = i, block[0], block[1]
+		}
+	}
+	return index, start, end
+}
+
+type slipStyle struct {
+	bold, underline, invert int
+	dw, dh, x2              int
+}
+
+func (s slipStyle) sizeByte() byte {
+	var n byte
+	if s.dw > 0 || s.x2 > 0 {
+		n |= 0x10
+	}
+	if s.dh > 0 || s.x2 > 0 {
+		n |= 0x01
+	}
+	return n
+}
+
+func onOff(n int) byte {
+	if n > 0 {
+		return 1
+	}
+	return 0
+}
+
+// apply updates the style for an opening (delta 1) or closing (delta -1) tag and returns
+// the ESC/POS command that puts the printer in the new state.
+func (s *slipStyle) apply(tag string, delta int) []byte {
+	switch tag {
+	case "B":
+		s.bold += delta
+		return []byte{0x1b, 0x45, onOff(s.bold)}
+	case "U":
+		s.underline += delta
+		return []byte{0x1b, 0x2d, onOff(s.underline)}
+	case "INV":
+		s.invert += delta
+		return []byte{0x1d, 0x42, onOff(s.invert)}
+	case "DW":
+		s.dw += delta
+	case "DH":
+		s.dh += delta
+	case "2X":
+		s.x2 += delta
+	default:
+		return nil
+	}
+	return []byte{0x1d, 0x21, s.sizeByte()}
+}
+
+// commands sets every style at once, to resync the printer after a raster line.
+func (s slipStyle) commands() []byte {
+	return []byte{
+		0x1b, 0x45, onOff(s.bold),
+		0x1b, 0x2d, onOff(s.underline),
+		0x1d, 0x42, onOff(s.invert),
+		0x1d, 0x21, s.sizeByte(),
+	}
+}
+
+// slipSpan is a run of line text sharing one style.
+type slipSpan struct {
+	text  string
+	style slipStyle
+}
+
+// styledSpans splits a line at its style tags, carrying style across lines.
+func styledSpans(line string, style *slipStyle) []slipSpan {
+	var spans []slipSpan
+	for {
+		loc := slipTagRe.FindStringSubmatchIndex(line)
+		if loc == nil {
+			break
+		}
+		if loc[0] > 0 {
+			spans = append(spans, slipSpan{text: line[:loc[0]], style: *style})
+		}
+		delta := 1
+		if line[loc[2]:loc[3]] == "/" {
+			delta = -1
+		}
+		style.apply(line[loc[4]:loc[5]], delta)
+		line = line[loc[1]:]
+	}
+	if line != "" {
+		spans = append(spans, slipSpan{text: line, style: *style})
+	}
+	return spans
+}
+
+// writeStyledText emits lines with ESC a alignment and ESC E / ESC - / GS B / GS ! styles.
+// Builders pad centered lines with spaces for plain printers; that padding is dropped here.
+// Lines picked by the printer's raster mode are drawn with the bundled fonts instead.
+func writeStyledText(out *bytes.Buffer, text string, opts slipOptions) {
+	var style slipStyle
+	dots := printerDots(opts.PaperWidthMm)
+	lines := strings.SplitAfter(text, "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		var align byte
+		switch {
+		case strings.HasPrefix(line, "<<<CENTER>>>"):
+			align = 1
+			line = strings.TrimLeft(strings.TrimPrefix(line, "<<<CENTER>>>"), " ")
+		case strings.HasPrefix(line, "<<<RIGHT>>>"):
+			align = 2
+			line = strings.TrimLeft(strings.TrimPrefix(line, "<<<RIGHT>>>"), " ")
+		}
+		if rasterizeLine(opts.RasterMode, line) {
+			next := style
+			spans := styledSpans(strings.TrimRight(line, "\n"), &next)
+			if img := renderRasterLine(spans, align, slipColumns(opts.PaperWidthMm), dots); img != nil {
+				out.Write(rasterGSv0(img, dots))
+				if next != style {
+					out.Write(next.commands())
+				}
+				style = next
+				continue
+			}
+		}
+		if align != 0 {
+			out.Write([]byte{0x1b, 0x61, align})
+		}
+		for {
+			loc := slipTagRe.FindStringSubmatchIndex(line)
+			if loc == nil {
+				out.WriteString(line)
+				break
+			}
+			out.WriteString(line[:loc[0]])
+			delta := 1
+			if line[loc[2]:loc[3]] == "/" {
+				delta = -1
+			}
+			out.Write(style.apply(line[loc[4]:loc[5]], delta))
+			line = line[loc[1]:]
+		}
+		if align != 0 {
+			// ESC a only takes effect at the start of a line; reset for the next one.
+			out.Write([]byte{0x1b, 0x61, 0x00})
+		}
+	}
+}
+
+// buildESCPOSBarcode prints data as a centered CODE128 barcode with the text underneath.
+// Non-ASCII or over-long data is printed as text instead.
+func buildESCPOSBarcode(data string) []byte {
+	var buf bytes.Buffer
+	ascii := len(data) <= 200
+	for i := 0; i < len(data) && ascii; i++ {
+		ascii = data[i] >= 0x20 && data[i] < 0x7f
+	}
+	if !ascii {
+		buf.WriteString(data)
+		buf.WriteByte('\n')
+		return buf.Bytes()
+	}
+	buf.Write([]byte{0x1b, 0x61, 0x01}) // center
+	buf.Write([]byte{0x1d, 0x68, 0x50}) // GS h — 80 dots tall
+	buf.Write([]byte{0x1d, 0x77, 0x02}) // GS w — module width 2
+	buf.Write([]byte{0x1d, 0x48, 0x02}) // GS H — human-readable text below
+	// GS k 73 n {B data — CODE128, code set B.
+	buf.Write([]byte{0x1d, 0x6b, 0x49, byte(len(data) + 2), '{', 'B'})
+	buf.WriteString(data)
+	buf.WriteByte('\n')
+	buf.Write([]byte{0x1b, 0x61, 0x00})
+	return buf.Bytes()
+}
+
+// printerDots is the printable width in dots at 203 dpi.
+func printerDots(paperWidthMm int) int {
+	if paperWidthMm >= 80 {
+		return 576
+	}
+	return 384
+}
+
+// slipColumns matches the server's thermalColsForPaper: 12-dot font A columns.
+func slipColumns(paperWidthMm int) int {
+	if paperWidthMm >= 80 {
+		return 48
+	}
+	return 32
+}
+
+// buildESCPOSLogo downloads an image once per URL and prints it centered as a raster.
+// A logo that can't be fetched or decoded is skipped so the bill still prints.
+func buildESCPOSLogo(url string, paperWidthMm int) []byte {
+	key := fmt.Sprintf("%d|%s", paperWidthMm, url)
+	logoCacheMu.Lock()
+	cached, ok := logoCache[key]
+	logoCacheMu.Unlock()
+	if ok {
+		return cached
+	}
+	img, err := fetchLogo(url)
+	if err != nil {
+		log.Printf("logo %s skipped: %v", url, err)
+		return nil
+	}
+	var buf bytes.Buffer
+	buf.Write([]byte{0x1b, 0x61, 0x01})
+	buf.Write(rasterGSv0(img, printerDots(paperWidthMm)*2/3))
+	buf.Write([]byte{0x1b, 0x61, 0x00})
+	out := buf.Bytes()
+	logoCacheMu.Lock()
+	logoCache[key] = out
+	logoCacheMu.Unlock()
+	return out
+}
+
+// Logos are read into memory at most maxLogoBytes, and images whose header declares more
+// than maxLogoSide pixels on either side are refused before decoding: a small compressed
+// file can otherwise expand to gigabytes of pixels.
+const (
+	maxLogoBytes = 2 << 20
+	maxLogoSide  = 2048
+)
+
+func fetchLogo(url string) (image.Image, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("logo URL must be https")
+	}
+	resp, err := logoHTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("logo %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxLogoBytes {
+		return nil, fmt.Errorf("logo larger than %d bytes", maxLogoBytes)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width > maxLogoSide || cfg.Height > maxLogoSide {
+		return nil, fmt.Errorf("logo is %dx%d, limit %dx%d", cfg.Width, cfg.Height, maxLogoSide, maxLogoSide)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// rasterGSv0 scales img to at most maxDots wide, dithers it to black and white
+// (Floyd–Steinberg; transparent pixels are paper) and encodes it as GS v 0 bands.
+func rasterGSv0(img image.Image, maxDots int) []byte {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return nil
+	}
+	w := srcW
+	if w > maxDots {
+		w = maxDots
+	}
+	h := srcH * w / srcW
+	if h < 1 {
+		h = 1
+	}
+
+	// Nearest-neighbour scale into a luminance buffer (0 = black, 255 = white).
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x*srcW/w, bounds.Min.Y+y*srcH/h)).(color.NRGBA)
+			l := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+			a := float64(c.A) / 255
+			lum[y*w+x] = l*a + 255*(1-a)
+		}
+	}
+
+	bytesPerRow := (w + 7) / 8
+	bits := make([]byte, bytesPerRow*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			old := lum[y*w+x]
+			v := 255.0
+			if old < 128 {
+				v = 0
+				bits[y*bytesPerRow+x/8] |= 0x80 >> uint(x%8)
+			}
+			e := old - v
+			if x+1 < w {
+				lum[y*w+x+1] += e * 7 / 16
+			}
+			if y+1 < h {
+				if x > 0 {
+					lum[(y+1)*w+x-1] += e * 3 / 16
+				}
+				lum[(y+1)*w+x] += e * 5 / 16
+				if x+1 < w {
+					lum[(y+1)*w+x+1] += e * 1 / 16
+				}
+			}
+		}
+	}
+
+	// Many printers have small receive buffers; send the image in bands.
+	const band = 128
+	var buf bytes.Buffer
+	for top := 0; top < h; top += band {
+		rows := h - top
+		if rows > band {
+			rows = band
+		}
+		buf.Write([]byte{0x1d, 0x76, 0x30, 0x00,
+			byte(bytesPerRow & 0xff), byte(bytesPerRow >> 8),
+			byte(rows & 0xff), byte(rows >> 8)})
+		buf.Write(bits[top*bytesPerRow : (top+rows)*bytesPerRow])
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStripSlipMarkup(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "Paneer Tikka  240.00\n", "Paneer Tikka  240.00\n"},
+		{"inline styles", "<<<DH>>><<<B>>>TOTAL<<</B>>><<</DH>>>  120.00\n", "TOTAL  120.00\n"},
+		{"alignment keeps padding", "<<<CENTER>>>   <<<2X>>>Spice Hut<<</2X>>>\n", "   Spice Hut\n"},
+		{"logo dropped with its newline", "<<<LOGO>>>https://cdn.example.com/l.png<<<END_LOGO>>>\nBILL\n", "BILL\n"},
+		{"barcode keeps data", "<<<BARCODE>>>INV-7<<<END_BARCODE>>>\n", "INV-7\n"},
+		{"qr left for the printer", "<<<BILLGENIE_QR>>>\nhttps://t.example.com\n<<<END_QR>>>\n", "<<<BILLGENIE_QR>>>\nhttps://t.example.com\n<<<END_QR>>>\n"},
+		{"unknown tag untouched", "<<<XL>>>Big\n", "<<<XL>>>Big\n"},
+	}
+	for _, tt := range tests {
+		if got := stripSlipMarkup(tt.in); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextSlipBlock(t *testing.T) {
+	tests := []struct {
+		in         string
+		index      int
+		start, end string
+	}{
+		{"no blocks here\n", -1, "", ""},
+		{"x<<<BARCODE>>>1<<<END_BARCODE>>>", 1, barcodeStart, barcodeEnd},
+		{"ab<<<LOGO>>>u<<<END_LOGO>>><<<BILLGENIE_QR>>>q<<<END_QR>>>", 2, logoStart, logoEnd},
+		{"<<<BILLGENIE_QR>>>q<<<END_QR>>><<<BARCODE>>>1<<<END_BARCODE>>>", 0, qrStart, qrEnd},
+	}
+	for _, tt := range tests {
+		index, start, end := nextSlipBlock(tt.in)
+		if index != tt.index || start != tt.start || end != tt.end {
+			t.Errorf("nextSlipBlock(%q) = %d %q %q, want %d %q %q", tt.in, index, start, end, tt.index, tt.start, tt.end)
+		}
+	}
+}
+
+func TestSlipStyleApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  []string // "B" opens, "/B" closes
+		want  []byte   // command for the last tag
+		style slipStyle
+	}{
+		{"bold on", []string{"B"}, []byte{0x1b, 0x45, 1}, slipStyle{bold: 1}},
+		{"bold off", []string{"B", "/B"}, []byte{0x1b, 0x45, 0}, slipStyle{}},
+		{"nested bold stays on", []string{"B", "B", "/B"}, []byte{0x1b, 0x45, 1}, slipStyle{bold: 1}},
+		{"underline", []string{"U"}, []byte{0x1b, 0x2d, 1}, slipStyle{underline: 1}},
+		{"invert", []string{"INV"}, []byte{0x1d, 0x42, 1}, slipStyle{invert: 1}},
+		{"double width", []string{"DW"}, []byte{0x1d, 0x21, 0x10}, slipStyle{dw: 1}},
+		{"double height", []string{"DH"}, []byte{0x1d, 0x21, 0x01}, slipStyle{dh: 1}},
+		{"double both", []string{"2X"}, []byte{0x1d, 0x21, 0x11}, slipStyle{x2: 1}},
+		{"width then height", []string{"DW", "DH"}, []byte{0x1d, 0x21, 0x11}, slipStyle{dw: 1, dh: 1}},
+		{"height kept after 2X closes", []string{"DH", "2X", "/2X"}, []byte{0x1d, 0x21, 0x01}, slipStyle{dh: 1}},
+		{"unknown tag", []string{"XL"}, nil, slipStyle{}},
+	}
+	for _, tt := range tests {
+		var s slipStyle
+		var got []byte
+		for _, tag := range tt.tags {
+			delta := 1
+			if tag[0] == '/' {
+				tag, delta = tag[1:], -1
+			}
+			got = s.apply(tag, delta)
+		}
+		if !bytes.Equal(got, tt.want) || s != tt.style {
+			t.Errorf("%s: got % x %+v, want % x %+v", tt.name, got, s, tt.want, tt.style)
+		}
+	}
+}
+
+func TestRenderSlipMarkup(t *testing.T) {
+	reset := append(slipStyle{}.commands(), 0x1b, 0x61, 0x00)
+	tests := []struct {
+		name string
+		in   string
+		want [][]byte // in order
+		not  [][]byte
+	}{
+		{
+			name: "centered bold",
+			in:   "<<<CENTER>>>    <<<B>>>BILL<<</B>>>\n",
+			want: [][]byte{{0x1b, 0x61, 0x01}, {0x1b, 0x45, 1}, []byte("BILL"), {0x1b, 0x45, 0}, []byte("\n"), {0x1b, 0x61, 0x00}},
+			not:  [][]byte{[]byte("    BILL"), []byte("<<<")},
+		},
+		{
+			name: "barcode block",
+			in:   "Invoice\n<<<BARCODE>>>INV-7<<<END_BARCODE>>>\nThanks\n",
+			want: [][]byte{[]byte("Invoice\n"), {0x1d, 0x6b, 0x49, 7, '{', 'B'}, []byte("INV-7\n"), []byte("Thanks\n")},
+			not:  [][]byte{[]byte(barcodeStart)},
+		},
+		{
+			name: "non-ascii barcode prints as text",
+			in:   "<<<BARCODE>>>बिल-7<<<END_BARCODE>>>\n",
+			want: [][]byte{[]byte("बिल-7\n")},
+			not:  [][]byte{{0x1d, 0x6b}},
+		},
+		{
+			name: "empty block skipped",
+			in:   "<<<BARCODE>>>  <<<END_BARCODE>>>\nok\n",
+			want: [][]byte{[]byte("ok\n")},
+			not:  [][]byte{{0x1d, 0x6b}},
+		},
+		{
+			name: "unterminated block printed literally",
+			in:   "<<<BARCODE>>>INV-7\n",
+			want: [][]byte{[]byte(barcodeStart + "INV-7\n")},
+			not:  [][]byte{{0x1d, 0x6b}},
+		},
+		{
+			name: "qr block",
+			in:   "<<<BILLGENIE_QR>>>\nhttps://t.example.com\n<<<END_QR>>>\n",
+			want: [][]byte{[]byte("https://t.example.com")},
+			not:  [][]byte{[]byte(qrStart)},
+		},
+	}
+	for _, tt := range tests {
+		got := renderSlipMarkup(tt.in, slipOptions{PaperWidthMm: 58})
+		rest := got
+		for _, w := range tt.want {
+			i := bytes.Index(rest, w)
+			if i < 0 {
+				t.Errorf("%s: missing % x (in order) in % x", tt.name, w, got)
+				break
+			}
+			rest = rest[i+len(w):]
+		}
+		for _, n := range tt.not {
+			if bytes.Contains(got, n) {
+				t.Errorf("%s: unexpected % x in % x", tt.name, n, got)
+			}
+		}
+		if !bytes.HasSuffix(got, reset) {
+			t.Errorf("%s: slip does not end with the style reset: % x", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Raster modes, set per printer in the print settings. ESC/POS text mode only knows the
+// printer's code page, so Devanagari, Tamil or Kannada print as garbage; these lines are
+// shaped with the bundled Noto fonts and sent as GS v 0 images instead.
+const (
+	rasterNonASCII = "non_ascii" // only lines with non-ASCII text
+	rasterAll      = "all"       // every text line, for a uniform look
+)
+
+// One column is 12 dots on both paper widths, like the printer's font A. Glyphs are drawn
+// at 20px so Latin text keeps the column grid; rows are taller than font A to fit Indic
+// vowel signs above and below the line.
+const (
+	rasterFontPx   = 20
+	rasterLineDots = 32
+	rasterBaseline = 23
+)
+
+// Noto Sans (Latin and Devanagari), Noto Sans Tamil and Noto Sans Kannada, under the OFL
+// (fonts/OFL.txt). BILLGENIE_RASTER_FONTS adds more .ttf files, tried before these.
+//
+//go:embed fonts/*.ttf
+var bundledFonts embed.FS
+
+var (
+	rasterFacesOnce sync.Once
+	rasterFaces     rasterFontmap
+	// rasterMu serialises shaping; the HarfBuzz shaper keeps a per-font cache.
+	rasterMu     sync.Mutex
+	rasterShaper shaping.HarfbuzzShaper
+)
+
+// rasterFontmap picks the first face with a glyph for each rune.
+type rasterFontmap []*font.Face
+
+func (m rasterFontmap) ResolveFace(r rune) *font.Face {
+	for _, face := range m {
+		if _, ok := face.NominalGlyph(r); ok {
+			return face
+		}
+	}
+	return m[0]
+}
+
+func loadRasterFaces() rasterFontmap {
+	var faces rasterFontmap
+	for _, path := range strings.Split(os.Getenv("BILLGENIE_RASTER_FONTS"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var face *font.Face
+			if face, err = font.ParseTTF(bytes.NewReader(data)); err == nil {
+				faces = append(faces, face)
+				continue
+			}
+		}
+		log.Printf("raster font %s skipped: %v", path, err)
+	}
+	entries, _ := bundledFonts.ReadDir("fonts")
+	for _, entry := range entries {
+		data, err := bundledFonts.ReadFile("fonts/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		face, err := font.ParseTTF(bytes.NewReader(data))
+		if err != nil {
+			log.Printf("bundled font %s: %v", entry.Name(), err)
+			continue
+		}
+		faces = append(faces, face)
+	}
+	return faces
+}
+
+// rasterizeLine reports whether the printer's raster mode draws this line as an image.
+func rasterizeLine(mode, line string) bool {
+	if mode != rasterNonASCII && mode != rasterAll {
+		return false
+	}
+	text := strings.TrimSpace(slipStyleRe.ReplaceAllString(line, ""))
+	if text == "" {
+		return false
+	}
+	if mode == rasterAll {
+		return true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return true
+		}
+	}
+	return false
+}
+
+// slipRuneCols is how many columns a rune takes, as the server's printTextWidth counts
+// them: combining marks share their base's cell and wide East Asian runes take two.
+func slipRuneCols(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1f64f, r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// rasterWord is a run of non-space text in one style, placed at a column.
+type rasterWord struct {
+	runes []rune
+	col   int
+	style slipStyle
+}
+
+// rasterDecor is a column range underlined or inverted, spaces included.
+type rasterDecor struct {
+	from, to          int
+	underline, invert bool
+	sy                int
+}
+
+func (s slipStyle) scale() (sx, sy int) {
+	sx, sy = 1, 1
+	if s.dw > 0 || s.x2 > 0 {
+		sx = 2
+	}
+	if s.dh > 0 || s.x2 > 0 {
+		sy = 2
+	}
+	return sx, sy
+}
+
+// layoutRasterLine places words on the column grid the server laid the slip out on.
+func layoutRasterLine(spans []slipSpan) (words []rasterWord, decor []rasterDecor, cols, sy int) {
+	sy = 1
+	for _, span := range spans {
+		sx, spanSy := span.style.scale()
+		if spanSy > sy {
+			sy = spanSy
+		}
+		start := cols
+		inWord := false
+		for _, r := range span.text {
+			if r == ' ' || r == '\t' {
+				inWord = false
+				cols += sx
+				continue
+			}
+			if unicode.IsControl(r) {
+				continue
+			}
+			if !inWord {
+				words = append(words, rasterWord{col: cols, style: span.style})
+				inWord = true
+			}
+			w := &words[len(words)-1]
+			w.runes = append(w.runes, r)
+			cols += slipRuneCols(r) * sx
+		}
+		if span.style.underline > 0 || span.style.invert > 0 {
+			decor = append(decor, rasterDecor{
+				from: start, to: cols,
+				underline: span.style.underline > 0, invert: span.style.invert > 0,
+				sy: spanSy,
+			})
+		}
+	}
+	return words, decor, cols, sy
+}
+
+// renderRasterLine draws one slip line as a black and white image the width of the paper.
+// It returns nil when no font could be loaded, so the line falls back to text mode.
+func renderRasterLine(spans []slipSpan, align byte, cols, dots int) image.Image {
+	rasterFacesOnce.Do(func() { rasterFaces = loadRasterFaces() })
+	if len(rasterFaces) == 0 || cols <= 0 {
+		return nil
+	}
+	words, decor, used, sy := layoutRasterLine(spans)
+	cell := dots / cols
+	offset := 0
+	switch align {
+	case 1:
+		offset = (dots - used*cell) / 2
+	case 2:
+		offset = dots - used*cell
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	height := rasterLineDots * sy
+	baseline := float32(rasterBaseline * sy)
+
+	raster := vector.NewRasterizer(dots, height)
+	rasterMu.Lock()
+	for i, word := range words {
+		// A word may run into the spaces after it, but keeps half a column from the next.
+		room := dots - offset - word.col*cell
+		if i+1 < len(words) {
+			room = (words[i+1].col-word.col)*cell - cell/2
+		}
+		drawRasterWord(raster, word, float32(offset+word.col*cell), baseline, float32(room))
+	}
+	rasterMu.Unlock()
+
+	mask := image.NewAlpha(image.Rect(0, 0, dots, height))
+	raster.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	img := image.NewGray(mask.Bounds())
+	for i, a := range mask.Pix {
+		if a < 0x80 {
+			img.Pix[i] = 0xff
+		}
+	}
+	for _, d := range decor {
+		x0, x1 := offset+d.from*cell, offset+d.to*cell
+		if x1 > dots {
+			x1 = dots
+		}
+		for x := x0; x < x1; x++ {
+			if d.underline {
+				for y := int(baseline) + 3; y < int(baseline)+3+d.sy && y < height; y++ {
+					img.Pix[y*img.Stride+x] = 0
+				}
+			}
+			if d.invert {
+				for y := 0; y < height; y++ {
+					img.Pix[y*img.Stride+x] ^= 0xff
+				}
+			}
+		}
+	}
+	return img
+}
+
+// drawRasterWord shapes a word with the fallback fonts and adds its outlines to the
+// rasterizer, squeezing it horizontally if it is wider than room.
+func drawRasterWord(raster *vector.Rasterizer, word rasterWord, x, baseline, room float32) {
+	sx, sy := word.style.scale()
+	px := rasterFontPx * sy
+	input := shaping.Input{
+		Text:      word.runes,
+		RunStart:  0,
+		RunEnd:    len(word.runes),
+		Direction: di.DirectionLTR,
+		Size:      fixed.I(px),
+		Script:    language.Latin,
+		Language:  language.DefaultLanguage(),
+	}
+	var seg shaping.Segmenter
+	var runs []shaping.Output
+	var advance fixed.Int26_6
+	for _, in := range seg.Split(input, rasterFaces) {
+		out := rasterShaper.Shape(in)
+		runs = append(runs, out)
+		advance += out.Advance
+	}
+	hx := float32(sx) / float32(sy)
+	if natural := float32(advance) / 64 * hx; room > 0 && natural > room {
+		hx *= room / natural
+	}
+	passes := 1
+	if word.style.bold > 0 {
+		passes = 2
+	}
+	pen := float32(0)
+	for _, run := range runs {
+		scale := float32(px) / float32(run.Face.Upem())
+		for _, g := range run.Glyphs {
+			outline, ok := run.Face.GlyphData(g.GlyphID).(font.GlyphOutline)
+			if ok {
+				gx := x + (pen+float32(g.XOffset)/64)*hx
+				gy := baseline - float32(g.YOffset)/64
+				for p := 0; p < passes; p++ {
+					// Faux bold: strike the glyph again one dot (two when doubled) to the right.
+					addGlyphOutline(raster, outline, gx+float32(p*sy), gy, scale*hx, scale)
+				}
+			}
+			pen += float32(g.Advance) / 64
+		}
+	}
+}
+
+func addGlyphOutline(raster *vector.Rasterizer, outline font.GlyphOutline, x, y, scaleX, scaleY float32) {
+	open := false
+	for _, s := range outline.Segments {
+		a := s.Args
+		switch s.Op {
+		case ot.SegmentOpMoveTo:
+			if open {
+				raster.ClosePath()
+			}
+			raster.MoveTo(x+a[0].X*scaleX, y-a[0].Y*scaleY)
+			open = true
+		case ot.SegmentOpLineTo:
+			raster.LineTo(x+a[0].X*scaleX, y-a[0].Y*scaleY)
+		case ot.SegmentOpQuadTo:
+			raster.QuadTo(x+a[0].X*scaleX, y-a[0].Y*scaleY, x+a[1].X*scaleX, y-a[1].Y*scaleY)
+		case ot.SegmentOpCubeTo:
+			raster.CubeTo(x+a[0].X*scaleX, y-a[0].Y*scaleY, x+a[1].X*scaleX, y-a[1].Y*scaleY,
+				x+a[2].X*scaleX, y-a[2].Y*scaleY)
+		}
+	}
+	if open {
+		raster.ClosePath()
+	}
+}
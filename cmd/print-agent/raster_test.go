@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestStyledSpans(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		start slipStyle
+		want  []slipSpan
+		end   slipStyle
+	}{
+		{"plain", "Dal Makhani", slipStyle{}, []slipSpan{{"Dal Makhani", slipStyle{}}}, slipStyle{}},
+		{"bold word", "Qty <<<B>>>2<<</B>>> x", slipStyle{}, []slipSpan{
+			{"Qty ", slipStyle{}},
+			{"2", slipStyle{bold: 1}},
+			{" x", slipStyle{}},
+		}, slipStyle{}},
+		{"nested sizes", "<<<DH>>><<<B>>>TOTAL<<</B>>> 120<<</DH>>>", slipStyle{}, []slipSpan{
+			{"TOTAL", slipStyle{bold: 1, dh: 1}},
+			{" 120", slipStyle{dh: 1}},
+		}, slipStyle{}},
+		{"style carried in", "Table 4<<</2X>>>", slipStyle{x2: 1}, []slipSpan{{"Table 4", slipStyle{x2: 1}}}, slipStyle{}},
+		{"style carried out", "<<<U>>>Notes", slipStyle{}, []slipSpan{{"Notes", slipStyle{underline: 1}}}, slipStyle{underline: 1}},
+		{"tags only", "<<<B>>><<</B>>>", slipStyle{}, nil, slipStyle{}},
+	}
+	for _, tt := range tests {
+		style := tt.start
+		got := styledSpans(tt.line, &style)
+		if !reflect.DeepEqual(got, tt.want) || style != tt.end {
+			t.Errorf("%s: got %+v ending %+v, want %+v ending %+v", tt.name, got, style, tt.want, tt.end)
+		}
+	}
+}
+
+func TestRenderSlipMarkupRasterMode(t *testing.T) {
+	gsv0 := []byte{0x1d, 0x76, 0x30}
+	tests := []struct {
+		name, in, mode string
+		raster         bool
+	}{
+		{"off", "पनीर टिक्का 240.00\n", "", false},
+		{"ascii stays text in non_ascii mode", "Paneer 240.00\n", rasterNonASCII, false},
+		{"non-ascii drawn in non_ascii mode", "पनीर टिक्का 240.00\n", rasterNonASCII, true},
+		{"ascii drawn in all mode", "<<<B>>>Paneer<<</B>>> 240.00\n", rasterAll, true},
+	}
+	for _, tt := range tests {
+		got := renderSlipMarkup(tt.in, slipOptions{PaperWidthMm: 58, RasterMode: tt.mode})
+		if bytes.Contains(got, gsv0) != tt.raster {
+			t.Errorf("%s: raster image = %v, want %v: % x", tt.name, !tt.raster, tt.raster, got)
+		}
+		if tt.raster && bytes.Contains(got, []byte("240.00")) {
+			t.Errorf("%s: rasterized line also sent as text", tt.name)
+		}
+	}
+}
+
+func TestPaperWidths(t *testing.T) {
+	tests := []struct {
+		mm, dots, cols int
+	}{
+		{0, 384, 32},
+		{58, 384, 32},
+		{80, 576, 48},
+		{112, 576, 48},
+	}
+	for _, tt := range tests {
+		if dots, cols := printerDots(tt.mm), slipColumns(tt.mm); dots != tt.dots || cols != tt.cols {
+			t.Errorf("%dmm: %d dots %d cols, want %d dots %d cols", tt.mm, dots, cols, tt.dots, tt.cols)
+		}
+		if tt.dots%tt.cols != 0 {
+			t.Errorf("%dmm: %d dots do not split into %d whole columns", tt.mm, tt.dots, tt.cols)
+		}
+	}
+}
+
+func TestRasterizeLine(t *testing.T) {
+	tests := []struct {
+		mode, line string
+		want       bool
+	}{
+		{"", "पनीर\n", false},
+		{"bogus", "पनीर\n", false},
+		{rasterNonASCII, "Paneer 240.00\n", false},
+		{rasterNonASCII, "पनीर 240.00\n", true},
+		{rasterNonASCII, "<<<B>>>மசாலா<<</B>>>\n", true},
+		{rasterNonASCII, "<<<CENTER>>>  <<<B>>><<</B>>>\n", false},
+		{rasterAll, "Paneer 240.00\n", true},
+		{rasterAll, "   \n", false},
+		{rasterAll, "<<<B>>><<</B>>>\n", false},
+	}
+	for _, tt := range tests {
+		if got := rasterizeLine(tt.mode, tt.line); got != tt.want {
+			t.Errorf("rasterizeLine(%q, %q) = %v, want %v", tt.mode, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSlipRuneCols(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'A', 1},
+		{'न', 1},
+		{'\u093f', 1}, // spacing vowel sign keeps its own column
+		{'\u094d', 0}, // virama joins the next consonant
+		{'\u200d', 0}, // zero width joiner
+		{'ಕ', 1},
+		{'日', 2},
+		{'한', 2},
+		{'🍛', 2},
+	}
+	for _, tt := range tests {
+		if got := slipRuneCols(tt.r); got != tt.want {
+			t.Errorf("slipRuneCols(%U) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutRasterLine(t *testing.T) {
+	type word struct {
+		text string
+		col  int
+	}
+	tests := []struct {
+		name  string
+		spans []slipSpan
+		words []word
+		decor []rasterDecor
+		cols  int
+		sy    int
+	}{
+		{
+			name:  "plain words",
+			spans: []slipSpan{{"Dal  240", slipStyle{}}},
+			words: []word{{"Dal", 0}, {"240", 5}},
+			cols:  8, sy: 1,
+		},
+		{
+			name:  "combining marks share columns",
+			spans: []slipSpan{{"पनीर टिक्का", slipStyle{}}},
+			words: []word{{"पनीर", 0}, {"टिक्का", 5}},
+			cols:  10, sy: 1,
+		},
+		{
+			name:  "wide runes",
+			spans: []slipSpan{{"日本 x", slipStyle{}}},
+			words: []word{{"日本", 0}, {"x", 5}},
+			cols:  6, sy: 1,
+		},
+		{
+			name:  "double width doubles columns only",
+			spans: []slipSpan{{"Hi x", slipStyle{dw: 1}}},
+			words: []word{{"Hi", 0}, {"x", 6}},
+			cols:  8, sy: 1,
+		},
+		{
+			name:  "2X then normal text",
+			spans: []slipSpan{{"Hi", slipStyle{x2: 1}}, {" 4", slipStyle{}}},
+			words: []word{{"Hi", 0}, {"4", 5}},
+			cols:  6, sy: 2,
+		},
+		{
+			name:  "double height keeps columns",
+			spans: []slipSpan{{"TOTAL", slipStyle{dh: 1, bold: 1}}},
+			words: []word{{"TOTAL", 0}},
+			cols:  5, sy: 2,
+		},
+		{
+			name:  "underline and invert ranges",
+			spans: []slipSpan{{"a ", slipStyle{}}, {"b c", slipStyle{underline: 1}}, {"d", slipStyle{invert: 1, dw: 1}}},
+			words: []word{{"a", 0}, {"b", 2}, {"c", 4}, {"d", 5}},
+			decor: []rasterDecor{
+				{from: 2, to: 5, underline: true, sy: 1},
+				{from: 5, to: 7, invert: true, sy: 1},
+			},
+			cols: 7, sy: 1,
+		},
+		{
+			name:  "control runes skipped",
+			spans: []slipSpan{{"a\rb", slipStyle{}}},
+			words: []word{{"ab", 0}},
+			cols:  2, sy: 1,
+		},
+	}
+	for _, tt := range tests {
+		words, decor, cols, sy := layoutRasterLine(tt.spans)
+		var got []word
+		for _, w := range words {
+			got = append(got, word{string(w.runes), w.col})
+		}
+		if !reflect.DeepEqual(got, tt.words) || !reflect.DeepEqual(decor, tt.decor) || cols != tt.cols || sy != tt.sy {
+			t.Errorf("%s: got %v %+v cols %d sy %d, want %v %+v cols %d sy %d",
+				tt.name, got, decor, cols, sy, tt.words, tt.decor, tt.cols, tt.sy)
+		}
+	}
+}
+
+func TestRenderRasterLine(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		align         byte
+		mm            int
+		width, height int
+		inkFrom       int // leftmost inked column must be at or after this
+		inkTo         int // and before this
+	}{
+		{"58mm left", "पनीर 240", 0, 58, 384, 32, 0, 24},
+		{"80mm centered 2X", "<<<2X>>>Hi<<</2X>>>", 1, 80, 576, 64, 264, 288},
+		{"58mm right", "ಮಸಾಲೆ", 2, 58, 384, 32, 384 - 5*12, 384 - 3*12},
+		{"80mm double height", "<<<DH>>>மசாலா<<</DH>>>", 0, 80, 576, 64, 0, 24},
+	}
+	for _, tt := range tests {
+		var style slipStyle
+		spans := styledSpans(tt.line, &style)
+		img := renderRasterLine(spans, tt.align, slipColumns(tt.mm), printerDots(tt.mm))
+		if img == nil {
+			t.Fatalf("%s: no image; are the bundled fonts embedded?", tt.name)
+		}
+		if b := img.Bounds(); b != image.Rect(0, 0, tt.width, tt.height) {
+			t.Errorf("%s: bounds %v, want %dx%d", tt.name, b, tt.width, tt.height)
+			continue
+		}
+		left := leftmostInk(img.(*image.Gray))
+		if left < tt.inkFrom || left >= tt.inkTo {
+			t.Errorf("%s: first ink at x=%d, want %d..%d", tt.name, left, tt.inkFrom, tt.inkTo)
+		}
+	}
+	if img := renderRasterLine([]slipSpan{{"x", slipStyle{}}}, 0, 0, 384); img != nil {
+		t.Error("zero columns should fall back to text mode")
+	}
+}
+
+func leftmostInk(img *image.Gray) int {
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			if img.GrayAt(x, y).Y == 0 {
+				return x
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,618 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The LAN spool lets POS tablets print while the restaurant's internet is down: they
+// post the slip straight to the agent, which prints it at once and journals it on disk.
+// The journal is replayed to the API as completed print jobs once it is reachable again.
+//
+//	BILLGENIE_SPOOL_ADDR   listen address, e.g. :9180 (spool disabled when empty)
+//	BILLGENIE_SPOOL_TOKEN  shared secret POS devices send as X-Spool-Token (required)
+//	BILLGENIE_SPOOL_DIR    journal directory (default: ./billgenie-spool)
+
+const (
+	spoolJournalFile  = "journal.jsonl"
+	spoolPrintersFile = "printers.json"
+	spoolSyncInterval = 20 * time.Second
+	spoolSyncBatch    = 50
+	maxSpoolBodyBytes = 256 << 10
+)
+
+// localSpool is nil unless BILLGENIE_SPOOL_ADDR is set.
+var localSpool *spoolServer
+
+type spoolEntry struct {
+	SpoolID         string    `json:"spool_id"`
+	JobType         string    `json:"job_type"`
+	Target          string    `json:"target"`
+	OrderID         string    `json:"order_id,omitempty"`
+	StationID       string    `json:"station_id,omitempty"`
+	PayloadText     string    `json:"payload_text"`
+	PrinterHost     string    `json:"printer_host"`
+	PrinterPort     int       `json:"printer_port"`
+	TopFeedLines    int       `json:"top_feed_lines"`
+	BottomFeedLines int       `json:"bottom_feed_lines"`
+	OpenDrawer      bool      `json:"open_drawer"`
+	Buzzer          bool      `json:"buzzer"`
+	PaperWidthMm    int       `json:"paper_width_mm"`
+	RasterMode      string    `json:"raster_mode,omitempty"`
+	Status          string    `json:"status"` // done | failed
+	Error           string    `json:"error,omitempty"`
+	PrintedAt       time.Time `json:"printed_at"`
+}
+
+// journalRecord is one line of the append-only journal: a printed slip, or an
+// acknowledgement that the API has stored it.
+type journalRecord struct {
+	Op    string      `json:"op"` // print | synced
+	Entry *spoolEntry `json:"entry,omitempty"`
+	ID    string      `json:"id,omitempty"`
+}
+
+// spoolJournal keeps unsynced slips in memory, backed by an fsync'd JSONL file so they
+// survive a crash or power cut during the outage.
+type spoolJournal struct {
+	mu      sync.Mutex
+	path    string
+	pending map[string]spoolEntry
+	order   []string
+}
+
+func openSpoolJournal(dir string) (*spoolJournal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	j := &spoolJournal{path: filepath.Join(dir, spoolJournalFile), pending: map[string]spoolEntry{}}
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	torn := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 4*maxSpoolBodyBytes)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn last line from a crash mid-write; everything before it is intact.
+			log.Printf("spool journal: skipping unreadable record: %v", err)
+			torn = true
+			continue
+		}
+		switch rec.Op {
+		case "print":
+			if rec.Entry != nil {
+				j.put(*rec.Entry)
+			}
+		case "synced":
+			delete(j.pending, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	j.compactOrder()
+	if torn {
+		// Rewrite so new records don't get glued onto the torn line.
+		if err := j.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// rewrite replaces the journal with just the pending entries (temp file + rename).
+func (j *spoolJournal) rewrite() error {
+	var buf bytes.Buffer
+	for _, id := range j.order {
+		entry := j.pending[id]
+		line, err := json.Marshal(journalRecord{Op: "print", Entry: &entry})
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+func (j *spoolJournal) put(entry spoolEntry) {
+	if _, ok := j.pending[entry.SpoolID]; !ok {
+		j.order = append(j.order, entry.SpoolID)
+	}
+	j.pending[entry.SpoolID] = entry
+}
+
+func (j *spoolJournal) compactOrder() {
+	kept := j.order[:0]
+	for _, id := range j.order {
+		if _, ok := j.pending[id]; ok {
+			kept = append(kept, id)
+		}
+	}
+	j.order = kept
+}
+
+func (j *spoolJournal) appendRecords(records ...journalRecord) error {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Get returns an unsynced entry, used to answer a POS device retrying the same slip.
+func (j *spoolJournal) Get(id string) (spoolEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.pending[id]
+	return entry, ok
+}
+
+func (j *spoolJournal) Add(entry spoolEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.appendRecords(journalRecord{Op: "print", Entry: &entry}); err != nil {
+		return err
+	}
+	j.put(entry)
+	return nil
+}
+
+// Pending returns up to limit unsynced entries, oldest first.
+func (j *spoolJournal) Pending(limit int) []spoolEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]spoolEntry, 0, limit)
+	for _, id := range j.order {
+		if len(out) >= limit {
+			break
+		}
+		out = append(out, j.pending[id])
+	}
+	return out
+}
+
+func (j *spoolJournal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.pending)
+}
+
+// MarkSynced drops acknowledged entries. Once nothing is pending the journal file is
+// truncated so it does not grow across outages.
+func (j *spoolJournal) MarkSynced(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	records := make([]journalRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, journalRecord{Op: "synced", ID: id})
+	}
+	if err := j.appendRecords(records...); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(j.pending, id)
+	}
+	j.compactOrder()
+	if len(j.pending) == 0 {
+		return os.Truncate(j.path, 0)
+	}
+	return nil
+}
+
+// spoolPrinters is the last printer layout the API sent with a heartbeat, saved to disk
+// so the spool can route slips after a restart during an outage.
+type spoolPrinters struct {
+	Printers        []probeTarget `json:"printers"`
+	TopFeedLines    int           `json:"top_feed_lines"`
+	BottomFeedLines int           `json:"bottom_feed_lines"`
+}
+
+type spoolServer struct {
+	addr    string
+	token   string
+	dir     string
+	journal *spoolJournal
+
+	mu       sync.RWMutex
+	printers spoolPrinters
+	inflight map[string]*spoolIDLock // spool ids being printed, guarded by mu
+}
+
+// spoolIDLock serialises requests for one spool_id; refs counts holders and waiters so the
+// entry can be dropped once the last one is done.
+type spoolIDLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockSpoolID holds id until unlock is called, so a POS device retrying a slip waits for
+// the first request to print and journal it, then gets that result instead of a second
+// print.
+func (s *spoolServer) lockSpoolID(id string) (unlock func()) {
+	s.mu.Lock()
+	l := s.inflight[id]
+	if l == nil {
+		l = &spoolIDLock{}
+		s.inflight[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.inflight, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func startLocalSpool(agentID string) (*spoolServer, error) {
+	addr := strings.TrimSpace(os.Getenv("BILLGENIE_SPOOL_ADDR"))
+	if addr == "" {
+		return nil, nil
+	}
+	token := strings.TrimSpace(os.Getenv("BILLGENIE_SPOOL_TOKEN"))
+	if token == "" {
+		return nil, fmt.Errorf("BILLGENIE_SPOOL_TOKEN is required when BILLGENIE_SPOOL_ADDR is set")
+	}
+	dir := strings.TrimSpace(os.Getenv("BILLGENIE_SPOOL_DIR"))
+	if dir == "" {
+		dir = "billgenie-spool"
+	}
+	journal, err := openSpoolJournal(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open spool journal: %w", err)
+	}
+	s := &spoolServer{
+		addr:     addr,
+		token:    token,
+		dir:      dir,
+		journal:  journal,
+		printers: spoolPrinters{BottomFeedLines: 3},
+		inflight: make(map[string]*spoolIDLock),
+	}
+	if raw, err := os.ReadFile(filepath.Join(dir, spoolPrintersFile)); err == nil {
+		_ = json.Unmarshal(raw, &s.printers)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/spool/health", s.handleHealth(agentID))
+	mux.HandleFunc("/spool/print", s.handlePrint)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("spool server stopped: %v", err)
+		}
+	}()
+	log.Printf("LAN spool listening on %s (journal %s, %d unsynced)", ln.Addr(), journal.path, journal.Len())
+	return s, nil
+}
+
+// URL is the address POS devices should fall back to, as reported in heartbeats.
+func (s *spoolServer) URL() string {
+	host, port, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = lanIP()
+	}
+	if host == "" {
+		return ""
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// lanIP picks the first private IPv4 address, which is what tablets on the Wi-Fi can reach.
+func lanIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil && ipnet.IP.IsPrivate() {
+			return ipnet.IP.String()
+		}
+	}
+	return ""
+}
+
+func (s *spoolServer) rememberPrinters(p spoolPrinters) {
+	s.mu.Lock()
+	s.printers = p
+	s.mu.Unlock()
+	if raw, err := json.Marshal(p); err == nil {
+		tmp := filepath.Join(s.dir, spoolPrintersFile+".tmp")
+		if os.WriteFile(tmp, raw, 0o600) == nil {
+			_ = os.Rename(tmp, filepath.Join(s.dir, spoolPrintersFile))
+		}
+	}
+}
+
+// printerFor picks the cached printer for a target/station. Bills fall back to the KOT
+// printer like they do on the server.
+func (s *spoolServer) printerFor(target, stationID string) (string, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var kotHost string
+	var kotPort int
+	for _, p := range s.printers.Printers {
+		if stationID != "" && p.StationID == stationID {
+			return p.Host, p.Port
+		}
+		if p.StationID != "" {
+			continue
+		}
+		if p.Target == target && stationID == "" {
+			return p.Host, p.Port
+		}
+		if p.Target == "kot_printer" && kotHost == "" {
+			kotHost, kotPort = p.Host, p.Port
+		}
+	}
+	return kotHost, kotPort
+}
+
+func (s *spoolServer) authorized(r *http.Request) bool {
+	got := r.Header.Get("X-Spool-Token")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+func writeSpoolJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func spoolCORS(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Spool-Token")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+}
+
+func (s *spoolServer) handleHealth(agentID string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spoolCORS(w)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeSpoolJSON(w, http.StatusOK, map[string]interface{}{
+			"ok":       true,
+			"agent_id": agentID,
+			"version":  agentVersion,
+			"pending":  s.journal.Len(),
+		})
+	}
+}
+
+// handlePrint prints a slip posted by a POS device and journals the outcome. A repeated
+// spool_id returns the earlier result instead of printing twice. The printer always comes
+// from the layout the API sent with the last heartbeat, never from the request, so a LAN
+// client can't aim the agent at another host or device.
+func (s *spoolServer) handlePrint(w http.ResponseWriter, r *http.Request) {
+	spoolCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeSpoolJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST only"})
+		return
+	}
+	if !s.authorized(r) {
+		writeSpoolJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid spool token"})
+		return
+	}
+	var in spoolEntry
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSpoolBodyBytes)).Decode(&in); err != nil {
+		writeSpoolJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(in.PayloadText) == "" {
+		writeSpoolJSON(w, http.StatusBadRequest, map[string]string{"error": "payload_text is required"})
+		return
+	}
+	in.SpoolID = strings.TrimSpace(in.SpoolID)
+	if len(in.SpoolID) > 64 {
+		writeSpoolJSON(w, http.StatusBadRequest, map[string]string{"error": "spool_id is too long"})
+		return
+	}
+	if in.SpoolID == "" {
+		in.SpoolID = newSpoolID()
+	} else {
+		unlock := s.lockSpoolID(in.SpoolID)
+		defer unlock()
+		if prev, ok := s.journal.Get(in.SpoolID); ok {
+			writeSpoolJSON(w, http.StatusOK, spoolResult(prev))
+			return
+		}
+	}
+	in.JobType = strings.ToLower(strings.TrimSpace(in.JobType))
+	if in.JobType != "bill" {
+		in.JobType = "kot"
+	}
+	if in.Target == "" {
+		in.Target = in.JobType + "_printer"
+	}
+
+	s.mu.RLock()
+	feeds := s.printers
+	s.mu.RUnlock()
+	in.PrinterHost, in.PrinterPort = s.printerFor(in.Target, in.StationID)
+	in.TopFeedLines, in.BottomFeedLines = feeds.TopFeedLines, feeds.BottomFeedLines
+
+	in.Status = "done"
+	if in.PrinterHost == "" {
+		in.Status = "failed"
+		in.Error = "no printer known for " + in.Target + " (agent has not reached the server yet)"
+	} else {
+		log.Printf("spool: printing %s %s → %s", in.JobType, in.SpoolID, describePrintTarget(in.PrinterHost, in.PrinterPort))
+		if err := printESCPOS(in.PrinterHost, in.PrinterPort, in.PayloadText, in.TopFeedLines, in.BottomFeedLines,
+			slipOptions{PaperWidthMm: in.PaperWidthMm, OpenDrawer: in.OpenDrawer, Buzzer: in.Buzzer, RasterMode: in.RasterMode}); err != nil {
+			in.Status = "failed"
+			in.Error = err.Error()
+		}
+	}
+	in.PrintedAt = time.Now().UTC()
+	if err := s.journal.Add(in); err != nil {
+		// The slip printed (or failed) but won't reach the server; say so rather than hide it.
+		log.Printf("spool journal write failed: %v", err)
+		writeSpoolJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"spool_id": in.SpoolID, "status": in.Status, "error": "journal write failed: " + err.Error(),
+		})
+		return
+	}
+	status := http.StatusOK
+	if in.Status == "failed" {
+		status = http.StatusBadGateway
+	}
+	writeSpoolJSON(w, status, spoolResult(in))
+}
+
+func spoolResult(e spoolEntry) map[string]interface{} {
+	return map[string]interface{}{"spool_id": e.SpoolID, "status": e.Status, "error": e.Error}
+}
+
+func newSpoolID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("sp-%d", time.Now().UnixNano())
+	}
+	return "sp-" + hex.EncodeToString(b)
+}
+
+// runSpoolSync uploads journaled slips whenever the API is reachable.
+func (s *spoolServer) runSpoolSync(apiURL, agentKey, agentID string, stop <-chan struct{}) {
+	for {
+		for s.journal.Len() > 0 {
+			n, err := s.syncOnce(apiURL, agentKey, agentID)
+			if err != nil {
+				log.Printf("spool sync: %v (%d pending)", err, s.journal.Len())
+				break
+			}
+			log.Printf("spool sync: uploaded %d slip(s), %d pending", n, s.journal.Len())
+			if n == 0 {
+				break
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(spoolSyncInterval):
+		}
+	}
+}
+
+func (s *spoolServer) syncOnce(apiURL, agentKey, agentID string) (int, error) {
+	batch := s.journal.Pending(spoolSyncBatch)
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"agent_id": agentID,
+		"jobs":     batch,
+	})
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/print-agent/spool/sync", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Print-Agent-Key", agentKey)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("sync %s: %s", resp.Status, string(raw))
+	}
+	var out struct {
+		Synced   []string `json:"synced"`
+		Rejected []struct {
+			SpoolID string `json:"spool_id"`
+			Error   string `json:"error"`
+		} `json:"rejected"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return 0, err
+	}
+	// The API will never accept a rejected entry; keeping it would block the journal.
+	done := out.Synced
+	for _, r := range out.Rejected {
+		log.Printf("spool sync: slip %s rejected by the API and dropped: %s", r.SpoolID, r.Error)
+		if r.SpoolID != "" {
+			done = append(done, r.SpoolID)
+		}
+	}
+	if err := s.journal.MarkSynced(done); err != nil {
+		return 0, err
+	}
+	return len(done), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSpoolPrintUsesAgentPrinterOnce(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var prints atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			prints.Add(1)
+			_, _ = io.Copy(io.Discard, conn)
+			conn.Close()
+		}
+	}()
+
+	journal, err := openSpoolJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	s := &spoolServer{
+		token:    "secret",
+		dir:      t.TempDir(),
+		journal:  journal,
+		printers: spoolPrinters{Printers: []probeTarget{{Target: "kot_printer", Host: "127.0.0.1", Port: port}}},
+		inflight: make(map[string]*spoolIDLock),
+	}
+
+	body := `{"spool_id":"sp-1","job_type":"kot","payload_text":"Dal x1\n","printer_host":"10.255.255.1","printer_port":9100}`
+	var wg sync.WaitGroup
+	results := make([]map[string]interface{}, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/spool/print", strings.NewReader(body))
+			req.Header.Set("X-Spool-Token", "secret")
+			rec := httptest.NewRecorder()
+			s.handlePrint(rec, req)
+			_ = json.Unmarshal(rec.Body.Bytes(), &results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if n := prints.Load(); n != 1 {
+		t.Fatalf("printed %d times, want once on the agent's own printer", n)
+	}
+	for _, r := range results {
+		if r["status"] != "done" || r["spool_id"] != "sp-1" {
+			t.Fatalf("every retry should get the first result: %v", results)
+		}
+	}
+	if entry, ok := journal.Get("sp-1"); !ok || entry.PrinterHost != "127.0.0.1" {
+		t.Fatalf("journaled printer %+v, want the agent's", entry)
+	}
+	if len(s.inflight) != 0 {
+		t.Fatalf("spool id locks left behind: %v", s.inflight)
+	}
+}
@@ -83,12 +83,19 @@ func main() {
 	handlers.SetJWTSecrets(cfg.JWTSecret, cfg.RefreshJWTSecret)
 	eventBridge := realtime.NewEventBridge(wsHub)
 	handlers.SetEventPublisher(eventBridge)
+	handlers.SetEventLog(realtime.NewEventLog())
+	handlers.SetKitchenStationRouting(services.NewKitchenStationService(db))
 	go wsHub.Run()
 
+	// SSE hubs (tracking, assistance, print agent wake-ups) fan out through Redis too.
+	hubBroker := realtime.NewHubBroker()
 	trackHub := services.NewOrderTrackingHub()
+	trackHub.UseBroker(hubBroker)
 	handlers.SetOrderTrackingHub(trackHub)
 	assistanceHub := services.NewAssistanceHub()
+	assistanceHub.UseBroker(hubBroker)
 	handlers.SetAssistanceHub(assistanceHub)
+	services.SharedPrintNotifyHub().UseBroker(hubBroker)
 
 	// Setup routes
 	handlers.SetupAuthRoutes(router, db)
@@ -102,14 +109,20 @@ func main() {
 	handlers.SetupUserRoutes(router, db)
 	handlers.SetupIngredientRoutes(router, db)
 	handlers.SetupExpenseRoutes(router, db)
+	handlers.SetupTransactionRoutes(router, db)
+	handlers.SetupCashDrawerRoutes(router, db)
+	handlers.SetupZReportRoutes(router, db)
 	handlers.SetupPublicRoutes(router, db)
 	handlers.SetupTrackRoutes(router, db)
 	handlers.SetupBillRoutes(router, db)
 	handlers.SetupAssistanceRoutes(router, db)
+	handlers.SetupGuestOrderRoutes(router, db)
 	handlers.SetupSubscriptionRoutes(router, db)
 	handlers.SetupWebhookRoutes(router, db)
 	handlers.SetupPlatformRoutes(router, db)
 	handlers.SetupPrintRoutes(router, db)
+	handlers.SetupKitchenStationRoutes(router, db)
+	handlers.SetupKDSRoutes(router, db)
 	handlers.SetupPushRoutes(router, db)
 
 	// WebSocket route — prefer Sec-WebSocket-Protocol; temporary ?token= fallback
@@ -6,6 +6,7 @@ require (
 	github.com/gin-contrib/gzip v1.2.6
 	github.com/gin-gonic/gin v1.12.0
 	github.com/go-playground/validator/v10 v10.30.3
+	github.com/go-text/typesetting v0.3.5
 	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
@@ -13,6 +14,7 @@ require (
 	github.com/redis/go-redis/v9 v9.22.0
 	go.bug.st/serial v1.8.0
 	golang.org/x/crypto v0.54.0
+	golang.org/x/image v0.34.0
 	gorm.io/driver/postgres v1.6.2
 	gorm.io/gorm v1.31.2
 )
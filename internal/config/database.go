@@ -9,6 +9,7 @@ import (
 
 	"restaurant-api/internal/migrations"
 	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -143,6 +144,18 @@ func MigrateDatabase(db *gorm.DB) {
 		&models.RestaurantPrintSettings{},
 		&models.PrintJob{},
 		&models.UserPushToken{},
+		&models.InvoiceSequence{},
+		&models.CreditNote{},
+		&models.CreditNoteLine{},
+		&models.CashDrawerSession{},
+		&models.CashDrawerMovement{},
+		&models.ZReport{},
+		&models.OrderTender{},
+		&models.KitchenStation{},
+		&models.KitchenStationRule{},
+		&models.PrintAgentStatus{},
+		&models.GuestOrderRequest{},
+		&models.KDSBump{},
 	)
 
 	if err != nil {
@@ -169,6 +182,30 @@ func MigrateDatabase(db *gorm.DB) {
 		log.Println("✅ BackfillMenuAvailableChannels migration completed")
 	}
 
+	if err := migrations.BackfillRestaurantTimezone(db, services.DefaultRestaurantTimezone()); err != nil {
+		log.Printf("⚠️  Migration BackfillRestaurantTimezone skipped or failed: %v", err)
+	} else {
+		log.Println("✅ BackfillRestaurantTimezone migration completed")
+	}
+
+	if err := migrations.InvoiceSequenceSeriesPK(db); err != nil {
+		log.Printf("⚠️  Migration InvoiceSequenceSeriesPK skipped or failed: %v", err)
+	} else {
+		log.Println("✅ InvoiceSequenceSeriesPK migration completed")
+	}
+
+	if err := migrations.BackfillOrderItemTaxRate(db); err != nil {
+		log.Printf("⚠️  Migration BackfillOrderItemTaxRate skipped or failed: %v", err)
+	} else {
+		log.Println("✅ BackfillOrderItemTaxRate migration completed")
+	}
+
+	if err := migrations.BackfillOrderTenders(db); err != nil {
+		log.Printf("⚠️  Migration BackfillOrderTenders skipped or failed: %v", err)
+	} else {
+		log.Println("✅ BackfillOrderTenders migration completed")
+	}
+
 	if err := migrations.NullEmptyAttendedByUserID(db); err != nil {
 		log.Printf("⚠️  Migration NullEmptyAttendedByUserID skipped or failed: %v", err)
 	} else {
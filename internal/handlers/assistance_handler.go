@@ -26,23 +26,30 @@ func SetAssistanceHub(hub *services.AssistanceHub) {
 
 // SetupAssistanceRoutes registers public customer table-session pages (no auth).
 func SetupAssistanceRoutes(router *gin.Engine, db *gorm.DB) {
+	orderService := services.NewOrderService(db)
 	handler := &AssistanceHandler{
 		db:           db,
-		orderService: services.NewOrderService(db),
+		orderService: orderService,
+		printService: services.NewPrintService(db),
+		guestOrders:  services.NewGuestOrderService(db, orderService),
 		hub:          globalAssistanceHub,
 	}
 	callWaiterLimit := middleware.RateLimit(20, 15*time.Minute)
+	guestOrderLimit := middleware.RateLimit(10, 15*time.Minute)
 	router.GET("/a/:token", handler.AssistancePage)
 	router.GET("/a/:token/status", handler.AssistanceStatus)
 	router.GET("/a/:token/events", handler.AssistanceEvents)
 	router.GET("/a/:token/menu", handler.AssistanceMenu)
 	router.POST("/a/:token/call-waiter", callWaiterLimit, handler.CallWaiter)
+	router.POST("/a/:token/order", guestOrderLimit, handler.PlaceGuestOrder)
 	log.Println("✅ Customer table session routes registered at /a/:token")
 }
 
 type AssistanceHandler struct {
 	db           *gorm.DB
 	orderService *services.OrderService
+	printService *services.PrintService
+	guestOrders  *services.GuestOrderService
 	hub          *services.AssistanceHub
 }
 
@@ -212,6 +219,68 @@ func (h *AssistanceHandler) CallWaiter(c *gin.Context) {
 	})
 }
 
+// PlaceGuestOrder stores the guest's cart as a pending request for staff to approve, or
+// sends it straight to the kitchen when the restaurant auto-approves guest orders.
+func (h *AssistanceHandler) PlaceGuestOrder(c *gin.Context) {
+	token := c.Param("token")
+	table, err := services.ResolveTableByAssistanceToken(h.db, token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table link not found."})
+		return
+	}
+	var input services.GuestOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order."})
+		return
+	}
+
+	req, autoApprove, err := h.guestOrders.Submit(table, input)
+	if err != nil {
+		c.JSON(guestOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	approved := false
+	if autoApprove {
+		result, err := h.guestOrders.Approve(table.RestaurantID, req.ID, "")
+		if err != nil {
+			// Leave it pending for staff rather than losing the guest's order.
+			log.Printf("⚠️  Guest order %s auto-approve failed: %v", req.ID, err)
+		} else {
+			announceGuestOrderApproval(h.db, h.orderService, h.printService, result)
+			req = result.Request
+			approved = true
+		}
+	}
+	if !approved {
+		BroadcastGuestOrderEvent(globalHub, table.RestaurantID, "guest_order_requested", req)
+		notifyStaffPush(h.db, table.RestaurantID, services.PushAlertGuestOrder,
+			"New table order",
+			"Table "+table.Name+" sent an order for approval",
+			map[string]string{
+				"table_id":   table.ID,
+				"table_name": table.Name,
+				"request_id": req.ID,
+			},
+		)
+	}
+
+	status, err := services.BuildAssistanceStatusForTable(h.db, h.orderService, table)
+	if err == nil && status != nil {
+		publishAssistanceStatusForTable(table, *status)
+	}
+
+	message := "Sent to staff for confirmation"
+	if approved {
+		message = "Order sent to the kitchen"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     message,
+		"guest_order": gin.H{"id": req.ID, "status": req.Status},
+		"status":      status,
+	})
+}
+
 func publishAssistanceStatus(token string, status services.AssistanceStatus) {
 	if globalAssistanceHub == nil || strings.TrimSpace(token) == "" {
 		return
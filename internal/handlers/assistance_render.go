@@ -140,6 +140,38 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
     .veg{background:var(--brand)}
     .nonveg{background:var(--danger)}
     .menu-loading,.menu-empty{color:var(--muted);font-size:.9rem;padding:16px 0;text-align:center}
+    .menu-row-side{display:flex;flex-direction:column;align-items:flex-end;gap:8px}
+    .add-btns{display:flex;flex-wrap:wrap;gap:6px;margin-top:8px}
+    .add-btn{
+      border:1px solid var(--brand);background:var(--surface);color:var(--brand-dark);
+      border-radius:8px;padding:6px 12px;font-size:.82rem;font-weight:800;cursor:pointer;font-family:inherit;
+    }
+    .guest-status{
+      display:none;margin-top:14px;padding:10px 12px;border-radius:10px;
+      font-size:.9rem;font-weight:600;background:var(--brand-wash);color:var(--ink-soft);
+    }
+    .guest-status.show{display:block}
+    .guest-status.rejected{background:#fef2f2;color:var(--danger)}
+    .cart{
+      position:sticky;bottom:0;z-index:6;background:var(--surface);
+      border-top:1px solid var(--line);padding:12px 16px 16px;display:none;
+      box-shadow:0 -4px 16px rgba(15,23,42,.06);
+    }
+    .cart.show{display:block}
+    .cart-lines{max-height:40vh;overflow-y:auto;margin-bottom:10px;display:none}
+    .cart-lines.open{display:block}
+    .cart-line{display:flex;justify-content:space-between;align-items:center;gap:10px;padding:8px 0;border-bottom:1px solid var(--line)}
+    .cart-line-name{font-weight:700;font-size:.92rem}
+    .qty{display:flex;align-items:center;gap:10px;font-weight:800}
+    .qty button{
+      width:30px;height:30px;border-radius:8px;border:1px solid var(--line);
+      background:var(--bg);font-size:1rem;font-weight:800;cursor:pointer;font-family:inherit;
+    }
+    .cart-bar{display:flex;gap:10px;align-items:center}
+    .cart-summary{flex:1;background:none;border:0;text-align:left;font-family:inherit;
+      font-size:.95rem;font-weight:700;color:var(--ink);cursor:pointer;padding:0}
+    .btn-send{background:var(--brand);color:#fff;width:auto;padding:12px 18px}
+    .btn-send:disabled{opacity:.6;cursor:default}
   </style>
 </head>
 <body>
@@ -167,6 +199,7 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
         <p class="hint">Review your bill and download a copy. Link stays valid for about an hour.</p>
         <a class="download" id="billDownload" href="#">Download bill</a>
       </div>
+      <div class="guest-status" id="guestStatus"></div>
       <div class="menu" id="menuPanel">
         <div class="menu-head">
           <h2>Menu</h2>
@@ -176,6 +209,13 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
         <div id="menuList" class="menu-items menu-loading">Loading menu…</div>
       </div>
     </main>
+    <div class="cart" id="cartPanel">
+      <div class="cart-lines" id="cartLines"></div>
+      <div class="cart-bar">
+        <button class="cart-summary" id="cartSummary" type="button"></button>
+        <button class="btn btn-send" id="sendBtn" type="button">Send order</button>
+      </div>
+    </div>
   </div>
   <script>
     const token = %q;
@@ -195,6 +235,108 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
     const menuList = document.getElementById('menuList');
     const menuCats = document.getElementById('menuCats');
     const menuCount = document.getElementById('menuCount');
+    const guestStatus = document.getElementById('guestStatus');
+    const cartPanel = document.getElementById('cartPanel');
+    const cartLines = document.getElementById('cartLines');
+    const cartSummary = document.getElementById('cartSummary');
+    const sendBtn = document.getElementById('sendBtn');
+    const sentKey = 'bg_guest_orders_' + token;
+    let cart = [];
+    let sending = false;
+    let sentIDs = [];
+    try { sentIDs = JSON.parse(sessionStorage.getItem(sentKey) || '[]'); } catch (e) {}
+
+    function canOrder(){
+      return !!state.guest_ordering && state.menu_visible !== false && !state.bill_available;
+    }
+
+    function addToCart(item, variant){
+      const key = item.id + '|' + (variant ? variant.id : '');
+      const line = cart.find(l => l.key === key);
+      if (line) {
+        line.quantity = Math.min(line.quantity + 1, 20);
+      } else {
+        cart.push({
+          key: key,
+          menu_item_id: item.id,
+          variant_id: variant ? variant.id : '',
+          name: (item.name || 'Item') + (variant && variant.label ? ' (' + variant.label + ')' : ''),
+          price: Number(variant ? variant.price : item.price) || 0,
+          quantity: 1,
+        });
+      }
+      renderCart();
+    }
+
+    function renderCart(){
+      if (!canOrder() || !cart.length) {
+        cartPanel.classList.remove('show');
+        cartLines.classList.remove('open');
+        return;
+      }
+      let count = 0;
+      let total = 0;
+      cartLines.innerHTML = '';
+      cart.forEach(line => {
+        count += line.quantity;
+        total += line.quantity * line.price;
+        const row = document.createElement('div');
+        row.className = 'cart-line';
+        const name = document.createElement('div');
+        name.className = 'cart-line-name';
+        name.textContent = line.name;
+        const qty = document.createElement('div');
+        qty.className = 'qty';
+        const minus = document.createElement('button');
+        minus.type = 'button';
+        minus.textContent = '−';
+        minus.addEventListener('click', () => {
+          line.quantity -= 1;
+          if (line.quantity <= 0) cart = cart.filter(l => l !== line);
+          renderCart();
+        });
+        const n = document.createElement('span');
+        n.textContent = line.quantity;
+        const plus = document.createElement('button');
+        plus.type = 'button';
+        plus.textContent = '+';
+        plus.addEventListener('click', () => {
+          line.quantity = Math.min(line.quantity + 1, 20);
+          renderCart();
+        });
+        qty.appendChild(minus);
+        qty.appendChild(n);
+        qty.appendChild(plus);
+        row.appendChild(name);
+        row.appendChild(qty);
+        cartLines.appendChild(row);
+      });
+      cartSummary.textContent = count + (count === 1 ? ' item' : ' items') + ' · ' + money(total) +
+        (cartLines.classList.contains('open') ? ' ▾' : ' ▸');
+      sendBtn.disabled = sending;
+      cartPanel.classList.add('show');
+    }
+
+    function renderGuestStatus(){
+      const mine = (Array.isArray(state.guest_orders) ? state.guest_orders : [])
+        .filter(o => sentIDs.indexOf(o.id) >= 0);
+      guestStatus.className = 'guest-status';
+      if (!mine.length) {
+        guestStatus.textContent = '';
+        return;
+      }
+      const latest = mine[0];
+      if (latest.status === 'pending') {
+        guestStatus.textContent = 'Your order is waiting for staff to confirm.';
+      } else if (latest.status === 'approved') {
+        guestStatus.textContent = 'Your order has been sent to the kitchen.';
+      } else {
+        guestStatus.textContent = 'Staff could not accept your order' +
+          (latest.reject_reason ? ': ' + latest.reject_reason : '. Please call a waiter.');
+        guestStatus.classList.add('rejected');
+      }
+      guestStatus.classList.add('show');
+    }
 
     function money(n){ return '₹' + Number(n||0).toFixed(2); }
 
@@ -214,7 +356,17 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
         rows.push(['tot-row', subtotalLabel(s), money(s.sub_total)]);
       }
       if (s.show_tax && Number(s.tax_amount) > 0) {
-        rows.push(['tot-row', 'GST (5%%)', money(s.tax_amount)]);
+        const slabs = Array.isArray(s.tax_breakdown) ? s.tax_breakdown : [];
+        if (slabs.length === 0) rows.push(['tot-row', 'GST', money(s.tax_amount)]);
+        slabs.forEach((slab) => {
+          if (Number(slab.igst) > 0) {
+            rows.push(['tot-row', 'IGST @ ' + Number(slab.rate) + '%%', money(slab.igst)]);
+            return;
+          }
+          const half = Number(slab.rate) / 2;
+          rows.push(['tot-row', 'CGST @ ' + half + '%%', money(slab.cgst)]);
+          rows.push(['tot-row', 'SGST @ ' + half + '%%', money(slab.sgst)]);
+        });
       }
       if (Number(s.discount_amount) > 0) {
         rows.push(['tot-row discount', 'Discount', '-' + money(s.discount_amount)]);
@@ -291,15 +443,39 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
         left.appendChild(sub);
       }
       const variants = Array.isArray(item.variants) ? item.variants : [];
-      if (variants.length) {
+      const ordering = canOrder() && item.id;
+      if (variants.length && ordering) {
+        const btns = document.createElement('div');
+        btns.className = 'add-btns';
+        variants.forEach(v => {
+          const btn = document.createElement('button');
+          btn.type = 'button';
+          btn.className = 'add-btn';
+          btn.textContent = '+ ' + (v.label || 'Option') + ' ' + money(v.price);
+          btn.addEventListener('click', () => addToCart(item, v));
+          btns.appendChild(btn);
+        });
+        left.appendChild(btns);
+      } else if (variants.length) {
         const sub = document.createElement('div');
         sub.className = 'menu-row-variants';
         sub.textContent = variants.map(v => (v.label || 'Option') + ' ' + money(v.price)).join(' · ');
         left.appendChild(sub);
       }
       const right = document.createElement('div');
-      right.className = 'menu-row-price';
-      right.textContent = variants.length ? '' : money(item.price);
+      right.className = 'menu-row-side';
+      const price = document.createElement('div');
+      price.className = 'menu-row-price';
+      price.textContent = variants.length ? '' : money(item.price);
+      right.appendChild(price);
+      if (ordering && !variants.length) {
+        const btn = document.createElement('button');
+        btn.type = 'button';
+        btn.className = 'add-btn';
+        btn.textContent = 'Add';
+        btn.addEventListener('click', () => addToCart(item, null));
+        right.appendChild(btn);
+      }
       row.appendChild(left);
       row.appendChild(right);
       return row;
@@ -355,6 +531,8 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
       }
     }
 
+    let wasOrdering = null;
+
     function render(s){
       state = s || state;
       document.getElementById('restaurant').textContent = state.restaurant_name || 'Restaurant';
@@ -423,12 +601,16 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
       } else {
         billPanel.classList.remove('show');
       }
+      const ordering = canOrder();
       if (showMenu) {
         menuPanel.classList.add('show');
-        ensureMenu();
+        if (ordering !== wasOrdering || !menuLoaded) ensureMenu();
       } else {
         menuPanel.classList.remove('show');
       }
+      wasOrdering = ordering;
+      renderGuestStatus();
+      renderCart();
     }
 
     async function refresh(){
@@ -453,6 +635,43 @@ func renderAssistancePageHTML(token string, status services.AssistanceStatus) st
       }
     });
 
+    cartSummary.addEventListener('click', () => {
+      cartLines.classList.toggle('open');
+      renderCart();
+    });
+
+    sendBtn.addEventListener('click', async () => {
+      if (sending || !cart.length) return;
+      sending = true;
+      sendBtn.disabled = true;
+      note.textContent = 'Sending your order…';
+      try {
+        const res = await fetch('/a/' + token + '/order', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({
+            items: cart.map(l => ({ menu_item_id: l.menu_item_id, variant_id: l.variant_id, quantity: l.quantity })),
+          }),
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || 'Could not send your order.');
+        if (data.guest_order && data.guest_order.id) {
+          sentIDs.unshift(data.guest_order.id);
+          sentIDs = sentIDs.slice(0, 10);
+          try { sessionStorage.setItem(sentKey, JSON.stringify(sentIDs)); } catch (e) {}
+        }
+        cart = [];
+        note.textContent = data.message || '';
+        sending = false;
+        if (data.status) render(data.status);
+        else await refresh();
+      } catch (e) {
+        note.textContent = e.message || 'Could not send your order. Please try again.';
+        sending = false;
+        renderCart();
+      }
+    });
+
     render(state);
 
     const pollId = setInterval(() => { refresh(); }, 2500);
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"restaurant-api/internal/services"
@@ -33,6 +34,9 @@ func customerBillStylesBlock() string {
     .row { display: flex; justify-content: space-between; gap: 16px; padding: 5px 0; color: #475569; font-size: .95rem; }
     .row.discount { color: #16a34a; }
     .row.total { margin-top: 10px; padding-top: 12px; border-top: 1px solid #e2e8f0; font-size: 1.2rem; font-weight: 800; color: #0f172a; }
+    .tax-summary { margin-top: 18px; }
+    .tax-summary table { font-size: .78rem; }
+    .tax-summary td { padding: 6px 0; color: #475569; }
     .actions { margin-top: 0; }
     .actions-wrap { margin-top: 20px; }
     .btn { display: flex; width: 100%; align-items: center; justify-content: center; padding: 12px 14px; border-radius: 12px; font-size: .95rem; font-weight: 600; text-decoration: none; }
@@ -84,8 +88,14 @@ func renderCustomerBillPageFragment(summary services.BillSummaryView) string {
 	}
 	taxRow := ""
 	if summary.TaxAmount > 0 && !summary.CompositeScheme {
-		taxRow = fmt.Sprintf(`<div class="row"><span>GST (5%%)</span><span>%s</span></div>`,
-			formatBillCurrency(summary.TaxAmount))
+		if len(summary.TaxBreakdown) == 0 {
+			taxRow = fmt.Sprintf(`<div class="row"><span>GST</span><span>%s</span></div>`,
+				formatBillCurrency(summary.TaxAmount))
+		}
+		for _, line := range services.GSTComponentLines(summary.TaxBreakdown) {
+			taxRow += fmt.Sprintf(`<div class="row"><span>%s</span><span>%s</span></div>`,
+				escapeBillHTML(line.Label), formatBillCurrency(line.Amount))
+		}
 	}
 	discountRow := ""
 	if summary.DiscountAmount > 0 {
@@ -93,7 +103,16 @@ func renderCustomerBillPageFragment(summary services.BillSummaryView) string {
 			formatBillCurrency(summary.DiscountAmount))
 	}
 	paymentRow := ""
-	if summary.IsPaid && summary.PaymentMethod != "" {
+	if summary.IsPaid && len(summary.Tenders) > 0 {
+		for _, tender := range summary.Tenders {
+			label := escapeBillHTML(tender.Label)
+			if tender.Reference != "" {
+				label += ` <small>Ref ` + escapeBillHTML(tender.Reference) + `</small>`
+			}
+			paymentRow += fmt.Sprintf(`<div class="row"><span>%s</span><span>%s</span></div>`,
+				label, formatBillCurrency(tender.Amount))
+		}
+	} else if summary.IsPaid && summary.PaymentMethod != "" {
 		paymentRow = fmt.Sprintf(`<div class="row"><span>Payment</span><span>%s</span></div>`,
 			strings.ToUpper(escapeBillHTML(summary.PaymentMethod)))
 	}
@@ -110,6 +129,13 @@ func renderCustomerBillPageFragment(summary services.BillSummaryView) string {
 	if strings.TrimSpace(summary.GstNumber) != "" {
 		gstLine = fmt.Sprintf(`<p class="meta">GSTIN: %s</p>`, escapeBillHTML(summary.GstNumber))
 	}
+	if inv := strings.TrimSpace(summary.InvoiceNumber); inv != "" {
+		gstLine += fmt.Sprintf(`<p class="meta">Invoice No: %s</p>`, escapeBillHTML(inv))
+	}
+	if summary.PlaceOfSupply != "" && len(summary.TaxSummary) > 0 {
+		gstLine += fmt.Sprintf(`<p class="meta">Place of supply: %s</p>`,
+			escapeBillHTML(services.PlaceOfSupplyLabel(summary.PlaceOfSupply)))
+	}
 
 	meta := buildBillMetaLine(summary)
 	dateLine := formatBillDateTime(summary.CreatedAt)
@@ -151,6 +177,7 @@ func renderCustomerBillPageFragment(summary services.BillSummaryView) string {
           <div class="row total"><span>Total</span><span>%s</span></div>
           %s
         </div>
+        %s
         <p class="footer">Thank you for dining with us.</p>
       </div>
     </div>
@@ -169,9 +196,37 @@ func renderCustomerBillPageFragment(summary services.BillSummaryView) string {
 		discountRow,
 		formatBillCurrency(summary.Total),
 		paymentRow,
+		renderBillTaxSummaryTable(summary),
 	)
 }
 
+// renderBillTaxSummaryTable renders the HSN/SAC-wise GST table shown under the totals.
+func renderBillTaxSummaryTable(summary services.BillSummaryView) string {
+	if len(summary.TaxSummary) == 0 {
+		return ""
+	}
+	var head, rows strings.Builder
+	if summary.InterState {
+		head.WriteString(`<th class="amount">IGST</th>`)
+	} else {
+		head.WriteString(`<th class="amount">CGST</th><th class="amount">SGST</th>`)
+	}
+	for _, row := range summary.TaxSummary {
+		components := fmt.Sprintf(`<td class="amount">%s</td>`, formatBillCurrency(row.IGST))
+		if !summary.InterState {
+			components = fmt.Sprintf(`<td class="amount">%s</td><td class="amount">%s</td>`,
+				formatBillCurrency(row.CGST), formatBillCurrency(row.SGST))
+		}
+		rows.WriteString(fmt.Sprintf(`<tr><td>%s</td><td class="qty">%s%%</td><td class="amount">%s</td>%s</tr>`,
+			escapeBillHTML(row.HSNCode), strconv.FormatFloat(row.Rate, 'f', -1, 64),
+			formatBillCurrency(row.TaxableValue), components))
+	}
+	return fmt.Sprintf(`<div class="tax-summary"><table>
+          <thead><tr><th>HSN/SAC</th><th class="qty">Rate</th><th class="amount">Taxable</th>%s</tr></thead>
+          <tbody>%s</tbody>
+        </table></div>`, head.String(), rows.String())
+}
+
 func renderBillDownloadActions(downloadHref, note string) string {
 	return fmt.Sprintf(`<div class="actions-wrap"><div class="actions"><a class="btn btn-primary" href="%s">Download bill</a></div><p class="note">%s</p></div>`,
 		escapeBillHTML(downloadHref), escapeBillHTML(note))
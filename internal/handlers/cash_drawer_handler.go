@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CashDrawerHandler struct {
+	db           *gorm.DB
+	drawers      *services.CashDrawerService
+	printService *services.PrintService
+}
+
+func NewCashDrawerHandler(db *gorm.DB) *CashDrawerHandler {
+	return &CashDrawerHandler{
+		db:           db,
+		drawers:      services.NewCashDrawerService(db),
+		printService: services.NewPrintService(db),
+	}
+}
+
+type OpenCashSessionRequest struct {
+	OpeningFloat float64 `json:"opening_float"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+type CashMovementRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+type CloseCashSessionRequest struct {
+	CountedCash *float64 `json:"counted_cash" binding:"required"`
+	Notes       string   `json:"notes,omitempty"`
+	Print       bool     `json:"print,omitempty"`
+}
+
+func cashDrawerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCashSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCashSessionClosed), errors.Is(err, services.ErrCashSessionAlreadyOpen):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func contextRole(c *gin.Context) string {
+	if v, ok := c.Get("role"); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// loadSessionForActor returns the session when the caller owns it or is admin/manager.
+func (h *CashDrawerHandler) loadSessionForActor(c *gin.Context, restaurantID string) (*models.CashDrawerSession, bool) {
+	session, err := h.drawers.Get(restaurantID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(cashDrawerErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	role := contextRole(c)
+	if session.CashierUserID != contextUserID(c) && role != "admin" && role != "manager" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this cash drawer belongs to another cashier"})
+		return nil, false
+	}
+	return session, true
+}
+
+// OpenSession opens a drawer for the signed-in cashier.
+func (h *CashDrawerHandler) OpenSession(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	var req OpenCashSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, err := h.drawers.Open(restaurantID, contextUserID(c), req.OpeningFloat, req.Notes)
+	if err != nil {
+		c.JSON(cashDrawerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ Cash drawer opened: %s (float %.2f)", session.ID, session.OpeningFloat)
+	c.JSON(http.StatusCreated, gin.H{"session": session})
+}
+
+// CurrentSession returns the signed-in cashier's open drawer, or null.
+func (h *CashDrawerHandler) CurrentSession(c *gin.Context) {
+	session, err := h.drawers.Current(c.GetString("restaurant_id"), contextUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// GetSession returns one drawer with movements and totals.
+func (h *CashDrawerHandler) GetSession(c *gin.Context) {
+	session, ok := h.loadSessionForActor(c, c.GetString("restaurant_id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+func (h *CashDrawerHandler) recordMovement(c *gin.Context, kind string) {
+	restaurantID := c.GetString("restaurant_id")
+	session, ok := h.loadSessionForActor(c, restaurantID)
+	if !ok {
+		return
+	}
+	var req CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	movement, err := h.drawers.RecordMovement(restaurantID, session.ID, contextUserID(c), kind, req.Amount, req.Reason)
+	if err != nil {
+		c.JSON(cashDrawerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"movement": movement})
+}
+
+// RecordPayout takes cash out of the drawer to pay for something.
+func (h *CashDrawerHandler) RecordPayout(c *gin.Context) {
+	h.recordMovement(c, services.CashMovementPayout)
+}
+
+// RecordDrop moves cash from the drawer to the safe.
+func (h *CashDrawerHandler) RecordDrop(c *gin.Context) {
+	h.recordMovement(c, services.CashMovementDrop)
+}
+
+// CloseSession counts the drawer and freezes expected cash and over/short.
+func (h *CashDrawerHandler) CloseSession(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	session, ok := h.loadSessionForActor(c, restaurantID)
+	if !ok {
+		return
+	}
+	var req CloseCashSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	closed, err := h.drawers.Close(restaurantID, session.ID, contextUserID(c), *req.CountedCash, req.Notes)
+	if err != nil {
+		c.JSON(cashDrawerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ Cash drawer closed: %s (over/short %.2f)", closed.ID, closed.OverShort)
+
+	printQueued := false
+	if req.Print {
+		printQueued, _ = h.printService.EnqueueCashSessionSlip(closed)
+	}
+	c.JSON(http.StatusOK, gin.H{"session": closed, "print_queued": printQueued})
+}
+
+// PrintSession queues the drawer slip on the bill printer.
+func (h *CashDrawerHandler) PrintSession(c *gin.Context) {
+	session, ok := h.loadSessionForActor(c, c.GetString("restaurant_id"))
+	if !ok {
+		return
+	}
+	queued, err := h.printService.EnqueueCashSessionSlip(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !queued {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no bill printer configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cash drawer slip queued", "print_queued": true})
+}
+
+func (h *CashDrawerHandler) dateRange(c *gin.Context, restaurantID string) (services.BusinessCalendar, time.Time, time.Time, bool) {
+	cal := services.LoadBusinessCalendar(h.db, restaurantID)
+	from, toEnd, err := services.ParseHistoryDateRange(c.Query("from"), c.Query("to"), cal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return cal, time.Time{}, time.Time{}, false
+	}
+	return cal, from, toEnd, true
+}
+
+// ListSessions returns drawers opened in the date range (admin/manager).
+func (h *CashDrawerHandler) ListSessions(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	_, from, toEnd, ok := h.dateRange(c, restaurantID)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	status := strings.TrimSpace(c.Query("status"))
+	if status != "" && status != services.CashSessionOpen && status != services.CashSessionClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or closed"})
+		return
+	}
+	sessions, total, err := h.drawers.List(restaurantID, services.CashSessionFilter{
+		Status:        status,
+		CashierUserID: strings.TrimSpace(c.Query("cashier_user_id")),
+		From:          from,
+		ToEnd:         toEnd,
+	}, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// CashierReport returns over/short per cashier for drawers closed in the date range (admin/manager).
+func (h *CashDrawerHandler) CashierReport(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	cal, from, toEnd, ok := h.dateRange(c, restaurantID)
+	if !ok {
+		return
+	}
+	rows, err := h.drawers.CashierReport(restaurantID, from, toEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fromDate, toDate := cal.HistoryRangeDates(from, toEnd)
+	c.JSON(http.StatusOK, gin.H{
+		"cashiers": rows,
+		"from":     fromDate,
+		"to":       toDate,
+		"currency": "INR",
+	})
+}
+
+// SetupCashDrawerRoutes registers cash drawer endpoints. Cashiers manage their own drawer;
+// admins and managers can see and close any drawer.
+func SetupCashDrawerRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewCashDrawerHandler(db)
+
+	protected := router.Group("/cash-sessions")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	protected.Use(middleware.RoleMiddleware("admin", "manager", "staff"))
+	{
+		protected.POST("/open", handler.OpenSession)
+		protected.GET("/current", handler.CurrentSession)
+		protected.GET("", middleware.RoleMiddleware("admin", "manager"), handler.ListSessions)
+		protected.GET("/report", middleware.RoleMiddleware("admin", "manager"), handler.CashierReport)
+		protected.GET("/:session_id", handler.GetSession)
+		protected.POST("/:session_id/payouts", handler.RecordPayout)
+		protected.POST("/:session_id/drops", handler.RecordDrop)
+		protected.POST("/:session_id/close", handler.CloseSession)
+		protected.POST("/:session_id/print", handler.PrintSession)
+	}
+
+	log.Println("✅ Cash drawer routes registered")
+}
@@ -40,9 +40,8 @@ type ExpenseLine struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-func parseYearMonth(c *gin.Context) (int, int, time.Time, time.Time, error) {
-	loc := services.RestaurantLocation()
-	now := time.Now().In(loc)
+func parseYearMonth(c *gin.Context, cal services.BusinessCalendar) (int, int, time.Time, time.Time, error) {
+	now := cal.BusinessDate(time.Now())
 	year := now.Year()
 	month := int(now.Month())
 
@@ -61,11 +60,12 @@ func parseYearMonth(c *gin.Context) (int, int, time.Time, time.Time, error) {
 		month = parsed
 	}
 
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
-	end := start.AddDate(0, 1, 0)
+	// Month bounds follow the business day, so a 01:00 sale before a 03:00 cutoff stays in the prior day's month.
+	start := cal.DayStart(year, time.Month(month), 1)
+	end := cal.DayStart(year, time.Month(month)+1, 1)
 	// Current month: include through end of today (exclusive end = start of tomorrow).
 	if year == now.Year() && month == int(now.Month()) {
-		end = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+		end = cal.DayStart(now.Year(), now.Month(), now.Day()+1)
 	}
 	return year, month, start, end, nil
 }
@@ -79,10 +79,9 @@ type apiError struct{ msg string }
 
 func (e *apiError) Error() string { return e.msg }
 
-func monthPeriodLabel(year, month int) string {
-	loc := services.RestaurantLocation()
-	now := time.Now().In(loc)
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+func monthPeriodLabel(year, month int, cal services.BusinessCalendar) string {
+	now := cal.BusinessDate(time.Now())
+	start := cal.DayStart(year, time.Month(month), 1)
 	if year == now.Year() && month == int(now.Month()) {
 		return start.Format("January 2006") + " · until today"
 	}
@@ -150,7 +149,20 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 		Amount:       req.Amount,
 		CreatedBy:    contextUserID(c),
 	}
-	if err := h.db.Create(&expense).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&expense).Error; err != nil {
+			return err
+		}
+		return services.RecordTransaction(tx, models.Transaction{
+			RestaurantID:    restaurantID,
+			Amount:          expense.Amount,
+			TransactionType: services.TransactionTypeExpense,
+			UserID:          expense.CreatedBy,
+			ReferenceID:     expense.ID,
+			Notes:           expense.Name,
+		})
+	})
+	if err != nil {
 		log.Printf("❌ Create expense failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save expense"})
 		return
@@ -169,7 +181,8 @@ func (h *ExpenseHandler) ListExpenses(c *gin.Context) {
 		return
 	}
 
-	year, month, start, end, err := parseYearMonth(c)
+	cal := services.LoadBusinessCalendar(h.db, restaurantID)
+	year, month, start, end, err := parseYearMonth(c, cal)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -193,7 +206,7 @@ func (h *ExpenseHandler) ListExpenses(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"year":             year,
 		"month":            month,
-		"period_label":     monthPeriodLabel(year, month),
+		"period_label":     monthPeriodLabel(year, month, cal),
 		"expenses":         manual,
 		"manual_total":     manualTotal,
 		"stock_total":      0,
@@ -212,13 +225,31 @@ func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
 	}
 
 	id := c.Param("expense_id")
-	res := h.db.Where("id = ? AND restaurant_id = ?", id, restaurantID).Delete(&models.Expense{})
-	if res.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+	var expense models.Expense
+	if err := h.db.Where("id = ? AND restaurant_id = ?", id, restaurantID).First(&expense).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if res.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+	// The ledger is append-only: deleting an expense books a reversal instead of erasing history.
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&expense).Error; err != nil {
+			return err
+		}
+		return services.RecordTransaction(tx, models.Transaction{
+			RestaurantID:    restaurantID,
+			Amount:          expense.Amount,
+			TransactionType: services.TransactionTypeExpenseReversal,
+			UserID:          contextUserID(c),
+			ReferenceID:     expense.ID,
+			Notes:           expense.Name,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted"})
@@ -231,7 +262,8 @@ func (h *ExpenseHandler) SettleReport(c *gin.Context) {
 		return
 	}
 
-	year, month, start, end, err := parseYearMonth(c)
+	cal := services.LoadBusinessCalendar(h.db, restaurantID)
+	year, month, start, end, err := parseYearMonth(c, cal)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -252,6 +284,13 @@ func (h *ExpenseHandler) SettleReport(c *gin.Context) {
 		return
 	}
 
+	refunds, err := h.orderService.RefundTotalsForRange(restaurantID, start.UTC(), end.UTC(), "all")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	netRevenue := revenue - refunds.Amount
+
 	var restaurant models.Restaurant
 	_ = h.db.Select("id", "name").Where("id = ?", restaurantID).First(&restaurant).Error
 
@@ -271,7 +310,7 @@ func (h *ExpenseHandler) SettleReport(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"year":                year,
 		"month":               month,
-		"period_label":        monthPeriodLabel(year, month),
+		"period_label":        monthPeriodLabel(year, month, cal),
 		"period_start":        start.Format(time.RFC3339),
 		"period_end":          end.Format(time.RFC3339),
 		"restaurant_name":     restaurant.Name,
@@ -282,7 +321,10 @@ func (h *ExpenseHandler) SettleReport(c *gin.Context) {
 		"total_orders":        orders,
 		"total_revenue":       revenue,
 		"average_order_value": aov,
-		"net":                 revenue - totalExpenses,
+		"refund_count":        refunds.Count,
+		"refunds":             refunds.Amount,
+		"net_revenue":         netRevenue,
+		"net":                 netRevenue - totalExpenses,
 		"top_items":           topItems,
 		"expense_lines":       lines,
 		"generated_at":        time.Now().UTC().Format(time.RFC3339),
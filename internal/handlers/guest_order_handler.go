@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GuestOrderHandler lets floor staff review carts guests send from the table QR page.
+type GuestOrderHandler struct {
+	db           *gorm.DB
+	orderService *services.OrderService
+	printService *services.PrintService
+	guestOrders  *services.GuestOrderService
+}
+
+func NewGuestOrderHandler(db *gorm.DB) *GuestOrderHandler {
+	orderService := services.NewOrderService(db)
+	return &GuestOrderHandler{
+		db:           db,
+		orderService: orderService,
+		printService: services.NewPrintService(db),
+		guestOrders:  services.NewGuestOrderService(db, orderService),
+	}
+}
+
+func guestOrderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrGuestOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGuestOrderDecided),
+		errors.Is(err, services.ErrGuestOrderBillPending),
+		errors.Is(err, services.ErrGuestOrderTooMany):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrGuestOrderingOff):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// announceGuestOrderApproval broadcasts an approved guest cart like a staff order and
+// sends its batch to the kitchen.
+func announceGuestOrderApproval(db *gorm.DB, orderService *services.OrderService, printService *services.PrintService, result *services.GuestOrderApproval) {
+	if result == nil || result.Order == nil {
+		return
+	}
+	restaurantID := result.Order.RestaurantID
+	if globalHub != nil || globalPublisher != nil {
+		eventType := "order_updated"
+		if result.NewOrder {
+			eventType = "order_created"
+		}
+		BroadcastOrderEvent(globalHub, restaurantID, eventType, result.Order)
+		BroadcastIngredientInventoryUpdates(globalHub, restaurantID, result.Ingredients)
+		if result.NewOrder && result.Table != nil {
+			BroadcastTableUpdate(globalHub, restaurantID, result.Table)
+		}
+		BroadcastGuestOrderEvent(globalHub, restaurantID, "guest_order_approved", result.Request)
+	}
+	if printService != nil {
+		printService.EnqueueKOTForOrder(result.Order, !result.NewOrder)
+	}
+	NotifyAssistanceUpdateByTableID(db, orderService, result.Request.TableID)
+}
+
+// ListGuestOrders returns guest carts; ?status=pending (default), approved, rejected or all.
+func (h *GuestOrderHandler) ListGuestOrders(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	requests, err := h.guestOrders.List(c.GetString("restaurant_id"), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load guest orders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"guest_orders": requests})
+}
+
+// ApproveGuestOrder adds the cart to the table's order and prints its KOT.
+func (h *GuestOrderHandler) ApproveGuestOrder(c *gin.Context) {
+	result, err := h.guestOrders.Approve(c.GetString("restaurant_id"), c.Param("request_id"), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(guestOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	announceGuestOrderApproval(h.db, h.orderService, h.printService, result)
+	c.JSON(http.StatusOK, gin.H{
+		"guest_order": result.Request,
+		"order_id":    result.Order.ID,
+		"new_order":   result.NewOrder,
+	})
+}
+
+// RejectGuestOrder declines the cart; the optional reason is shown to the guest.
+func (h *GuestOrderHandler) RejectGuestOrder(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	req, err := h.guestOrders.Reject(c.GetString("restaurant_id"), c.Param("request_id"), c.GetString("user_id"), input.Reason)
+	if err != nil {
+		c.JSON(guestOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	BroadcastGuestOrderEvent(globalHub, req.RestaurantID, "guest_order_rejected", req)
+	NotifyAssistanceUpdateByTableID(h.db, h.orderService, req.TableID)
+	c.JSON(http.StatusOK, gin.H{"guest_order": req})
+}
+
+// SetupGuestOrderRoutes registers staff review of QR table orders.
+func SetupGuestOrderRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewGuestOrderHandler(db)
+
+	protected := router.Group("/guest-orders")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	protected.Use(middleware.RoleMiddleware("admin", "manager", "staff"))
+	{
+		protected.GET("", handler.ListGuestOrders)
+		protected.POST("/:request_id/approve", handler.ApproveGuestOrder)
+		protected.POST("/:request_id/reject", handler.RejectGuestOrder)
+	}
+
+	log.Println("✅ Guest order routes registered")
+}
@@ -604,11 +604,12 @@ func contextUserID(c *gin.Context) string {
 }
 
 // applyRestockItems adds stock for each item in a single transaction and returns updated rows.
-// Purchase price / stock expenditure is no longer recorded on restock.
-func (h *IngredientHandler) applyRestockItems(restaurantID, _createdBy string, items []RestockItem) ([]models.Ingredient, float64, error) {
+// Lines with a purchase price are written to the transaction ledger; the returned float is their total.
+func (h *IngredientHandler) applyRestockItems(restaurantID, createdBy string, items []RestockItem) ([]models.Ingredient, float64, error) {
 	type qtyLine struct {
-		id  string
-		qty float64
+		id    string
+		qty   float64
+		price float64
 	}
 
 	// Load ingredients first so we can convert entry units → inventory units.
@@ -652,14 +653,20 @@ func (h *IngredientHandler) applyRestockItems(restaurantID, _createdBy string, i
 		if err != nil {
 			return nil, 0, fmt.Errorf("ingredient %s: %w", id, err)
 		}
+		price := item.Price
+		if price < 0 {
+			price = 0
+		}
 		if idx, exists := indexByID[id]; exists {
 			ordered[idx].qty += qty
+			ordered[idx].price += price
 			continue
 		}
 		indexByID[id] = len(ordered)
 		ordered = append(ordered, qtyLine{
-			id:  id,
-			qty: qty,
+			id:    id,
+			qty:   qty,
+			price: price,
 		})
 	}
 	if len(ordered) == 0 {
@@ -667,6 +674,7 @@ func (h *IngredientHandler) applyRestockItems(restaurantID, _createdBy string, i
 	}
 
 	updated := make([]models.Ingredient, 0, len(ordered))
+	expenditure := 0.0
 	err := h.db.Transaction(func(tx *gorm.DB) error {
 		for _, line := range ordered {
 			var ingredient models.Ingredient
@@ -685,13 +693,26 @@ func (h *IngredientHandler) applyRestockItems(restaurantID, _createdBy string, i
 				return err
 			}
 			updated = append(updated, ingredient)
+			if line.price > 0 {
+				if err := services.RecordTransaction(tx, models.Transaction{
+					RestaurantID:    restaurantID,
+					Amount:          line.price,
+					TransactionType: services.TransactionTypeRestock,
+					UserID:          createdBy,
+					ReferenceID:     ingredient.ID,
+					Notes:           fmt.Sprintf("%s +%.3f %s", ingredient.Name, line.qty, ingredient.Unit),
+				}); err != nil {
+					return err
+				}
+				expenditure += line.price
+			}
 		}
 		return nil
 	})
 	if err != nil {
 		return nil, 0, err
 	}
-	return updated, 0, nil
+	return updated, expenditure, nil
 }
 
 // RestockIngredient adds quantity to current_stock (refill) for one ingredient.
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// KDSHandler serves the kitchen display: open tickets by batch and station, bump and recall.
+type KDSHandler struct {
+	db           *gorm.DB
+	orderService *services.OrderService
+	kds          *services.KDSService
+}
+
+func NewKDSHandler(db *gorm.DB) *KDSHandler {
+	return &KDSHandler{
+		db:           db,
+		orderService: services.NewOrderService(db),
+		kds:          services.NewKDSService(db),
+	}
+}
+
+func kdsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrKDSTicketNotFound),
+		errors.Is(err, services.ErrKDSNothingToRecall):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKDSOrderClosed):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// announceKDSChange pushes a bump or recall to every screen like any other kitchen
+// status change: item statuses, counter auto-completion, order tracking and push alerts.
+func announceKDSChange(db *gorm.DB, orderService *services.OrderService, eventType, status string, change *services.KDSChange) {
+	order, bump := change.Order, change.Bump
+	if status == "ready" {
+		if completed, didComplete, err := orderService.TryCompleteCounterOrderAfterKitchen(order.RestaurantID, order.ID); err == nil && didComplete {
+			BroadcastOrderEvent(globalHub, order.RestaurantID, "order_completed", completed)
+		} else {
+			BroadcastOrderItemStatusEvent(globalHub, order.RestaurantID, order, "", "", true)
+		}
+		notifyOrderItemStatusPush(db, order.RestaurantID, order, status)
+	} else {
+		BroadcastOrderItemStatusEvent(globalHub, order.RestaurantID, order, "", "", true)
+	}
+	NotifyOrderTrackingUpdate(orderService, order.ID, order.RestaurantID)
+
+	itemIDs := make([]string, 0, len(bump.Items))
+	for _, item := range bump.Items {
+		itemIDs = append(itemIDs, item.ItemID)
+	}
+	BroadcastKDSTicketEvent(globalHub, order.RestaurantID, eventType, models.KDSTicketEventData{
+		BumpID:      bump.ID,
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		TableNo:     order.TableNumber,
+		SubID:       bump.SubID,
+		StationID:   bump.StationID,
+		ItemIDs:     itemIDs,
+	})
+}
+
+// GetBoard returns open tickets oldest first with SLA colours and all-day counts.
+// ?station=<station id | main> limits it to one station.
+func (h *KDSHandler) GetBoard(c *gin.Context) {
+	board, err := h.kds.Board(c.GetString("restaurant_id"), c.Query("station"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load kitchen tickets"})
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// BumpTicket marks every line of a ticket still to cook as ready.
+func (h *KDSHandler) BumpTicket(c *gin.Context) {
+	var key services.KDSTicketKey
+	if err := c.ShouldBindJSON(&key); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	restaurantID := c.GetString("restaurant_id")
+	if err := services.EnforceKitchenUpdate(h.db, restaurantID, key.OrderID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := h.kds.Bump(restaurantID, c.GetString("user_id"), key)
+	if err != nil {
+		c.JSON(kdsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ KDS bump: order #%d batch %q station %s (%d items)", change.Order.OrderNumber, change.Bump.SubID, change.Bump.StationID, len(change.Bump.Items))
+	announceKDSChange(h.db, h.orderService, "kds_ticket_bumped", "ready", change)
+	c.JSON(http.StatusOK, gin.H{"bump": change.Bump})
+}
+
+// RecallTicket undoes the most recent bump, on one station when station_id is given.
+func (h *KDSHandler) RecallTicket(c *gin.Context) {
+	var input struct {
+		StationID string `json:"station_id"`
+	}
+	_ = c.ShouldBindJSON(&input)
+
+	change, err := h.kds.Recall(c.GetString("restaurant_id"), input.StationID)
+	if err != nil {
+		c.JSON(kdsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ KDS recall: order #%d batch %q station %s", change.Order.OrderNumber, change.Bump.SubID, change.Bump.StationID)
+	announceKDSChange(h.db, h.orderService, "kds_ticket_recalled", "", change)
+	c.JSON(http.StatusOK, gin.H{"bump": change.Bump})
+}
+
+// SetupKDSRoutes registers the kitchen display endpoints.
+func SetupKDSRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewKDSHandler(db)
+
+	protected := router.Group("/kds")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	protected.Use(middleware.RoleMiddleware("admin", "manager", "chef", "staff"))
+	{
+		protected.GET("", handler.GetBoard)
+		protected.POST("/tickets/bump", handler.BumpTicket)
+		protected.POST("/recall", handler.RecallTicket)
+	}
+
+	log.Println("✅ KDS routes registered")
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type KitchenStationHandler struct {
+	stations     *services.KitchenStationService
+	printService *services.PrintService
+}
+
+func NewKitchenStationHandler(db *gorm.DB) *KitchenStationHandler {
+	return &KitchenStationHandler{
+		stations:     services.NewKitchenStationService(db),
+		printService: services.NewPrintService(db),
+	}
+}
+
+func kitchenStationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrKitchenStationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKitchenStationExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ListStations returns kitchen stations with their category/item rules.
+func (h *KitchenStationHandler) ListStations(c *gin.Context) {
+	stations, err := h.stations.List(c.GetString("restaurant_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stations": stations})
+}
+
+// CreateStation adds a named station with its own KOT printer.
+func (h *KitchenStationHandler) CreateStation(c *gin.Context) {
+	var input services.KitchenStationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	station, err := h.stations.Create(c.GetString("restaurant_id"), input)
+	if err != nil {
+		c.JSON(kitchenStationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ Kitchen station created: %s (%s)", station.Name, station.ID)
+	c.JSON(http.StatusCreated, gin.H{"station": station})
+}
+
+// UpdateStation patches a station's name, printer, paper width or order.
+func (h *KitchenStationHandler) UpdateStation(c *gin.Context) {
+	var input services.KitchenStationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	station, err := h.stations.Update(c.GetString("restaurant_id"), c.Param("station_id"), input)
+	if err != nil {
+		c.JSON(kitchenStationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"station": station})
+}
+
+// DeleteStation removes a station; its items print on the main KOT printer again.
+func (h *KitchenStationHandler) DeleteStation(c *gin.Context) {
+	if err := h.stations.Delete(c.GetString("restaurant_id"), c.Param("station_id")); err != nil {
+		c.JSON(kitchenStationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Kitchen station deleted"})
+}
+
+// SetStationRules replaces the menu categories and items routed to a station.
+func (h *KitchenStationHandler) SetStationRules(c *gin.Context) {
+	var input services.KitchenStationRulesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	station, err := h.stations.SetRules(c.GetString("restaurant_id"), c.Param("station_id"), input)
+	if err != nil {
+		c.JSON(kitchenStationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"station": station})
+}
+
+// TestStationPrint queues a test slip on the station's printer.
+func (h *KitchenStationHandler) TestStationPrint(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	station, err := h.stations.Get(restaurantID, c.Param("station_id"))
+	if err != nil {
+		c.JSON(kitchenStationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	queued, err := h.printService.EnqueueStationTestPrint(restaurantID, station)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "queued": false})
+		return
+	}
+	if !queued {
+		c.JSON(http.StatusOK, gin.H{
+			"queued":  false,
+			"message": "station printer host is not set and there is no main KOT printer",
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"queued": true, "message": "Test print queued. Keep the print agent running."})
+}
+
+// SetupKitchenStationRoutes registers kitchen station and KOT routing endpoints.
+func SetupKitchenStationRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewKitchenStationHandler(db)
+
+	protected := router.Group("/restaurants/kitchen-stations")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	{
+		protected.GET("", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), handler.ListStations)
+		protected.POST("", middleware.RoleMiddleware("admin", "manager"), handler.CreateStation)
+		protected.PUT("/:station_id", middleware.RoleMiddleware("admin", "manager"), handler.UpdateStation)
+		protected.DELETE("/:station_id", middleware.RoleMiddleware("admin", "manager"), handler.DeleteStation)
+		protected.PUT("/:station_id/rules", middleware.RoleMiddleware("admin", "manager"), handler.SetStationRules)
+		protected.POST("/:station_id/test", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), handler.TestStationPrint)
+	}
+
+	log.Println("✅ Kitchen station routes registered")
+}
@@ -28,6 +28,8 @@ type CreateMenuItemRequest struct {
 	IsVeg             bool                    `json:"is_veg"`
 	ReadilyAvailable  bool                    `json:"readily_available"`
 	IsTaxable         *bool                   `json:"is_taxable"`
+	GSTRate           *float64                `json:"gst_rate"`
+	HSNCode           *string                 `json:"hsn_code"`
 	AvailableChannels []string                `json:"available_channels"`
 	ChannelPrices     map[string]float64      `json:"channel_prices"`
 	Variants          []services.VariantInput `json:"variants"`
@@ -43,6 +45,8 @@ type UpdateMenuItemRequest struct {
 	IsAvailable       *bool                    `json:"is_available"`
 	ReadilyAvailable  *bool                    `json:"readily_available"`
 	IsTaxable         *bool                    `json:"is_taxable"`
+	GSTRate           *float64                 `json:"gst_rate"`
+	HSNCode           *string                  `json:"hsn_code"`
 	AvailableChannels *[]string                `json:"available_channels"`
 	ChannelPrices     *map[string]float64      `json:"channel_prices"`
 	Variants          *[]services.VariantInput `json:"variants"`
@@ -75,6 +79,8 @@ func isAvailabilityOnlyUpdate(req UpdateMenuItemRequest) bool {
 		req.IsVeg == nil &&
 		req.ReadilyAvailable == nil &&
 		req.IsTaxable == nil &&
+		req.GSTRate == nil &&
+		req.HSNCode == nil &&
 		req.AvailableChannels == nil &&
 		req.ChannelPrices == nil &&
 		req.Variants == nil
@@ -121,10 +127,27 @@ func (h *MenuHandler) CreateMenuItem(c *gin.Context) {
 		IsAvailable:      true,
 		ReadilyAvailable: req.ReadilyAvailable,
 		IsTaxable:        true,
+		GSTRate:          services.DefaultGSTRate,
 	}
 	if req.IsTaxable != nil {
 		menuItem.IsTaxable = *req.IsTaxable
 	}
+	if req.GSTRate != nil {
+		rate, err := services.NormalizeGSTRate(*req.GSTRate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		menuItem.GSTRate = rate
+	}
+	if req.HSNCode != nil {
+		code, err := services.NormalizeHSNCode(*req.HSNCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		menuItem.HSNCode = code
+	}
 	channels, err := services.NormalizeMenuAvailableChannels(req.AvailableChannels, true)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -360,6 +383,22 @@ func (h *MenuHandler) UpdateMenuItem(c *gin.Context) {
 	if req.IsTaxable != nil {
 		updates["is_taxable"] = *req.IsTaxable
 	}
+	if req.GSTRate != nil {
+		rate, err := services.NormalizeGSTRate(*req.GSTRate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["gst_rate"] = rate
+	}
+	if req.HSNCode != nil {
+		code, err := services.NormalizeHSNCode(*req.HSNCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["hsn_code"] = code
+	}
 
 	channelsForPrices := item.AvailableChannels
 	if req.AvailableChannels != nil {
@@ -342,6 +342,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 			"cash_amount":     order.CashAmount,
 			"upi_amount":           order.UpiAmount,
 			"upi_transaction_id":   order.UpiTransactionID,
+			"tenders":              order.Tenders,
 			"attended_by_user_id":  order.AttendedByUserID,
 			"attended_by_name":     services.AttendedByName(order),
 			"notes":                order.Notes,
@@ -664,14 +665,15 @@ func (h *OrderHandler) clampSalesRangeQuery(c *gin.Context, restaurantID string,
 	if *fromStr == "" || *toStr == "" {
 		return fmt.Errorf("from and to are required for a custom date range")
 	}
-	from, _, err := services.ParseHistoryDateRange(*fromStr, *toStr)
-	if err != nil {
-		return err
-	}
 	var restaurant models.Restaurant
 	if err := h.orderService.GetDB().Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
 		return fmt.Errorf("failed to load restaurant")
 	}
+	cal := services.BusinessCalendarForRestaurant(&restaurant)
+	from, _, err := services.ParseHistoryDateRange(*fromStr, *toStr, cal)
+	if err != nil {
+		return err
+	}
 	limits, err := services.LoadSubscriptionLimits(h.orderService.GetDB(), &restaurant)
 	if err != nil {
 		return fmt.Errorf("failed to load subscription")
@@ -683,7 +685,7 @@ func (h *OrderHandler) clampSalesRangeQuery(c *gin.Context, restaurantID string,
 	}
 	capped := services.SubscriptionLimits{HistoryDays: maxDays}
 	clamped := services.ClampHistoryFrom(capped, from)
-	*fromStr = clamped.In(services.RestaurantLocation()).Format("2006-01-02")
+	*fromStr = cal.BusinessDate(clamped).Format("2006-01-02")
 	_ = c
 	return nil
 }
@@ -706,17 +708,17 @@ func (h *OrderHandler) ListOrderHistory(c *gin.Context) {
 		limit = 100
 	}
 
-	from, toEnd, err := services.ParseHistoryDateRange(fromStr, toStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
 	var restaurant models.Restaurant
 	if err := h.orderService.GetDB().Where("id = ?", restaurantID.(string)).First(&restaurant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load restaurant"})
 		return
 	}
+
+	from, toEnd, err := services.ParseHistoryDateRange(fromStr, toStr, services.BusinessCalendarForRestaurant(&restaurant))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	limits, err := services.LoadSubscriptionLimits(h.orderService.GetDB(), &restaurant)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load subscription"})
@@ -775,6 +777,7 @@ func (h *OrderHandler) ListOrderHistory(c *gin.Context) {
 		ChangeReturned  float64             `json:"change_returned,omitempty"`
 		CashAmount      float64             `json:"cash_amount,omitempty"`
 		UpiAmount       float64             `json:"upi_amount,omitempty"`
+		Tenders         []models.OrderTender `json:"tenders,omitempty"`
 		Notes           string              `json:"notes"`
 		CreatedAt       time.Time           `json:"created_at"`
 		UpdatedAt       time.Time           `json:"updated_at"`
@@ -827,6 +830,7 @@ func (h *OrderHandler) ListOrderHistory(c *gin.Context) {
 			ChangeReturned: order.ChangeReturned,
 			CashAmount:     order.CashAmount,
 			UpiAmount:      order.UpiAmount,
+			Tenders:        order.Tenders,
 			Notes:          order.Notes,
 			CreatedAt:      order.CreatedAt,
 			UpdatedAt:      order.UpdatedAt,
@@ -925,14 +929,20 @@ func (h *OrderHandler) CompleteOrderWithPayment(c *gin.Context) {
 	orderID := c.Param("order_id")
 
 	var input struct {
-		PaymentMethod    string   `json:"payment_method" binding:"required"` // cash | upi | split
+		PaymentMethod    string   `json:"payment_method"` // cash | upi | card | wallet | split; optional with tenders
 		AmountReceived   float64  `json:"amount_received,omitempty"`
 		ChangeReturned   float64  `json:"change_returned,omitempty"`
 		CashAmount       float64  `json:"cash_amount,omitempty"`
 		UpiAmount        float64  `json:"upi_amount,omitempty"`
 		UpiTransactionID string   `json:"upi_transaction_id,omitempty"`
+		// Reference/Provider describe a single card or wallet payment (approval code, wallet name).
+		Reference string `json:"reference,omitempty"`
+		Provider  string `json:"provider,omitempty"`
+		// Tenders settles the bill with any mix of methods; amounts must add up to the total.
+		Tenders []services.PaymentTender `json:"tenders,omitempty"`
 		AttendedByUserID string   `json:"attended_by_user_id,omitempty"`
 		DiscountAmount   *float64 `json:"discount_amount,omitempty"`
+		PlaceOfSupply    *string  `json:"place_of_supply,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -942,23 +952,31 @@ func (h *OrderHandler) CompleteOrderWithPayment(c *gin.Context) {
 	}
 	log.Printf("   Payment Data: Method=%s, Received=[redacted], Change=[redacted]", input.PaymentMethod)
 
-	// Validate payment method
-	if input.PaymentMethod != "cash" && input.PaymentMethod != "upi" && input.PaymentMethod != "split" {
-		log.Printf("❌ [Handler] Invalid payment method: %s", input.PaymentMethod)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_method must be 'cash', 'upi', or 'split'"})
-		return
-	}
+	input.PaymentMethod = strings.ToLower(strings.TrimSpace(input.PaymentMethod))
+	if len(input.Tenders) == 0 {
+		// Validate payment method
+		if input.PaymentMethod != "split" && !services.IsTenderMethod(input.PaymentMethod) {
+			log.Printf("❌ [Handler] Invalid payment method: %s", input.PaymentMethod)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "payment_method must be 'cash', 'upi', 'card', 'wallet', or 'split' (or send tenders)"})
+			return
+		}
 
-	// For cash payments, amount_received is required
-	if input.PaymentMethod == "cash" && input.AmountReceived == 0 {
-		log.Printf("❌ [Handler] Cash payment missing amount_received")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "amount_received is required for cash payments"})
-		return
-	}
+		// For cash payments, amount_received is required
+		if input.PaymentMethod == "cash" && input.AmountReceived == 0 {
+			log.Printf("❌ [Handler] Cash payment missing amount_received")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount_received is required for cash payments"})
+			return
+		}
+
+		if input.PaymentMethod == "split" {
+			if input.CashAmount <= 0 || input.UpiAmount <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "cash_amount and upi_amount are required for split payments"})
+				return
+			}
+		}
 
-	if input.PaymentMethod == "split" {
-		if input.CashAmount <= 0 || input.UpiAmount <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "cash_amount and upi_amount are required for split payments"})
+		if (input.PaymentMethod == "card" || input.PaymentMethod == "wallet") && strings.TrimSpace(input.Reference) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reference is required for card and wallet payments"})
 			return
 		}
 	}
@@ -976,13 +994,18 @@ func (h *OrderHandler) CompleteOrderWithPayment(c *gin.Context) {
 
 	// Complete the order with payment details
 	paymentDetails := services.OrderPaymentDetails{
+		RecordedByUserID: contextUserID(c),
 		PaymentMethod:    input.PaymentMethod,
 		AmountReceived:   input.AmountReceived,
 		ChangeReturned:   input.ChangeReturned,
 		CashAmount:       input.CashAmount,
 		UpiAmount:        input.UpiAmount,
 		UpiTransactionID: strings.TrimSpace(input.UpiTransactionID),
+		Reference:        input.Reference,
+		Provider:         input.Provider,
+		Tenders:          input.Tenders,
 		AttendedByUserID: attendedByUserID,
+		PlaceOfSupply:    input.PlaceOfSupply,
 	}
 	if input.DiscountAmount != nil {
 		paymentDetails.HasDiscount = true
@@ -1001,7 +1024,7 @@ func (h *OrderHandler) CompleteOrderWithPayment(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ [Handler] Order #%d completed with %s payment. Response:", order.OrderNumber, input.PaymentMethod)
+	log.Printf("✅ [Handler] Order #%d completed with %s payment. Response:", order.OrderNumber, order.PaymentMethod)
 
 	if h.checkoutLock != nil {
 		h.checkoutLock.ForceRelease(orderID)
@@ -1047,11 +1070,13 @@ func (h *OrderHandler) CompleteOrderWithPayment(c *gin.Context) {
 			"discount_amount":     order.DiscountAmount,
 			"total":               order.Total,
 			"payment_method":      order.PaymentMethod,
+			"invoice_number":      order.InvoiceNumber,
 			"amount_received":     order.AmountReceived,
 			"change_returned":     order.ChangeReturned,
 			"cash_amount":         order.CashAmount,
 			"upi_amount":          order.UpiAmount,
 			"upi_transaction_id":  order.UpiTransactionID,
+			"tenders":             order.Tenders,
 			"attended_by_user_id": order.AttendedByUserID,
 			"attended_by_name":    services.AttendedByName(order),
 			"completed_at":        order.CompletedAt,
@@ -1256,6 +1281,93 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	})
 }
 
+// RefundOrder issues a credit note against a paid order (full or per line)
+// @Summary Refund order
+// @Description Refund a completed order in full or per line; writes a credit note and restocks where applicable
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Param request body services.RefundRequest true "Refund request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /orders/:order_id/refund [post]
+func (h *OrderHandler) RefundOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	restaurantID, exists := c.Get("restaurant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "restaurant info not found"})
+		return
+	}
+
+	var req services.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID := c.Param("order_id")
+	result, err := h.orderService.RefundOrder(restaurantID.(string), orderID, userID.(string), req)
+	if err != nil {
+		log.Printf("❌ Order refund failed: %v", err)
+		status := http.StatusBadRequest
+		if err.Error() == "order not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := result.Order
+	note := result.CreditNote
+	BroadcastRefundEvent(globalHub, restaurantID.(string), models.RefundEventData{
+		OrderID:          order.ID,
+		OrderNumber:      order.OrderNumber,
+		InvoiceNumber:    order.InvoiceNumber,
+		CreditNoteID:     note.ID,
+		CreditNoteNumber: note.CreditNoteNumber,
+		Amount:           note.Total,
+		RefundedAmount:   order.RefundedAmount,
+		FullyRefunded:    note.FullRefund,
+		RefundMethod:     note.RefundMethod,
+	})
+	BroadcastIngredientInventoryUpdates(globalHub, restaurantID.(string), result.RestoredIngredients)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Refund recorded",
+		"credit_note": note,
+		"order":       order,
+	})
+}
+
+// ListCreditNotes returns credit notes issued against an order
+// @Summary List credit notes
+// @Security ApiKeyAuth
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /orders/:order_id/credit-notes [get]
+func (h *OrderHandler) ListCreditNotes(c *gin.Context) {
+	restaurantID, exists := c.Get("restaurant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "restaurant info not found"})
+		return
+	}
+
+	notes, err := h.orderService.ListCreditNotes(restaurantID.(string), c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credit_notes": notes})
+}
+
 // UpdateOrderItemStatus updates the status of a specific order item
 // @Summary Update order item status
 // @Description Update the status of an order item (pending, cooking, ready, served)
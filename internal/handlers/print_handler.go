@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/models"
 	"restaurant-api/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +29,18 @@ func NewPrintHandler(printService *services.PrintService) *PrintHandler {
 // SetupPrintRoutes registers restaurant print settings + print-agent claim/SSE APIs.
 func SetupPrintRoutes(router *gin.Engine, db *gorm.DB) {
 	printService := services.NewPrintService(db)
+	printService.OnJobFailed(func(job models.PrintJob) {
+		notifyPrintJobFailed(db, job)
+	})
+	printService.OnAgentHealthChanged(func(restaurantID string, online bool, agents []models.PrintAgentStatus) {
+		eventType := "print_agent_offline"
+		if online {
+			eventType = "print_agent_online"
+		}
+		BroadcastPrintAgentEvent(globalHub, restaurantID, eventType, models.PrintAgentEventData{Online: online, Agents: agents})
+	})
+	printService.StartClaimLeaseSweeper(context.Background())
+	printService.StartAgentHealthSweeper(context.Background())
 	h := NewPrintHandler(printService)
 	authService := getAuthService(db)
 
@@ -37,6 +53,9 @@ func SetupPrintRoutes(router *gin.Engine, db *gorm.DB) {
 		restaurant.PUT("/print-settings", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), h.UpdatePrintSettings)
 		restaurant.POST("/print-settings/rotate-agent-key", middleware.RoleMiddleware("admin"), h.RotateAgentKey)
 		restaurant.POST("/print-settings/test", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), h.EnqueueTestPrint)
+		restaurant.GET("/print-jobs", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), h.ListJobs)
+		restaurant.POST("/print-jobs/:job_id/retry", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), h.RetryJob)
+		restaurant.POST("/print-jobs/:job_id/reprint", middleware.RoleMiddleware("admin", "manager", "staff", "chef"), h.ReprintJob)
 	}
 
 	orders := router.Group("/orders")
@@ -50,6 +69,8 @@ func SetupPrintRoutes(router *gin.Engine, db *gorm.DB) {
 	agent.Use(middleware.PrintAgentAuthMiddleware(printService))
 	{
 		agent.GET("/events", h.AgentEvents)
+		agent.POST("/heartbeat", h.AgentHeartbeat)
+		agent.POST("/spool/sync", h.SyncSpool)
 		agent.POST("/jobs/claim", h.ClaimJobs)
 		agent.POST("/jobs/:job_id/complete", h.CompleteJob)
 		agent.POST("/jobs/:job_id/fail", h.FailJob)
@@ -65,9 +86,16 @@ func (h *PrintHandler) GetPrintSettings(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	agents, err := h.printService.ListAgents(settings.RestaurantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"settings": settings,
+		"settings":      settings,
 		"has_agent_key": settings.AgentAPIKeyHash != "",
+		"agents":        agents,
+		"agent_online":  services.AnyAgentOnline(agents),
 	})
 }
 
@@ -82,13 +110,20 @@ func (h *PrintHandler) UpdatePrintSettings(c *gin.Context) {
 	}
 
 	isManager := role == "admin" || role == "manager"
-	// Only admin/manager may flip master enable toggles or paper feed.
+	// Only admin/manager may flip master enable toggles, paper feed, drawer kick, buzzer or slip styling.
 	if !isManager {
 		input.BillPrintingEnabled = nil
 		input.KotPrintingEnabled = nil
 		input.BillAutoPrintOnCheckout = nil
 		input.TopFeedLines = nil
 		input.BottomFeedLines = nil
+		input.BillOpenDrawer = nil
+		input.KotBuzzer = nil
+		input.KotPlainText = nil
+		input.BillPlainText = nil
+		input.BillLogoURL = nil
+		input.KotRasterText = nil
+		input.BillRasterText = nil
 	}
 
 	current, err := h.printService.GetOrCreateSettings(restaurantID.(string))
@@ -124,7 +159,13 @@ func (h *PrintHandler) UpdatePrintSettings(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"settings": settings, "has_agent_key": settings.AgentAPIKeyHash != ""})
+	agents, _ := h.printService.ListAgents(settings.RestaurantID)
+	c.JSON(http.StatusOK, gin.H{
+		"settings":      settings,
+		"has_agent_key": settings.AgentAPIKeyHash != "",
+		"agents":        agents,
+		"agent_online":  services.AnyAgentOnline(agents),
+	})
 }
 
 func (h *PrintHandler) RotateAgentKey(c *gin.Context) {
@@ -226,13 +267,14 @@ func (h *PrintHandler) ClaimJobs(c *gin.Context) {
 	var body struct {
 		AgentID string `json:"agent_id"`
 		Limit   int    `json:"limit"`
+		Markup  bool   `json:"markup"` // agent renders <<<B>>>-style slip markup
 	}
 	_ = c.ShouldBindJSON(&body)
 	agentID := strings.TrimSpace(body.AgentID)
 	if agentID == "" {
 		agentID = c.ClientIP()
 	}
-	jobs, err := h.printService.ClaimPendingJobs(restaurantID.(string), agentID, body.Limit)
+	jobs, err := h.printService.ClaimPendingJobs(restaurantID.(string), agentID, body.Limit, body.Markup)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -297,10 +339,78 @@ func (h *PrintHandler) AgentEvents(c *gin.Context) {
 	}
 }
 
+// AgentHeartbeat records the agent's version, uptime and printer reachability, and
+// returns the printers it should probe next time.
+func (h *PrintHandler) AgentHeartbeat(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	var input services.PrintAgentHeartbeatInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	agent, err := h.printService.RecordHeartbeat(restaurantID, c.ClientIP(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	printers, err := h.printService.ProbeTargets(restaurantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	settings, err := h.printService.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"agent":                      agent,
+		"printers":                   printers,
+		"top_feed_lines":             settings.TopFeedLines,
+		"bottom_feed_lines":          settings.BottomFeedLines,
+		"heartbeat_interval_seconds": int(services.PrintAgentOfflineAfter().Seconds() / 3),
+	})
+}
+
+// SyncSpool uploads slips the agent printed from its LAN spool during an outage.
+func (h *PrintHandler) SyncSpool(c *gin.Context) {
+	var body struct {
+		AgentID string                     `json:"agent_id"`
+		Jobs    []services.SpooledPrintJob `json:"jobs"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	agentID := strings.TrimSpace(body.AgentID)
+	if agentID == "" {
+		agentID = c.ClientIP()
+	}
+	synced, rejected, err := h.printService.SyncSpooledJobs(c.GetString("restaurant_id"), agentID, body.Jobs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"synced": synced, "rejected": rejected})
+}
+
+// reportingAgentID names the agent confirming or failing a job, the same way ClaimJobs
+// names the one claiming it.
+func reportingAgentID(c *gin.Context, agentID string) string {
+	if agentID = strings.TrimSpace(agentID); agentID != "" {
+		return agentID
+	}
+	return c.ClientIP()
+}
+
 func (h *PrintHandler) CompleteJob(c *gin.Context) {
 	restaurantID, _ := c.Get("restaurant_id")
 	jobID := c.Param("job_id")
-	if err := h.printService.CompleteJob(restaurantID.(string), jobID, false, ""); err != nil {
+	var body struct {
+		AgentID string `json:"agent_id"`
+	}
+	_ = c.ShouldBindJSON(&body)
+	if err := h.printService.CompleteJob(restaurantID.(string), jobID, reportingAgentID(c, body.AgentID), false, ""); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -311,12 +421,142 @@ func (h *PrintHandler) FailJob(c *gin.Context) {
 	restaurantID, _ := c.Get("restaurant_id")
 	jobID := c.Param("job_id")
 	var body struct {
-		Error string `json:"error"`
+		AgentID     string `json:"agent_id"`
+		Error       string `json:"error"`
+		Unreachable bool   `json:"unreachable"` // agent could not connect; safe to reroute
 	}
 	_ = c.ShouldBindJSON(&body)
-	if err := h.printService.CompleteJob(restaurantID.(string), jobID, true, body.Error); err != nil {
+	rerouted, err := h.printService.FailJob(restaurantID.(string), jobID, reportingAgentID(c, body.AgentID), body.Error, body.Unreachable)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "rerouted": rerouted})
+}
+
+// RetryJob requeues a failed print job by hand (e.g. after fixing paper or power).
+func (h *PrintHandler) RetryJob(c *gin.Context) {
+	restaurantID, _ := c.Get("restaurant_id")
+	job, err := h.printService.RetryJob(restaurantID.(string), c.Param("job_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "print job not found"})
+		case errors.Is(err, services.ErrPrintJobNotFailed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job": job, "message": "print job requeued"})
+}
+
+// ListJobs returns print history for an order or a date range, newest first.
+// Without order_id the range defaults to today; filters: status, target, type.
+func (h *PrintHandler) ListJobs(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	filter := services.PrintJobFilter{
+		OrderID: strings.TrimSpace(c.Query("order_id")),
+		Status:  strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		JobType: strings.ToLower(strings.TrimSpace(c.Query("type"))),
+	}
+	switch target := strings.ToLower(strings.TrimSpace(c.Query("target"))); target {
+	case "":
+	case "kot", services.PrintTargetKOT:
+		filter.Target = services.PrintTargetKOT
+	case "bill", services.PrintTargetBill:
+		filter.Target = services.PrintTargetBill
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target must be kot or bill"})
+		return
+	}
+	if filter.OrderID == "" || c.Query("from") != "" || c.Query("to") != "" {
+		cal := services.LoadBusinessCalendar(h.printService.GetDB(), restaurantID)
+		from, toEnd, err := services.ParseHistoryDateRange(c.Query("from"), c.Query("to"), cal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.From, filter.ToEnd = from, toEnd
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	jobs, total, err := h.printService.ListJobs(restaurantID, filter, limit, offset)
+	if errors.Is(err, services.ErrPrintJobFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Print history lookup failed for restaurant %s: %v", restaurantID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load print jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "total": total, "limit": limit, "offset": offset})
+}
+
+// ReprintJob queues a new pending job with the slip of an earlier one, any job status, headed
+// with a REPRINT line sized to the printer's current paper width. The copy goes to the same
+// target and station, links back to the original through reprint_of_id (reprinting a
+// reprint links to the first slip), and never opens the cash drawer or sounds the buzzer.
+// Responds 202 with the new job, or 404 when the job is not the restaurant's.
+func (h *PrintHandler) ReprintJob(c *gin.Context) {
+	job, err := h.printService.ReprintJob(c.GetString("restaurant_id"), c.Param("job_id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "print job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"job": job, "queued": true, "message": "reprint queued"})
+}
+
+// notifyPrintJobFailed raises a WebSocket event and staff push for a job that will not print.
+func notifyPrintJobFailed(db *gorm.DB, job models.PrintJob) {
+	data := models.PrintJobEventData{
+		JobID:        job.ID,
+		OrderID:      job.OrderID,
+		JobType:      job.JobType,
+		Target:       job.Target,
+		StationID:    job.StationID,
+		Status:       job.Status,
+		Attempts:     job.Attempts,
+		ErrorMessage: job.ErrorMessage,
+	}
+	label := "A print job"
+	if job.OrderID != "" {
+		var order models.Order
+		if err := db.Select("id", "order_number", "ticket_number", "table_number", "order_type").
+			Where("id = ?", job.OrderID).First(&order).Error; err == nil {
+			data.OrderNumber = order.OrderNumber
+			data.TableNo = order.TableNumber
+			slip := strings.ToUpper(job.JobType)
+			if order.OrderType == "counter" {
+				ticket := order.TicketNumber
+				if ticket <= 0 {
+					ticket = order.OrderNumber
+				}
+				label = fmt.Sprintf("%s for ticket #%d", slip, ticket)
+			} else if order.TableNumber != "" {
+				label = fmt.Sprintf("%s for Table %s", slip, order.TableNumber)
+			} else {
+				label = fmt.Sprintf("%s for order #%d", slip, order.OrderNumber)
+			}
+		}
+	}
+	BroadcastPrintJobEvent(globalHub, job.RestaurantID, "print_job_failed", data)
+	notifyStaffPush(db, job.RestaurantID, services.PushAlertPrintFailed,
+		"Print failed",
+		label+" did not print — retry from the app",
+		map[string]string{"job_id": job.ID, "order_id": job.OrderID},
+	)
 }
@@ -69,6 +69,13 @@ func (h *RestaurantHandler) GetRestaurantProfile(c *gin.Context) {
 		"composite_scheme":             restaurant.CompositeScheme,
 		"category_display_blocklist":   services.ParseCategoryDisplayBlocklist(restaurant.CategoryDisplayBlocklist),
 		"gst_number":                   restaurant.GstNumber,
+		"timezone":                     services.LocationForRestaurant(&restaurant).String(),
+		"business_day_start":           businessDayStartOrDefault(restaurant.BusinessDayStart),
+		"invoice_prefix":               restaurant.InvoicePrefix,
+		"guest_ordering":               restaurant.GuestOrdering,
+		"guest_order_auto_approve":     restaurant.GuestOrderAutoApprove,
+		"kds_warn_minutes":             restaurant.KDSWarnMinutes,
+		"kds_late_minutes":             restaurant.KDSLateMinutes,
 		"subscription_end":           restaurant.SubscriptionEnd,
 		"subscription_plan":          restaurant.SubscriptionPlan,
 		"subscription_monthly_price": restaurant.SubscriptionMonthlyPrice,
@@ -116,6 +123,13 @@ func (h *RestaurantHandler) UpdateRestaurantProfile(c *gin.Context) {
 		CompositeScheme          *bool     `json:"composite_scheme"`
 		CategoryDisplayBlocklist *[]string `json:"category_display_blocklist"`
 		GstNumber                *string   `json:"gst_number"`
+		Timezone                 *string   `json:"timezone"`
+		BusinessDayStart         *string   `json:"business_day_start"`
+		InvoicePrefix            *string   `json:"invoice_prefix"`
+		GuestOrdering            *bool     `json:"guest_ordering"`
+		GuestOrderAutoApprove    *bool     `json:"guest_order_auto_approve"`
+		KDSWarnMinutes           *int      `json:"kds_warn_minutes"`
+		KDSLateMinutes           *int      `json:"kds_late_minutes"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -189,6 +203,56 @@ func (h *RestaurantHandler) UpdateRestaurantProfile(c *gin.Context) {
 		}
 		restaurant.GstNumber = gst
 	}
+	if input.Timezone != nil {
+		tz, err := services.NormalizeRestaurantTimezone(*input.Timezone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		restaurant.Timezone = tz
+	}
+	if input.BusinessDayStart != nil {
+		dayStart, err := services.NormalizeBusinessDayStart(*input.BusinessDayStart)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		restaurant.BusinessDayStart = dayStart
+	}
+	if input.InvoicePrefix != nil {
+		prefix, err := services.NormalizeInvoicePrefix(*input.InvoicePrefix)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		restaurant.InvoicePrefix = prefix
+	}
+	if input.GuestOrdering != nil {
+		restaurant.GuestOrdering = *input.GuestOrdering
+	}
+	if input.GuestOrderAutoApprove != nil {
+		restaurant.GuestOrderAutoApprove = *input.GuestOrderAutoApprove
+	}
+	if input.KDSWarnMinutes != nil {
+		minutes, err := services.NormalizeKDSMinutes(*input.KDSWarnMinutes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		restaurant.KDSWarnMinutes = minutes
+	}
+	if input.KDSLateMinutes != nil {
+		minutes, err := services.NormalizeKDSMinutes(*input.KDSLateMinutes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		restaurant.KDSLateMinutes = minutes
+	}
+	if (input.KDSWarnMinutes != nil || input.KDSLateMinutes != nil) && restaurant.KDSLateMinutes <= restaurant.KDSWarnMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kds_late_minutes must be greater than kds_warn_minutes"})
+		return
+	}
 
 	if err := h.db.Save(&restaurant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update restaurant profile"})
@@ -258,3 +322,11 @@ func (h *RestaurantHandler) LogoutAllDevices(c *gin.Context) {
 		"revoked_users": len(revokedUsers),
 	})
 }
+
+func businessDayStartOrDefault(value string) string {
+	normalized, err := services.NormalizeBusinessDayStart(value)
+	if err != nil {
+		return "00:00"
+	}
+	return normalized
+}
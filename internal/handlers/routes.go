@@ -85,6 +85,8 @@ func SetupOrderRoutes(router *gin.Engine, db *gorm.DB) {
 		protected.POST("/:order_id/checkout/start", orderHandler.StartCheckout)
 		protected.POST("/:order_id/checkout/cancel", orderHandler.CancelCheckout)
 		protected.PUT("/:order_id/cancel", orderHandler.CancelOrder)
+		protected.POST("/:order_id/refund", middleware.RoleMiddleware("admin", "manager"), orderHandler.RefundOrder)
+		protected.GET("/:order_id/credit-notes", middleware.RoleMiddleware("admin", "manager"), orderHandler.ListCreditNotes)
 		protected.PUT("/:order_id/items/:item_id/status", orderHandler.UpdateOrderItemStatus)
 		protected.PUT("/:order_id/items/:item_id/quantity", orderHandler.AdjustOrderItemQuantity)
 		protected.DELETE("/:order_id/items/:item_id", orderHandler.DeleteCancelledOrderItem)
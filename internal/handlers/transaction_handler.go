@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransactionHandler struct {
+	db     *gorm.DB
+	ledger *services.TransactionLedgerService
+}
+
+func NewTransactionHandler(db *gorm.DB) *TransactionHandler {
+	return &TransactionHandler{
+		db:     db,
+		ledger: services.NewTransactionLedgerService(db),
+	}
+}
+
+// ListTransactions returns the restaurant's money ledger, newest first.
+// Query: from, to (YYYY-MM-DD business days), type, payment_method, order_id, user_id, cash_session_id, limit, offset.
+func (h *TransactionHandler) ListTransactions(c *gin.Context) {
+	restaurantID, exists := c.Get("restaurant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var restaurant models.Restaurant
+	if err := h.db.Where("id = ?", restaurantID.(string)).First(&restaurant).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load restaurant"})
+		return
+	}
+	cal := services.BusinessCalendarForRestaurant(&restaurant)
+	from, toEnd, err := services.ParseHistoryDateRange(c.Query("from"), c.Query("to"), cal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := services.TransactionFilter{
+		Type:          strings.TrimSpace(c.Query("type")),
+		PaymentMethod: strings.TrimSpace(c.Query("payment_method")),
+		OrderID:       strings.TrimSpace(c.Query("order_id")),
+		UserID:        strings.TrimSpace(c.Query("user_id")),
+		CashSessionID: strings.TrimSpace(c.Query("cash_session_id")),
+		From:          from,
+		ToEnd:         toEnd,
+	}
+
+	rows, total, totals, err := h.ledger.List(restaurantID.(string), filter, limit, offset)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown transaction type") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("❌ Transaction ledger retrieval failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fromDate, toDate := cal.HistoryRangeDates(from, toEnd)
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": rows,
+		"total":        total,
+		"totals":       totals,
+		"limit":        limit,
+		"offset":       offset,
+		"from":         fromDate,
+		"to":           toDate,
+	})
+}
+
+// SetupTransactionRoutes registers the read-only money ledger (admin/manager).
+func SetupTransactionRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewTransactionHandler(db)
+
+	protected := router.Group("/transactions")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	protected.Use(middleware.RoleMiddleware("admin", "manager"))
+	{
+		protected.GET("", handler.ListTransactions)
+	}
+
+	log.Println("✅ Transaction routes registered (admin/manager)")
+}
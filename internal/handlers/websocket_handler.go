@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +37,37 @@ type WebSocketClient struct {
 	userID       string
 	restaurantID string
 	roomID       string
+	role         string
+	registered   chan struct{}
+	closeOnce    sync.Once
+	// backlog is written before live messages: the welcome, then a resync notice or the
+	// events the client missed. Live events up to replayedThrough are already in it.
+	backlog         []models.NotificationEvent
+	replayedThrough int64
+
+	subMu sync.RWMutex
+	sub   wsSubscription
+}
+
+// wants reports whether the client's subscription covers message; anything that is not
+// a NotificationEvent goes through.
+func (c *WebSocketClient) wants(message interface{}) bool {
+	event, ok := message.(models.NotificationEvent)
+	if !ok {
+		return true
+	}
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.sub.wants(event)
+}
+
+// subscribe replaces what the client receives and returns the confirmation to send it.
+func (c *WebSocketClient) subscribe(req wsSubscribeRequest) models.NotificationEvent {
+	sub, data := resolveSubscription(c.role, req)
+	c.subMu.Lock()
+	c.sub = sub
+	c.subMu.Unlock()
+	return subscribedEvent(c.roomID, data)
 }
 
 var upgrader = websocket.Upgrader{
@@ -123,6 +156,15 @@ type EventPublisher interface {
 
 var globalPublisher EventPublisher
 
+// EventLog numbers each room's events and keeps the recent ones for replay on reconnect.
+type EventLog interface {
+	Append(roomID string, event *models.NotificationEvent)
+	Head(roomID string) int64
+	Since(roomID string, lastSeq int64) (events []models.NotificationEvent, head int64, ok bool)
+}
+
+var globalEventLog EventLog
+
 // SetGlobalHub sets the global WebSocket hub
 func SetGlobalHub(hub *WebSocketHub) {
 	globalHub = hub
@@ -133,6 +175,11 @@ func SetEventPublisher(publisher EventPublisher) {
 	globalPublisher = publisher
 }
 
+// SetEventLog sets the per-restaurant sequence counter and replay log.
+func SetEventLog(eventLog EventLog) {
+	globalEventLog = eventLog
+}
+
 // NewWebSocketHub creates a new WebSocket hub
 func NewWebSocketHub() *WebSocketHub {
 	return &WebSocketHub{
@@ -157,6 +204,7 @@ func (h *WebSocketHub) Run() {
 				h.roomMap[client.roomID] = []*WebSocketClient{client}
 			}
 			h.mu.Unlock()
+			close(client.registered)
 			log.Printf("✅ Client connected to room %s: %s", client.roomID, client.userID)
 
 		case client := <-h.unregister:
@@ -185,11 +233,14 @@ func (h *WebSocketHub) Run() {
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for client := range h.clients {
+				if !client.wants(message) {
+					continue
+				}
 				select {
 				case client.send <- message:
 				default:
-					// Channel full, skip this client
-					log.Printf("⚠️  Client send buffer full, dropping message for %s", client.userID)
+					log.Printf("⚠️  Client send buffer full, disconnecting %s so it can resume", client.userID)
+					client.disconnect()
 				}
 			}
 			h.mu.RUnlock()
@@ -217,11 +268,17 @@ func (h *WebSocketHub) BroadcastToRoom(roomID string, message interface{}) {
 	log.Printf("📤 [BROADCAST] Sending to %d clients in room %s", clientCount, roomID)
 
 	for _, client := range room {
+		if !client.wants(message) {
+			continue
+		}
 		select {
 		case client.send <- message:
 			log.Printf("   ✓ Message sent to client %s", client.userID)
 		default:
-			log.Printf("⚠️  Client send buffer full for %s in room %s", client.userID, roomID)
+			// Dropping the event would leave the client silently stale; closing makes it
+			// reconnect with last_seq and replay what it missed.
+			log.Printf("⚠️  Client send buffer full for %s in room %s, disconnecting so it can resume", client.userID, roomID)
+			client.disconnect()
 		}
 	}
 }
@@ -237,7 +294,15 @@ func (h *WebSocketHub) getRoomList() []string {
 	return rooms
 }
 
-// HandleWebSocket handles WebSocket connections
+// disconnect closes the connection; readPump then unregisters the client.
+func (c *WebSocketClient) disconnect() {
+	c.closeOnce.Do(func() { c.conn.Close() })
+}
+
+// HandleWebSocket handles WebSocket connections. A reconnecting client passes
+// ?last_seq=<seq of the last event it handled> to be sent the events it missed, or a
+// resync_required event when they are no longer in the log. ?topics=, ?stations= and
+// ?tables= (comma-separated) set the first subscription; see websocket_topics.go.
 func HandleWebSocket(c *gin.Context, hub *WebSocketHub) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -266,24 +331,62 @@ func HandleWebSocket(c *gin.Context, hub *WebSocketHub) {
 		userID:       userID.(string),
 		restaurantID: restaurantID.(string),
 		roomID:       roomID,
+		role:         c.GetString("role"),
+		registered:   make(chan struct{}),
 	}
+	subscribed := client.subscribe(subscribeRequestFromQuery(c.Request.URL.Query()))
 
 	hub.register <- client
+	// Read the log only once live events reach the client, so none fall between the two.
+	<-client.registered
+	lastSeq, _ := strconv.ParseInt(c.Query("last_seq"), 10, 64)
+	client.backlog, client.replayedThrough = buildConnectBacklog(roomID, lastSeq)
+	client.backlog = slices.Insert(client.backlog, 1, subscribed)
 
-	// Send welcome message
-	welcomeMsg := models.NotificationEvent{
+	// Handle client messages
+	go client.readPump()
+	go client.writePump()
+}
+
+// buildConnectBacklog returns the welcome (whose seq is the room's current head) followed
+// by the events after lastSeq, or by resync_required when the log can't cover the gap.
+// replayedThrough is the newest seq the client has, counting the replay (0 on resync).
+func buildConnectBacklog(roomID string, lastSeq int64) (backlog []models.NotificationEvent, replayedThrough int64) {
+	now := time.Now()
+	welcome := models.NotificationEvent{
 		Type:      "connected",
 		RoomID:    roomID,
-		Timestamp: time.Now(),
+		Timestamp: now,
 		Version:   1,
-		Seq:       time.Now().UnixNano(),
+		Seq:       now.UnixNano(),
 		Data:      json.RawMessage(`{"message":"Connected to server"}`),
 	}
-	client.send <- welcomeMsg
+	if globalEventLog == nil {
+		return []models.NotificationEvent{welcome}, 0
+	}
+	if lastSeq <= 0 {
+		welcome.Seq = globalEventLog.Head(roomID)
+		return []models.NotificationEvent{welcome}, 0
+	}
 
-	// Handle client messages
-	go client.readPump()
-	go client.writePump()
+	missed, head, ok := globalEventLog.Since(roomID, lastSeq)
+	welcome.Seq = head
+	if !ok {
+		resync := models.NotificationEvent{
+			Type:      "resync_required",
+			RoomID:    roomID,
+			Timestamp: now,
+			Version:   1,
+			Seq:       head,
+			Data:      json.RawMessage(toJSON(models.ResyncEventData{LastSeq: lastSeq, Seq: head})),
+		}
+		return []models.NotificationEvent{welcome, resync}, 0
+	}
+	replayedThrough = lastSeq
+	if len(missed) > 0 {
+		replayedThrough = missed[len(missed)-1].Seq
+	}
+	return append([]models.NotificationEvent{welcome}, missed...), replayedThrough
 }
 
 // readPump reads from WebSocket connection
@@ -314,6 +417,18 @@ func (c *WebSocketClient) readPump() {
 		switch event.Type {
 		case "ping", "pong", "heartbeat":
 			// keep-alive from client; read deadline already extended via PongHandler
+		case "subscribe":
+			var req wsSubscribeRequest
+			if len(event.Data) > 0 {
+				if err := json.Unmarshal(event.Data, &req); err != nil {
+					log.Printf("📩 Bad subscribe from user=%s: %v", c.userID, err)
+					continue
+				}
+			}
+			select {
+			case c.send <- c.subscribe(req):
+			default:
+			}
 		default:
 			if event.Type != "" {
 				log.Printf("📩 Ignoring client WS event type=%s from user=%s", event.Type, c.userID)
@@ -330,6 +445,17 @@ func (c *WebSocketClient) writePump() {
 		c.conn.Close()
 	}()
 
+	for _, message := range c.backlog {
+		if !c.wants(message) {
+			continue
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := c.conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+	c.backlog = nil
+
 	for {
 		select {
 		case message, ok := <-c.send:
@@ -338,6 +464,9 @@ func (c *WebSocketClient) writePump() {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
+			if event, isEvent := message.(models.NotificationEvent); isEvent && event.Seq > 0 && event.Seq <= c.replayedThrough {
+				continue // already sent in the replay
+			}
 
 			if err := c.conn.WriteJSON(message); err != nil {
 				return
@@ -418,18 +547,25 @@ func buildOrderEventData(order *models.Order) models.OrderEventData {
 	}
 }
 
-func publishEvent(restaurantID, eventType string, data interface{}) {
+func publishEvent(restaurantID, eventType string, scope eventScope, data interface{}) {
 	if globalPublisher == nil && globalHub == nil {
 		return
 	}
 
 	event := models.NotificationEvent{
-		Type:      eventType,
-		RoomID:    restaurantID,
-		Timestamp: time.Now(),
-		Version:   1,
-		Seq:       time.Now().UnixNano(),
-		Data:      json.RawMessage(toJSON(data)),
+		Type:       eventType,
+		RoomID:     restaurantID,
+		Timestamp:  time.Now(),
+		Version:    1,
+		Data:       json.RawMessage(toJSON(data)),
+		Topic:      scope.Topic,
+		TableID:    scope.TableID,
+		StationIDs: scope.StationIDs,
+	}
+	if globalEventLog != nil {
+		globalEventLog.Append(restaurantID, &event)
+	} else {
+		event.Seq = time.Now().UnixNano()
 	}
 
 	if globalPublisher != nil {
@@ -448,7 +584,7 @@ func BroadcastSessionRevoked(restaurantID, userID string) {
 		"user_id": userID,
 		"reason":  "logged_in_elsewhere",
 	}
-	publishEvent(restaurantID, "session_revoked", data)
+	publishEvent(restaurantID, "session_revoked", eventScope{}, data)
 	log.Printf("📤 Broadcast session_revoked for user %s in room %s", userID, restaurantID)
 }
 
@@ -459,7 +595,7 @@ func BroadcastOrderEvent(hub *WebSocketHub, restaurantID, eventType string, orde
 	}
 	_ = hub // kept for call-site compatibility
 	data := buildOrderEventData(order)
-	publishEvent(restaurantID, eventType, data)
+	publishEvent(restaurantID, eventType, orderScope(wsTopicOrders, order, order.Items), data)
 	log.Printf("📤 Broadcast %s: Order #%d (Table: %s) to room %s", eventType, order.OrderNumber, order.TableNumber, restaurantID)
 }
 
@@ -477,7 +613,16 @@ func BroadcastOrderItemStatusEvent(hub *WebSocketHub, restaurantID string, order
 	data.ItemID = itemID
 	data.MenuID = menuID
 	data.BulkUpdate = bulk
-	publishEvent(restaurantID, "order_item_status_changed", data)
+	items := order.Items
+	if !bulk && itemID != "" {
+		items = nil
+		for _, item := range order.Items {
+			if item.ID == itemID {
+				items = append(items, item)
+			}
+		}
+	}
+	publishEvent(restaurantID, "order_item_status_changed", orderScope(wsTopicKitchen, order, items), data)
 }
 
 func BroadcastTableUpdate(hub *WebSocketHub, restaurantID string, table *models.RestaurantTable) {
@@ -489,17 +634,77 @@ func BroadcastTableUpdate(hub *WebSocketHub, restaurantID string, table *models.
 		CurrentOrderID:      table.CurrentOrderID,
 		AssistanceRequested: services.TableNeedsAssistance(table),
 	}
-	publishEvent(restaurantID, "table_status_changed", data)
+	publishEvent(restaurantID, "table_status_changed", eventScope{Topic: wsTopicTables, TableID: table.ID}, data)
 	log.Printf("📤 Broadcast table update: Table %s (Occupied: %v, Assistance: %v) to room %s", table.Name, table.IsOccupied, data.AssistanceRequested, restaurantID)
 }
 
 // BroadcastCheckoutEvent broadcasts checkout lock start/cancel events.
 func BroadcastCheckoutEvent(hub *WebSocketHub, restaurantID, eventType string, data models.CheckoutEventData) {
 	_ = hub
-	publishEvent(restaurantID, eventType, data)
+	publishEvent(restaurantID, eventType, eventScope{Topic: wsTopicCheckout, TableID: data.TableID}, data)
 	log.Printf("📤 Broadcast %s: order %s by %s to room %s", eventType, data.OrderID, data.LockedByName, restaurantID)
 }
 
+// BroadcastRefundEvent notifies clients that a credit note was issued against a paid order.
+func BroadcastRefundEvent(hub *WebSocketHub, restaurantID string, data models.RefundEventData) {
+	_ = hub
+	publishEvent(restaurantID, "order_refunded", eventScope{Topic: wsTopicCheckout}, data)
+	log.Printf("📤 Broadcast order_refunded: Order #%d credit note %s to room %s", data.OrderNumber, data.CreditNoteNumber, restaurantID)
+}
+
+// BroadcastPrintJobEvent notifies clients about a print job that needs attention (print_job_failed).
+func BroadcastPrintJobEvent(hub *WebSocketHub, restaurantID, eventType string, data models.PrintJobEventData) {
+	_ = hub
+	scope := eventScope{Topic: wsTopicPrint}
+	if data.StationID != "" {
+		scope.StationIDs = []string{data.StationID}
+	} else if data.JobType == "kot" {
+		scope.StationIDs = []string{services.MainKOTStation}
+	}
+	publishEvent(restaurantID, eventType, scope, data)
+	log.Printf("📤 Broadcast %s: %s job %s to room %s", eventType, data.JobType, data.JobID, restaurantID)
+}
+
+// BroadcastPrintAgentEvent tells dashboards the print agent went offline or came back.
+func BroadcastPrintAgentEvent(hub *WebSocketHub, restaurantID, eventType string, data models.PrintAgentEventData) {
+	_ = hub
+	publishEvent(restaurantID, eventType, eventScope{Topic: wsTopicPrint}, data)
+	log.Printf("📤 Broadcast %s: %d agent(s) to room %s", eventType, len(data.Agents), restaurantID)
+}
+
+// BroadcastGuestOrderEvent tells staff about a guest cart from a table QR page
+// (guest_order_requested, guest_order_approved, guest_order_rejected).
+func BroadcastGuestOrderEvent(hub *WebSocketHub, restaurantID, eventType string, req *models.GuestOrderRequest) {
+	_ = hub
+	if req == nil {
+		return
+	}
+	data := models.GuestOrderEventData{
+		RequestID:      req.ID,
+		TableID:        req.TableID,
+		TableNumber:    req.TableNumber,
+		Status:         req.Status,
+		AutoApproved:   req.AutoApproved,
+		Items:          req.Items,
+		EstimatedTotal: req.EstimatedTotal,
+		RejectReason:   req.RejectReason,
+	}
+	if req.OrderID != nil {
+		data.OrderID = *req.OrderID
+	}
+	publishEvent(restaurantID, eventType, eventScope{Topic: wsTopicGuestOrders, TableID: req.TableID}, data)
+	log.Printf("📤 Broadcast %s: table %s request %s to room %s", eventType, req.TableNumber, req.ID, restaurantID)
+}
+
+// BroadcastKDSTicketEvent tells kitchen displays a ticket was bumped or recalled
+// (kds_ticket_bumped, kds_ticket_recalled).
+func BroadcastKDSTicketEvent(hub *WebSocketHub, restaurantID, eventType string, data models.KDSTicketEventData) {
+	_ = hub
+	scope := eventScope{Topic: wsTopicKitchen, StationIDs: []string{data.StationID}}
+	publishEvent(restaurantID, eventType, scope, data)
+	log.Printf("📤 Broadcast %s: order #%d station %s to room %s", eventType, data.OrderNumber, data.StationID, restaurantID)
+}
+
 // BroadcastMenuUpdate notifies clients that the menu changed.
 // Cost price is cleared so non-admin WS clients never receive margin data.
 func BroadcastMenuUpdate(hub *WebSocketHub, restaurantID, action string, item *models.MenuItem, menuItemID string) {
@@ -521,7 +726,7 @@ func BroadcastMenuUpdate(hub *WebSocketHub, restaurantID, action string, item *m
 	if action == "deleted" && menuItemID == "" && item != nil {
 		data.MenuItemID = item.ID
 	}
-	publishEvent(restaurantID, "menu_updated", data)
+	publishEvent(restaurantID, "menu_updated", eventScope{Topic: wsTopicMenu}, data)
 	if action == "deleted" {
 		log.Printf("📤 Broadcast menu_updated (deleted): %s to room %s", data.MenuItemID, restaurantID)
 		return
@@ -540,7 +745,7 @@ func BroadcastInventoryUpdate(hub *WebSocketHub, restaurantID string, itemName s
 		Quantity: quantity,
 		IsLow:    isLow,
 	}
-	publishEvent(restaurantID, "inventory_updated", data)
+	publishEvent(restaurantID, "inventory_updated", eventScope{Topic: wsTopicInventory}, data)
 	log.Printf("📤 Broadcast inventory update: %s (Qty: %.2f) to room %s", itemName, quantity, restaurantID)
 }
 
@@ -564,7 +769,7 @@ func BroadcastIngredientInventoryUpdate(hub *WebSocketHub, restaurantID string,
 		AlertQuantity: ingredient.AlertQuantity,
 		IsLow:         ingredientIsLowStock(ingredient.CurrentStock, ingredient.AlertQuantity),
 	}
-	publishEvent(restaurantID, "inventory_updated", data)
+	publishEvent(restaurantID, "inventory_updated", eventScope{Topic: wsTopicInventory}, data)
 	log.Printf("📤 Broadcast ingredient inventory: %s (stock: %.2f %s) to room %s",
 		ingredient.Name, ingredient.CurrentStock, ingredient.Unit, restaurantID)
 }
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
+)
+
+// WebSocket topics a client can subscribe to. Events without a topic (connected,
+// session_revoked, resync_required) reach every client.
+const (
+	wsTopicOrders      = "orders"       // order_created, order_updated
+	wsTopicKitchen     = "kitchen"      // order_item_status_changed, kds_ticket_*
+	wsTopicTables      = "tables"       // table_status_changed
+	wsTopicCheckout    = "checkout"     // checkout locks, order_refunded
+	wsTopicMenu        = "menu"         // menu_updated
+	wsTopicInventory   = "inventory"    // inventory_updated
+	wsTopicGuestOrders = "guest_orders" // guest_order_*
+	wsTopicPrint       = "print"        // print_job_*, print_agent_*
+)
+
+var allWSTopics = []string{
+	wsTopicOrders, wsTopicKitchen, wsTopicTables, wsTopicCheckout,
+	wsTopicMenu, wsTopicInventory, wsTopicGuestOrders, wsTopicPrint,
+}
+
+// wsRoleTopics is what each role may receive; the server drops anything else whatever
+// the client asks for. Chefs get no tables, bills or guest carts.
+var wsRoleTopics = map[string][]string{
+	"admin":   allWSTopics,
+	"manager": allWSTopics,
+	"staff":   allWSTopics,
+	"chef":    {wsTopicOrders, wsTopicKitchen, wsTopicMenu, wsTopicInventory, wsTopicPrint},
+}
+
+// eventScope says which subscribers an event is for: its topic, and the table and
+// kitchen stations it concerns when it has any.
+type eventScope struct {
+	Topic      string
+	TableID    string
+	StationIDs []string
+}
+
+// wsSubscription is what one client receives. Empty Stations / Tables mean no filter;
+// filters only narrow events that concern a station or table, so a takeaway order or a
+// menu change still reaches a table-filtered client.
+type wsSubscription struct {
+	Topics   map[string]bool
+	Stations map[string]bool
+	Tables   map[string]bool
+}
+
+// wsSubscribeRequest is the data of a client "subscribe" message and the ?topics=,
+// ?stations= and ?tables= query parameters. No topics means every topic the role allows.
+type wsSubscribeRequest struct {
+	Topics   []string `json:"topics"`
+	Stations []string `json:"stations"`
+	Tables   []string `json:"tables"`
+}
+
+func subscribeRequestFromQuery(q url.Values) wsSubscribeRequest {
+	split := func(v string) []string {
+		var out []string
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+	return wsSubscribeRequest{
+		Topics:   split(q.Get("topics")),
+		Stations: split(q.Get("stations")),
+		Tables:   split(q.Get("tables")),
+	}
+}
+
+// resolveSubscription grants the requested topics the role allows and lists the rest
+// as denied.
+func resolveSubscription(role string, req wsSubscribeRequest) (wsSubscription, models.WSSubscriptionData) {
+	allowed := map[string]bool{}
+	for _, t := range wsRoleTopics[strings.ToLower(strings.TrimSpace(role))] {
+		allowed[t] = true
+	}
+	requested := req.Topics
+	if len(requested) == 0 {
+		requested = allWSTopics
+	}
+
+	sub := wsSubscription{Topics: map[string]bool{}, Stations: toSet(req.Stations), Tables: toSet(req.Tables)}
+	data := models.WSSubscriptionData{Topics: []string{}, Stations: req.Stations, Tables: req.Tables}
+	for _, t := range requested {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch {
+		case sub.Topics[t]:
+		case allowed[t]:
+			sub.Topics[t] = true
+			data.Topics = append(data.Topics, t)
+		case len(req.Topics) > 0:
+			data.Denied = append(data.Denied, t)
+		}
+	}
+	return sub, data
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+// wants reports whether an event belongs in this subscription.
+func (s wsSubscription) wants(event models.NotificationEvent) bool {
+	if event.Topic == "" {
+		return true
+	}
+	if !s.Topics[event.Topic] {
+		return false
+	}
+	if s.Tables != nil && event.TableID != "" && !s.Tables[event.TableID] {
+		return false
+	}
+	if s.Stations != nil && len(event.StationIDs) > 0 {
+		for _, id := range event.StationIDs {
+			if s.Stations[id] {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func subscribedEvent(roomID string, data models.WSSubscriptionData) models.NotificationEvent {
+	return models.NotificationEvent{
+		Type:      "subscribed",
+		RoomID:    roomID,
+		Timestamp: time.Now(),
+		Version:   1,
+		Data:      json.RawMessage(toJSON(data)),
+	}
+}
+
+// wsStations routes order lines to kitchen stations for station-filtered subscribers.
+var wsStations *services.KitchenStationService
+
+// SetKitchenStationRouting lets order broadcasts carry the kitchen stations they touch.
+func SetKitchenStationRouting(stations *services.KitchenStationService) {
+	wsStations = stations
+}
+
+// orderScope scopes an order event to its table and the stations its lines print on.
+func orderScope(topic string, order *models.Order, items []models.OrderItem) eventScope {
+	scope := eventScope{Topic: topic}
+	if order.TableID != nil {
+		scope.TableID = *order.TableID
+	}
+	if wsStations != nil && len(items) > 0 {
+		scope.StationIDs = wsStations.ItemStationIDs(order.RestaurantID, items)
+	}
+	return scope
+}
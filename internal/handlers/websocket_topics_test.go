@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestResolveSubscription_EnforcesRole(t *testing.T) {
+	sub, data := resolveSubscription("chef", wsSubscribeRequest{Topics: []string{"kitchen", "checkout", "Tables"}})
+	if !sub.Topics[wsTopicKitchen] || sub.Topics[wsTopicCheckout] || sub.Topics[wsTopicTables] {
+		t.Fatalf("chef topics: %+v", sub.Topics)
+	}
+	if len(data.Denied) != 2 {
+		t.Fatalf("checkout and tables should be denied: %+v", data)
+	}
+
+	sub, data = resolveSubscription("chef", wsSubscribeRequest{})
+	if sub.Topics[wsTopicCheckout] || !sub.Topics[wsTopicOrders] || len(data.Denied) != 0 {
+		t.Fatalf("no topics means everything the role allows: %+v %+v", sub.Topics, data)
+	}
+	if sub, _ := resolveSubscription("unknown", wsSubscribeRequest{}); len(sub.Topics) != 0 {
+		t.Fatalf("unknown roles get no topics: %+v", sub.Topics)
+	}
+}
+
+func TestSubscriptionWants(t *testing.T) {
+	sub, _ := resolveSubscription("staff", subscribeRequestFromQuery(url.Values{
+		"topics":   {"orders, kitchen"},
+		"stations": {"tandoor"},
+		"tables":   {"t1"},
+	}))
+
+	cases := []struct {
+		name  string
+		event models.NotificationEvent
+		want  bool
+	}{
+		{"untopiced", models.NotificationEvent{Type: "session_revoked"}, true},
+		{"other topic", models.NotificationEvent{Topic: wsTopicMenu}, false},
+		{"own table", models.NotificationEvent{Topic: wsTopicOrders, TableID: "t1"}, true},
+		{"other table", models.NotificationEvent{Topic: wsTopicOrders, TableID: "t2"}, false},
+		{"takeaway", models.NotificationEvent{Topic: wsTopicOrders}, true},
+		{"own station", models.NotificationEvent{Topic: wsTopicKitchen, StationIDs: []string{"main", "tandoor"}}, true},
+		{"other station", models.NotificationEvent{Topic: wsTopicKitchen, StationIDs: []string{"bar"}}, false},
+	}
+	for _, tc := range cases {
+		if got := sub.wants(tc.event); got != tc.want {
+			t.Errorf("%s: wants = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"restaurant-api/internal/middleware"
+	"restaurant-api/internal/models"
+	"restaurant-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ZReportHandler struct {
+	reports *services.ZReportService
+}
+
+func NewZReportHandler(db *gorm.DB) *ZReportHandler {
+	return &ZReportHandler{reports: services.NewZReportService(db)}
+}
+
+type GenerateZReportRequest struct {
+	BusinessDate string `json:"business_date,omitempty"` // YYYY-MM-DD; defaults to the last business day that has ended
+	Print        bool   `json:"print,omitempty"`
+	Email        bool   `json:"email,omitempty"`
+	EmailTo      string `json:"email_to,omitempty"`
+}
+
+type EmailZReportRequest struct {
+	To string `json:"to,omitempty"` // defaults to the restaurant's email
+}
+
+// GenerateZReport freezes the day's closing report, optionally printing and emailing it.
+// Calling it again for a closed day returns the stored report unchanged.
+func (h *ZReportHandler) GenerateZReport(c *gin.Context) {
+	restaurantID := c.GetString("restaurant_id")
+	var req GenerateZReportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, created, err := h.reports.Generate(restaurantID, req.BusinessDate, contextUserID(c))
+	if errors.Is(err, services.ErrZReportDayOpen) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Z-report generation failed: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if created {
+		log.Printf("✅ Z-report #%d frozen for %s (restaurant %s)", report.ReportNumber, report.BusinessDate, restaurantID)
+	}
+
+	resp := gin.H{"report": report, "created": created}
+	if req.Print {
+		queued, err := h.reports.Print(report)
+		resp["print_queued"] = queued
+		if err != nil {
+			resp["print_error"] = err.Error()
+		}
+	}
+	if req.Email {
+		sentTo, err := h.reports.Email(report, req.EmailTo)
+		if err != nil {
+			log.Printf("❌ Z-report email failed: %v", err)
+			resp["email_error"] = err.Error()
+		} else {
+			resp["emailed_to"] = sentTo
+		}
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, resp)
+}
+
+// ListZReports returns frozen reports, newest first.
+func (h *ZReportHandler) ListZReports(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	reports, total, err := h.reports.List(c.GetString("restaurant_id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reports": reports, "total": total, "limit": limit, "offset": offset})
+}
+
+func (h *ZReportHandler) loadReport(c *gin.Context) (*models.ZReport, bool) {
+	report, err := h.reports.Get(c.GetString("restaurant_id"), c.Param("report_id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrZReportNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return report, true
+}
+
+// GetZReport returns one frozen report with its snapshot and slip text.
+func (h *ZReportHandler) GetZReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// PrintZReport re-queues the identical frozen slip on the bill printer.
+func (h *ZReportHandler) PrintZReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	queued, err := h.reports.Print(report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !queued {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no bill printer configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Z-report queued for printing", "print_queued": true})
+}
+
+// EmailZReport sends the frozen slip by email.
+func (h *ZReportHandler) EmailZReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	var req EmailZReportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	sentTo, err := h.reports.Email(report, req.To)
+	if err != nil {
+		log.Printf("❌ Z-report email failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Z-report emailed", "emailed_to": sentTo})
+}
+
+// SetupZReportRoutes registers end-of-day report endpoints (admin/manager).
+func SetupZReportRoutes(router *gin.Engine, db *gorm.DB) {
+	authService := getAuthService(db)
+	handler := NewZReportHandler(db)
+
+	protected := router.Group("/reports/z")
+	protected.Use(middleware.AuthMiddleware(authService))
+	protected.Use(withSubscription(db))
+	protected.Use(middleware.RoleMiddleware("admin", "manager"))
+	{
+		protected.POST("", handler.GenerateZReport)
+		protected.GET("", handler.ListZReports)
+		protected.GET("/:report_id", handler.GetZReport)
+		protected.POST("/:report_id/print", handler.PrintZReport)
+		protected.POST("/:report_id/email", handler.EmailZReport)
+	}
+
+	log.Println("✅ Z-report routes registered (admin/manager)")
+}
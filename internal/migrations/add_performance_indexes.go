@@ -8,6 +8,7 @@ func AddPerformanceIndexes(db *gorm.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_orders_restaurant_created_at ON orders(restaurant_id, created_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_orders_restaurant_status_created_at ON orders(restaurant_id, status, created_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_order_items_order_status ON order_items(order_id, status)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_restaurant_invoice_number ON orders(restaurant_id, invoice_number) WHERE invoice_number <> ''`,
 	}
 
 	for _, stmt := range statements {
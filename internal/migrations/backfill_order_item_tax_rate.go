@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillOrderItemTaxRate snapshots a GST rate onto order lines written before
+// per-item slabs existed: the flat 5% for taxable items, 0 for MRP/exempt items.
+func BackfillOrderItemTaxRate(db *gorm.DB) error {
+	if err := db.Exec(`
+		UPDATE order_items oi
+		SET tax_rate = CASE WHEN COALESCE(mi.is_taxable, true) THEN 5 ELSE 0 END
+		FROM menu_items mi
+		WHERE mi.id = oi.menu_id
+		  AND oi.tax_rate IS NULL
+	`).Error; err != nil {
+		return fmt.Errorf("backfill order_items.tax_rate: %w", err)
+	}
+	return nil
+}
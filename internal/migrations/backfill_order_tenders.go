@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const backfillOrderTendersMigrationID = "backfill_order_tenders_v1"
+
+// BackfillOrderTenders creates order_tenders rows for orders paid before tenders existed,
+// from the legacy payment_method / cash_amount / upi_amount columns. Split payments become
+// a cash tender and a UPI tender; everything else is one tender for the order total.
+func BackfillOrderTenders(db *gorm.DB) error {
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS app_migrations (
+			id TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`).Error; err != nil {
+		return fmt.Errorf("ensure app_migrations table: %w", err)
+	}
+
+	var alreadyApplied int64
+	if err := db.Raw(
+		`SELECT COUNT(1) FROM app_migrations WHERE id = ?`,
+		backfillOrderTendersMigrationID,
+	).Scan(&alreadyApplied).Error; err != nil {
+		return fmt.Errorf("check order tenders backfill flag: %w", err)
+	}
+	if alreadyApplied > 0 {
+		fmt.Println("⏭️  BackfillOrderTenders already applied; skipping")
+		return nil
+	}
+
+	var inserted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// One statement so a split order's cash leg does not hide it from the UPI leg.
+		result := tx.Exec(`
+			WITH paid AS (
+				SELECT o.id, o.restaurant_id, LOWER(TRIM(o.payment_method)) AS method, o.total,
+					o.cash_amount, o.upi_amount, COALESCE(o.upi_transaction_id, '') AS upi_ref,
+					COALESCE(o.completed_at, o.updated_at) AS paid_at
+				FROM orders o
+				WHERE o.status <> 'cancelled'
+				  AND COALESCE(TRIM(o.payment_method), '') <> ''
+				  AND NOT EXISTS (SELECT 1 FROM order_tenders t WHERE t.order_id = o.id)
+			)
+			INSERT INTO order_tenders (id, order_id, restaurant_id, method, amount, reference, created_at)
+			SELECT gen_random_uuid()::text, id, restaurant_id, 'cash', cash_amount, '', paid_at
+			FROM paid WHERE method = 'split' AND cash_amount > 0
+			UNION ALL
+			SELECT gen_random_uuid()::text, id, restaurant_id, 'upi', upi_amount, upi_ref, paid_at
+			FROM paid WHERE method = 'split' AND upi_amount > 0
+			UNION ALL
+			SELECT gen_random_uuid()::text, id, restaurant_id, method, total,
+				CASE WHEN method = 'upi' THEN upi_ref ELSE '' END, paid_at
+			FROM paid WHERE method IN ('cash', 'upi', 'card', 'wallet') AND total > 0
+		`)
+		if result.Error != nil {
+			return result.Error
+		}
+		inserted = result.RowsAffected
+		return tx.Exec(
+			`INSERT INTO app_migrations (id) VALUES (?) ON CONFLICT (id) DO NOTHING`,
+			backfillOrderTendersMigrationID,
+		).Error
+	})
+	if err != nil {
+		return fmt.Errorf("backfill order tenders: %w", err)
+	}
+
+	fmt.Printf("✅ BackfillOrderTenders complete (inserted %d tender(s))\n", inserted)
+	return nil
+}
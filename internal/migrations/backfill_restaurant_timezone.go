@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// BackfillRestaurantTimezone sets timezone on restaurants created before the
+// per-restaurant column existed, using the server-wide default they ran under.
+func BackfillRestaurantTimezone(db *gorm.DB, defaultTimezone string) error {
+	if err := db.Exec(`
+		UPDATE restaurants
+		SET timezone = ?
+		WHERE timezone IS NULL OR TRIM(timezone) = ''
+	`, defaultTimezone).Error; err != nil {
+		return fmt.Errorf("backfill restaurants.timezone: %w", err)
+	}
+	return nil
+}
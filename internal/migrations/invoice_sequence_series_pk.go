@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// InvoiceSequenceSeriesPK widens the invoice_sequences primary key to include the
+// series column so credit notes can keep their own per-year counter. AutoMigrate adds
+// the column but never rewrites an existing primary key.
+func InvoiceSequenceSeriesPK(db *gorm.DB) error {
+	var hasSeries int64
+	if err := db.Raw(`
+		SELECT COUNT(*)
+		FROM information_schema.key_column_usage
+		WHERE table_name = 'invoice_sequences'
+		  AND constraint_name = 'invoice_sequences_pkey'
+		  AND column_name = 'series'
+	`).Scan(&hasSeries).Error; err != nil {
+		return fmt.Errorf("inspect invoice_sequences primary key: %w", err)
+	}
+	if hasSeries > 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE invoice_sequences SET series = 'invoice' WHERE series IS NULL OR series = ''`).Error; err != nil {
+			return fmt.Errorf("backfill invoice_sequences.series: %w", err)
+		}
+		if err := tx.Exec(`ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey`).Error; err != nil {
+			return fmt.Errorf("drop invoice_sequences primary key: %w", err)
+		}
+		if err := tx.Exec(`ALTER TABLE invoice_sequences ADD PRIMARY KEY (restaurant_id, series, financial_year)`).Error; err != nil {
+			return fmt.Errorf("add invoice_sequences primary key: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CashDrawerSession is one cashier's till from opening float to counted close.
+// Expected cash and over/short are frozen when the session closes.
+type CashDrawerSession struct {
+	ID             string     `gorm:"primaryKey" json:"id"`
+	RestaurantID   string     `json:"restaurant_id" gorm:"index;not null"`
+	CashierUserID  string     `json:"cashier_user_id" gorm:"type:varchar(36);index;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(16);default:'open';index"` // open | closed
+	OpeningFloat   float64    `json:"opening_float" gorm:"type:numeric(12,2);default:0"`
+	CashSales      float64    `json:"cash_sales" gorm:"type:numeric(12,2);default:0"`
+	CashRefunds    float64    `json:"cash_refunds" gorm:"type:numeric(12,2);default:0"`
+	Payouts        float64    `json:"payouts" gorm:"type:numeric(12,2);default:0"`
+	Drops          float64    `json:"drops" gorm:"type:numeric(12,2);default:0"`
+	ExpectedCash   float64    `json:"expected_cash" gorm:"type:numeric(12,2);default:0"`
+	CountedCash    *float64   `json:"counted_cash,omitempty" gorm:"type:numeric(12,2)"`
+	OverShort      float64    `json:"over_short" gorm:"type:numeric(12,2);default:0"` // counted - expected
+	OpenNotes      string     `json:"open_notes,omitempty" gorm:"type:text"`
+	CloseNotes     string     `json:"close_notes,omitempty" gorm:"type:text"`
+	ClosedByUserID string     `json:"closed_by_user_id,omitempty" gorm:"type:varchar(36)"`
+	OpenedAt       time.Time  `json:"opened_at" gorm:"index"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Cashier   *User                `json:"cashier,omitempty" gorm:"foreignKey:CashierUserID"`
+	Movements []CashDrawerMovement `json:"movements,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+func (CashDrawerSession) TableName() string {
+	return "cash_drawer_sessions"
+}
+
+func (s *CashDrawerSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.Status == "" {
+		s.Status = "open"
+	}
+	if s.OpenedAt.IsZero() {
+		s.OpenedAt = time.Now()
+	}
+	return nil
+}
+
+// CashDrawerMovement is cash taken out of an open drawer: a payout (spent) or a drop (moved to the safe).
+type CashDrawerMovement struct {
+	ID           string    `gorm:"primaryKey" json:"id"`
+	SessionID    string    `json:"session_id" gorm:"type:varchar(36);index;not null"`
+	RestaurantID string    `json:"restaurant_id" gorm:"index;not null"`
+	Kind         string    `json:"kind" gorm:"type:varchar(16);not null"` // payout | drop
+	Amount       float64   `json:"amount" gorm:"type:numeric(12,2);not null"`
+	Reason       string    `json:"reason" gorm:"type:text"`
+	UserID       string    `json:"user_id" gorm:"type:varchar(36)"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (CashDrawerMovement) TableName() string {
+	return "cash_drawer_movements"
+}
+
+func (m *CashDrawerMovement) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
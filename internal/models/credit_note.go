@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreditNote reverses all or part of a paid order. It carries its own GST document number
+// and references the invoice it adjusts.
+type CreditNote struct {
+	ID               string    `gorm:"primaryKey" json:"id"`
+	RestaurantID     string    `json:"restaurant_id" gorm:"index;not null"`
+	OrderID          string    `json:"order_id" gorm:"index;not null"`
+	InvoiceNumber    string    `json:"invoice_number" gorm:"type:varchar(40)"` // original invoice being credited
+	CreditNoteNumber string    `json:"credit_note_number" gorm:"type:varchar(40);index"`
+	FinancialYear    string    `json:"financial_year" gorm:"type:varchar(7)"`
+	Seq              int       `json:"seq"`
+	Reason           string    `json:"reason" gorm:"type:text"`
+	RefundMethod     string    `json:"refund_method" gorm:"type:varchar(50)"` // cash | upi | card | original
+	FullRefund       bool      `json:"full_refund" gorm:"default:false"`
+	SubTotal         float64   `json:"sub_total" gorm:"type:numeric(10,2);default:0"`
+	TaxAmount        float64   `json:"tax_amount" gorm:"type:numeric(10,2);default:0"`
+	Total            float64   `json:"total" gorm:"type:numeric(10,2);default:0"`
+	CreatedByUserID  string    `json:"created_by_user_id" gorm:"type:varchar(36)"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+
+	Lines []CreditNoteLine `json:"lines" gorm:"foreignKey:CreditNoteID"`
+}
+
+func (CreditNote) TableName() string {
+	return "credit_notes"
+}
+
+func (c *CreditNote) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// CreditNoteLine is one refunded quantity of an order line.
+type CreditNoteLine struct {
+	ID           string  `gorm:"primaryKey" json:"id"`
+	CreditNoteID string  `json:"credit_note_id" gorm:"index;not null"`
+	OrderItemID  string  `json:"order_item_id" gorm:"index;not null"`
+	MenuID       string  `json:"menu_id"`
+	Name         string  `json:"name" gorm:"type:varchar(255)"`
+	Quantity     int     `json:"quantity"`
+	UnitRate     float64 `json:"unit_rate" gorm:"type:numeric(10,2)"`
+	TaxRate      float64 `json:"tax_rate" gorm:"type:numeric(5,2)"`
+	HSNCode      string  `json:"hsn_code,omitempty" gorm:"type:varchar(8)"`
+	SubTotal     float64 `json:"sub_total" gorm:"type:numeric(10,2)"`
+	TaxAmount    float64 `json:"tax_amount" gorm:"type:numeric(10,2)"`
+	Total        float64 `json:"total" gorm:"type:numeric(10,2)"`
+	Restocked    bool    `json:"restocked" gorm:"default:false"`
+}
+
+func (CreditNoteLine) TableName() string {
+	return "credit_note_lines"
+}
+
+func (l *CreditNoteLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GuestOrderRequest is a cart a guest sent from the table QR page. Nothing is added to the
+// order, deducted from stock or printed until staff approve it (or the restaurant
+// auto-approves); the approved lines then form one KOT batch whose sub_id is this ID.
+type GuestOrderRequest struct {
+	ID              string           `gorm:"primaryKey" json:"id"`
+	RestaurantID    string           `json:"restaurant_id" gorm:"index;not null"`
+	TableID         string           `json:"table_id" gorm:"type:varchar(36);index;not null"`
+	TableNumber     string           `json:"table_number" gorm:"type:varchar(50)"`
+	OrderID         *string          `json:"order_id,omitempty" gorm:"type:varchar(36);index"`       // set on approval
+	Status          string           `json:"status" gorm:"type:varchar(16);default:'pending';index"` // pending | approved | rejected
+	Items           []GuestOrderLine `json:"items" gorm:"serializer:json;type:jsonb"`
+	EstimatedTotal  float64          `json:"estimated_total" gorm:"type:numeric(10,2);default:0"` // dine-in menu prices, before tax
+	AutoApproved    bool             `json:"auto_approved" gorm:"default:false"`
+	DecidedByUserID string           `json:"decided_by_user_id,omitempty" gorm:"type:varchar(36)"`
+	RejectReason    string           `json:"reject_reason,omitempty" gorm:"type:varchar(200)"`
+	DecidedAt       *time.Time       `json:"decided_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// GuestOrderLine is one cart line, priced from the dine-in menu when the guest sent it.
+type GuestOrderLine struct {
+	MenuItemID   string  `json:"menu_item_id"`
+	VariantID    string  `json:"variant_id,omitempty"`
+	Name         string  `json:"name"`
+	VariantLabel string  `json:"variant_label,omitempty"`
+	Quantity     int     `json:"quantity"`
+	UnitPrice    float64 `json:"unit_price"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+func (GuestOrderRequest) TableName() string {
+	return "guest_order_requests"
+}
+
+func (r *GuestOrderRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	if r.Status == "" {
+		r.Status = "pending"
+	}
+	return nil
+}
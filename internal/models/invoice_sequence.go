@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Invoice sequence series.
+const (
+	InvoiceSeriesInvoice    = "invoice"
+	InvoiceSeriesCreditNote = "credit_note"
+)
+
+// InvoiceSequence is a per-restaurant document counter for one series (invoices, credit notes)
+// and financial year (e.g. "2025-26"). Rows are incremented inside the posting transaction so
+// numbers stay continuous: a rolled-back payment or refund never consumes a number.
+type InvoiceSequence struct {
+	RestaurantID  string    `json:"restaurant_id" gorm:"primaryKey;type:varchar(36)"`
+	Series        string    `json:"series" gorm:"primaryKey;type:varchar(20);default:'invoice'"`
+	FinancialYear string    `json:"financial_year" gorm:"primaryKey;type:varchar(7)"`
+	LastNumber    int       `json:"last_number" gorm:"not null;default:0"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KDSBump records a kitchen display ticket marked done: the lines it moved to ready and
+// the status each had before, so the kitchen can recall a ticket bumped by mistake.
+type KDSBump struct {
+	ID             string        `gorm:"primaryKey" json:"id"`
+	RestaurantID   string        `json:"restaurant_id" gorm:"index;not null"`
+	OrderID        string        `json:"order_id" gorm:"type:varchar(36);index;not null"`
+	SubID          string        `json:"sub_id"`                                   // KOT batch (OrderItem.SubId)
+	StationID      string        `json:"station_id" gorm:"type:varchar(36);index"` // kitchen station, "main" for the main KOT printer
+	Items          []KDSBumpItem `json:"items" gorm:"serializer:json;type:jsonb"`
+	BumpedByUserID string        `json:"bumped_by_user_id,omitempty" gorm:"type:varchar(36)"`
+	RecalledAt     *time.Time    `json:"recalled_at,omitempty"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// KDSBumpItem is one line a bump moved to ready.
+type KDSBumpItem struct {
+	ItemID     string `json:"item_id"`
+	PrevStatus string `json:"prev_status"`
+}
+
+func (KDSBump) TableName() string {
+	return "kds_bumps"
+}
+
+func (b *KDSBump) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KitchenStation is a named prep area (tandoor, Chinese, bar) with its own KOT printer.
+// Items routed to a station print on that station's slip only; anything unrouted goes
+// to the restaurant's main KOT printer from RestaurantPrintSettings.
+type KitchenStation struct {
+	ID           string    `gorm:"primaryKey" json:"id"`
+	RestaurantID string    `json:"restaurant_id" gorm:"index;not null"`
+	Name         string    `json:"name" gorm:"type:varchar(60);not null"`
+	PrinterHost  string    `json:"printer_host" gorm:"type:varchar(255)"` // IP, hostname, or COM/serial for BT
+	PrinterPort  int       `json:"printer_port" gorm:"default:9100"`
+	PaperWidthMm int       `json:"paper_width_mm" gorm:"default:58"`    // 58 or 80
+	Buzzer       bool      `json:"buzzer" gorm:"default:false"`         // beep this station's printer on new KOTs
+	PlainText    bool      `json:"plain_text" gorm:"default:false"`     // printer can't take ESC/POS styling
+	RasterText   string    `json:"raster_text" gorm:"type:varchar(16)"` // "", non_ascii or all; see RestaurantPrintSettings
+	SortOrder    int       `json:"sort_order" gorm:"default:0"`
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Failover lists printers to reroute to, in order, when the agent can't reach this one.
+	Failover []PrinterEndpoint    `json:"failover" gorm:"serializer:json;type:jsonb"`
+	Rules    []KitchenStationRule `json:"rules,omitempty" gorm:"foreignKey:StationID"`
+}
+
+func (KitchenStation) TableName() string {
+	return "kitchen_stations"
+}
+
+func (s *KitchenStation) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// KitchenStationRule sends a menu category, or a single menu item, to a station.
+// Exactly one of Category / MenuItemID is set; item rules win over category rules.
+type KitchenStationRule struct {
+	ID           string    `gorm:"primaryKey" json:"id"`
+	RestaurantID string    `json:"restaurant_id" gorm:"index;not null"`
+	StationID    string    `json:"station_id" gorm:"type:varchar(36);index;not null"`
+	Category     string    `json:"category,omitempty" gorm:"type:varchar(100)"`
+	MenuItemID   string    `json:"menu_item_id,omitempty" gorm:"type:varchar(36);index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (KitchenStationRule) TableName() string {
+	return "kitchen_station_rules"
+}
+
+func (r *KitchenStationRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -76,6 +77,19 @@ type Restaurant struct {
 	// Extra category labels that must not be appended into dish display names (JSON string array).
 	CategoryDisplayBlocklist string `json:"category_display_blocklist" gorm:"type:text;default:'[]'"`
 	GstNumber                string `json:"gst_number" gorm:"type:varchar(20)"` // GSTIN printed on bills
+	// Timezone is the IANA zone (e.g. Asia/Dubai) for business-day boundaries: counter tickets, history, sales.
+	Timezone                 string          `json:"timezone" gorm:"type:varchar(64)"`
+	// BusinessDayStart is the local HH:MM when the trading day rolls over (e.g. 03:00 for late-night bars).
+	BusinessDayStart         string          `json:"business_day_start" gorm:"type:varchar(5);default:'00:00'"`
+	// InvoicePrefix leads GST invoice numbers (e.g. "BG" → BG/25-26/000123); blank omits it.
+	InvoicePrefix            string          `json:"invoice_prefix" gorm:"type:varchar(16)"`
+	// GuestOrdering lets guests order from the table QR page; GuestOrderAutoApprove sends
+	// their carts to the kitchen without waiting for staff.
+	GuestOrdering            bool            `json:"guest_ordering" gorm:"default:false"`
+	GuestOrderAutoApprove    bool            `json:"guest_order_auto_approve" gorm:"default:false"`
+	// KDSWarnMinutes / KDSLateMinutes turn kitchen display tickets amber, then red.
+	KDSWarnMinutes           int             `json:"kds_warn_minutes" gorm:"default:10"`
+	KDSLateMinutes           int             `json:"kds_late_minutes" gorm:"default:20"`
 	Settings                 json.RawMessage `json:"settings" gorm:"type:jsonb"` // Customizable settings
 	// Restaurant Profile fields
 	ContactNumber string    `json:"contact_number"`
@@ -114,6 +128,7 @@ type Order struct {
 	TableID        *string `json:"table_id" gorm:"index"` // Link to RestaurantTable for dine-in orders
 	CustomerName   string  `json:"customer_name"`
 	CustomerPhone  string  `json:"customer_phone,omitempty" gorm:"type:varchar(20)"`
+	Covers         int     `json:"covers,omitempty" gorm:"default:0"` // guests seated (dine-in), 0 when not captured
 	OrderNumber    int     `json:"order_number" gorm:"index"`                                  // Sequential order number
 	OrderType      string  `json:"order_type" gorm:"default:'dine_in';type:varchar(20);index"` // dine_in | counter
 	TicketNumber   int     `json:"ticket_number" gorm:"default:0;index"`                       // Daily counter ticket (resets each day)
@@ -123,8 +138,18 @@ type Order struct {
 	TaxAmount      float64 `json:"tax_amount" gorm:"type:numeric(10,2);default:0"`
 	DiscountAmount float64 `json:"discount_amount" gorm:"type:numeric(10,2);default:0"`
 	Total          float64 `json:"total" gorm:"type:numeric(10,2);default:0"`
-	PaymentMethod  string  `json:"payment_method" gorm:"type:varchar(50)"` // "cash", "card", "upi", "split"
+	PaymentMethod  string  `json:"payment_method" gorm:"type:varchar(50)"` // "cash", "card", "upi", "wallet", or "split" when several tenders
 	PaymentID      string  `json:"payment_id"`                             // Razorpay payment ID
+	// InvoiceNumber is the GST invoice number assigned at payment (e.g. "BG/25-26/000123");
+	// InvoiceYear + InvoiceSeq are the financial year and position in that year's sequence.
+	InvoiceNumber string `json:"invoice_number,omitempty" gorm:"type:varchar(40)"`
+	InvoiceYear   string `json:"invoice_year,omitempty" gorm:"type:varchar(7)"`
+	InvoiceSeq    int    `json:"invoice_seq,omitempty" gorm:"default:0"`
+	// RefundedAmount is the sum of credit notes issued against this order.
+	RefundedAmount float64 `json:"refunded_amount,omitempty" gorm:"type:numeric(10,2);default:0"`
+	// PlaceOfSupply is the GST state code of the buyer when it differs from the restaurant
+	// (inter-state → IGST). Blank means the restaurant's own state.
+	PlaceOfSupply string `json:"place_of_supply,omitempty" gorm:"type:varchar(2)"`
 	// Payment completion details
 	AmountReceived      float64    `json:"amount_received,omitempty" gorm:"type:numeric(10,2)"`
 	ChangeReturned      float64    `json:"change_returned,omitempty" gorm:"type:numeric(10,2)"`
@@ -136,6 +161,7 @@ type Order struct {
 	BillToken           string     `json:"bill_token,omitempty" gorm:"type:varchar(64);index"`
 	BillExpiresAt       *time.Time `json:"bill_expires_at,omitempty"`
 	BillPreviewDiscount float64    `json:"bill_preview_discount,omitempty" gorm:"type:numeric(10,2);default:0"`
+	DrawerKickedAt      *time.Time `json:"drawer_kicked_at,omitempty"` // first paid bill that opened the cash drawer; reprints never do
 	Notes               string     `json:"notes" gorm:"type:text"`
 	CreatedByUserID     string     `json:"created_by_user_id"`
 	AttendedByUserID    *string    `json:"attended_by_user_id,omitempty" gorm:"type:varchar(36);index"`
@@ -146,6 +172,7 @@ type Order struct {
 	// Relations
 	Restaurant *Restaurant `json:"-" gorm:"foreignKey:RestaurantID"`
 	Items      []OrderItem `json:"-" gorm:"foreignKey:OrderID;cascade:delete"`
+	Tenders    []OrderTender `json:"tenders,omitempty" gorm:"foreignKey:OrderID"`
 	CreatedBy  *User       `json:"-" gorm:"foreignKey:CreatedByUserID"`
 	AttendedBy *User       `json:"-" gorm:"foreignKey:AttendedByUserID"`
 }
@@ -173,6 +200,10 @@ type OrderItem struct {
 	Quantity     int       `json:"quantity" validate:"required,min=1"`
 	UnitRate     float64   `json:"unit_rate" gorm:"type:numeric(10,2)"`
 	Total        float64   `json:"total" gorm:"type:numeric(10,2)"`
+	// TaxRate is the GST slab (percent) snapshotted when the line was added; 0 for exempt lines.
+	TaxRate      *float64  `json:"tax_rate,omitempty" gorm:"type:numeric(5,2)"`
+	HSNCode      string    `json:"hsn_code,omitempty" gorm:"type:varchar(8)"` // HSN/SAC snapshotted with TaxRate
+	RefundedQuantity int   `json:"refunded_quantity,omitempty" gorm:"default:0"` // units credited back after payment
 	Status       string    `json:"status" gorm:"default:'pending';type:varchar(50)"` // pending, preparing, ready, served
 	SubId        string    `json:"sub_id,omitempty" gorm:"index"`                    // Batch tracking for incremental orders
 	Notes        string    `json:"notes" gorm:"type:text"`
@@ -216,6 +247,10 @@ type MenuItem struct {
 	ChannelPrices map[string]float64 `json:"channel_prices" gorm:"serializer:json;type:jsonb"`
 	ReadilyAvailable bool `json:"readily_available" gorm:"default:false"` // skip kitchen (e.g. water, packaged items)
 	IsTaxable        bool `json:"is_taxable" gorm:"default:true"`         // false for MRP items already taxed (e.g. water bottle)
+	// GSTRate is the GST slab in percent (5, 12, 18…). Variants may override it.
+	GSTRate          float64   `json:"gst_rate" gorm:"type:numeric(5,2);default:5"`
+	// HSNCode is the HSN (goods) or SAC (services) code printed on GST invoices; blank uses SAC 996331.
+	HSNCode          string    `json:"hsn_code" gorm:"type:varchar(8)"`
 	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
@@ -246,6 +281,8 @@ type MenuItemVariant struct {
 	Label        string    `json:"label" gorm:"type:varchar(80);not null"` // Half, Full, Family…
 	Price        float64   `json:"price" gorm:"type:numeric(10,2);not null"`
 	RecipeScale  float64   `json:"recipe_scale" gorm:"type:numeric(10,3);default:1"` // multiplies parent recipe BOM
+	// GSTRate overrides the parent item's GST slab for this portion; nil inherits it.
+	GSTRate *float64 `json:"gst_rate,omitempty" gorm:"type:numeric(5,2)"`
 	// ChannelPrices maps channel id → unit price for this portion (e.g. Swiggy Half vs Zomato Half).
 	// Missing channel falls back to Price (and for default Regular, item.channel_prices).
 	ChannelPrices map[string]float64 `json:"channel_prices,omitempty" gorm:"serializer:json;type:jsonb"`
@@ -310,10 +347,13 @@ type Transaction struct {
 	RestaurantID    string    `json:"restaurant_id" gorm:"index" validate:"required"`
 	OrderID         string    `json:"order_id" gorm:"index"`
 	Amount          float64   `json:"amount" gorm:"type:numeric(10,2);not null"`
-	TransactionType string    `json:"transaction_type" gorm:"type:varchar(50)"`         // "sale", "payment", "refund", "expense"
+	TransactionType string    `json:"transaction_type" gorm:"type:varchar(50);index"`   // "payment", "refund", "expense", "expense_reversal", "restock", "payout"
 	PaymentMethod   string    `json:"payment_method"`                                   // "cash", "card", "upi", "bank_transfer"
-	PaymentID       string    `json:"payment_id"`                                       // Razorpay/external payment ID
+	PaymentID       string    `json:"payment_id"`                                       // UPI reference / external payment ID
 	Status          string    `json:"status" gorm:"default:'pending';type:varchar(50)"` // pending, completed, failed
+	UserID          string    `json:"user_id,omitempty" gorm:"type:varchar(36);index"`  // staff member who recorded the movement
+	CashSessionID   *string   `json:"cash_session_id,omitempty" gorm:"type:varchar(36);index"` // open drawer of UserID for cash movements
+	ReferenceID     string    `json:"reference_id,omitempty" gorm:"type:varchar(36)"`  // credit note / expense / ingredient id
 	Notes           string    `json:"notes" gorm:"type:text"`
 	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime;index"`
 	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
@@ -336,6 +376,15 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ErrTransactionImmutable is returned when code tries to rewrite a ledger row.
+// Corrections are new rows (refund, expense_reversal), never edits.
+var ErrTransactionImmutable = errors.New("transactions are immutable")
+
+// BeforeUpdate keeps the ledger append-only.
+func (t *Transaction) BeforeUpdate(tx *gorm.DB) error {
+	return ErrTransactionImmutable
+}
+
 // AuditLog tracks all important changes
 type AuditLog struct {
 	ID           string          `gorm:"primaryKey" json:"id"`
@@ -376,6 +425,28 @@ type NotificationEvent struct {
 	Timestamp time.Time       `json:"timestamp"`
 	Version   int             `json:"version"`
 	Seq       int64           `json:"seq"`
+
+	// Subscription scope: clients only get topics their role allows and they subscribed
+	// to, narrowed by table and kitchen station when they filter on those.
+	Topic      string   `json:"topic,omitempty"`
+	TableID    string   `json:"table_id,omitempty"`
+	StationIDs []string `json:"station_ids,omitempty"`
+}
+
+// WSSubscriptionData confirms what a WebSocket client now receives (the "subscribed"
+// event); Denied lists requested topics the user's role may not see.
+type WSSubscriptionData struct {
+	Topics   []string `json:"topics"`
+	Stations []string `json:"stations,omitempty"`
+	Tables   []string `json:"tables,omitempty"`
+	Denied   []string `json:"denied,omitempty"`
+}
+
+// ResyncEventData tells a reconnecting client its missed events are no longer in the
+// replay log; it should refetch orders and tables and continue from Seq.
+type ResyncEventData struct {
+	LastSeq int64 `json:"last_seq"`
+	Seq     int64 `json:"seq"`
 }
 
 // WSOrderItem is the WebSocket-safe order line (includes menu name for clients).
@@ -421,6 +492,65 @@ type OrderEventData struct {
 	BulkUpdate    bool          `json:"bulk_update,omitempty"`
 }
 
+// RefundEventData is sent when a credit note is issued against a paid order.
+type RefundEventData struct {
+	OrderID          string  `json:"order_id"`
+	OrderNumber      int     `json:"order_number"`
+	InvoiceNumber    string  `json:"invoice_number,omitempty"`
+	CreditNoteID     string  `json:"credit_note_id"`
+	CreditNoteNumber string  `json:"credit_note_number"`
+	Amount           float64 `json:"amount"`
+	RefundedAmount   float64 `json:"refunded_amount"`
+	FullyRefunded    bool    `json:"fully_refunded"`
+	RefundMethod     string  `json:"refund_method,omitempty"`
+}
+
+// PrintJobEventData is sent when a print job fails for good and needs staff attention.
+type PrintJobEventData struct {
+	JobID        string `json:"job_id"`
+	OrderID      string `json:"order_id,omitempty"`
+	OrderNumber  int    `json:"order_number,omitempty"`
+	TableNo      string `json:"table_no,omitempty"`
+	JobType      string `json:"job_type"`
+	Target       string `json:"target"`
+	StationID    string `json:"station_id,omitempty"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// PrintAgentEventData is sent when a restaurant loses its last online print agent or gets one back.
+type PrintAgentEventData struct {
+	Online bool               `json:"online"`
+	Agents []PrintAgentStatus `json:"agents"`
+}
+
+// GuestOrderEventData is sent when a guest submits a cart from the table QR page
+// (guest_order_requested) and when staff approve or reject it.
+type GuestOrderEventData struct {
+	RequestID      string           `json:"request_id"`
+	TableID        string           `json:"table_id"`
+	TableNumber    string           `json:"table_number"`
+	OrderID        string           `json:"order_id,omitempty"`
+	Status         string           `json:"status"`
+	AutoApproved   bool             `json:"auto_approved,omitempty"`
+	Items          []GuestOrderLine `json:"items"`
+	EstimatedTotal float64          `json:"estimated_total"`
+	RejectReason   string           `json:"reject_reason,omitempty"`
+}
+
+// KDSTicketEventData is sent when the kitchen display bumps a ticket (kds_ticket_bumped)
+// or recalls the last bump (kds_ticket_recalled).
+type KDSTicketEventData struct {
+	BumpID      string   `json:"bump_id"`
+	OrderID     string   `json:"order_id"`
+	OrderNumber int      `json:"order_number"`
+	TableNo     string   `json:"table_no,omitempty"`
+	SubID       string   `json:"sub_id"`
+	StationID   string   `json:"station_id"`
+	ItemIDs     []string `json:"item_ids"`
+}
+
 // TableEventData for WebSocket table status updates
 type TableEventData struct {
 	TableID             string  `json:"table_id"`
@@ -814,6 +944,14 @@ func (si *SupportIssue) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// PrinterEndpoint is one entry in a printer failover list: either another configured
+// printer by target (kot_printer, bill_printer) or a host and port of its own.
+type PrinterEndpoint struct {
+	Target string `json:"target,omitempty"`
+	Host   string `json:"host,omitempty"`
+	Port   int    `json:"port,omitempty"`
+}
+
 // RestaurantPrintSettings holds LAN printer targets and enable flags for the on-site print agent.
 type RestaurantPrintSettings struct {
 	RestaurantID         string    `gorm:"primaryKey" json:"restaurant_id"`
@@ -831,6 +969,16 @@ type RestaurantPrintSettings struct {
 	KotPaperWidthMm      int       `json:"kot_paper_width_mm" gorm:"default:58"`  // 58 or 80
 	TopFeedLines         int       `json:"top_feed_lines" gorm:"default:0"`    // blank lines before slip content
 	BottomFeedLines      int       `json:"bottom_feed_lines" gorm:"default:3"` // blank lines before cutter
+	BillOpenDrawer       bool      `json:"bill_open_drawer" gorm:"default:false"` // kick the cash drawer on paid cash/split bills
+	KotBuzzer            bool      `json:"kot_buzzer" gorm:"default:false"`       // beep the main KOT printer on new KOTs
+	KotPlainText         bool      `json:"kot_plain_text" gorm:"default:false"`   // printer can't take ESC/POS styling; strip markup
+	BillPlainText        bool      `json:"bill_plain_text" gorm:"default:false"`
+	BillLogoURL          string    `json:"bill_logo_url" gorm:"type:varchar(500)"` // PNG/JPEG printed as a raster logo on bills
+	KotRasterText        string    `json:"kot_raster_text" gorm:"type:varchar(16)"` // "", non_ascii or all: lines the agent draws as images
+	BillRasterText       string    `json:"bill_raster_text" gorm:"type:varchar(16)"`
+	// Ordered printers to reroute to when the agent can't reach the KOT or bill printer.
+	KotFailover  []PrinterEndpoint `json:"kot_failover" gorm:"serializer:json;type:jsonb"`
+	BillFailover []PrinterEndpoint `json:"bill_failover" gorm:"serializer:json;type:jsonb"`
 	AgentAPIKeyHash      string    `json:"-" gorm:"type:varchar(64);index"`
 	AgentAPIKeyHint      string    `json:"agent_api_key_hint,omitempty" gorm:"type:varchar(12)"` // last 4 chars for UI
 	CreatedAt            time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -844,10 +992,17 @@ func (RestaurantPrintSettings) TableName() string {
 // PrintJob is a queued ESC/POS text slip for the on-site print agent.
 type PrintJob struct {
 	ID           string     `gorm:"primaryKey" json:"id"`
-	RestaurantID string     `json:"restaurant_id" gorm:"index;not null"`
+	RestaurantID string     `json:"restaurant_id" gorm:"index;not null;uniqueIndex:idx_print_jobs_restaurant_spool,where:spool_id <> ''"`
 	OrderID      string     `json:"order_id" gorm:"index"`
 	JobType      string     `json:"job_type" gorm:"type:varchar(16);not null"` // kot | bill
 	Target       string     `json:"target" gorm:"type:varchar(16);not null"`   // kot_printer | bill_printer
+	StationID    string     `json:"station_id,omitempty" gorm:"type:varchar(36);index"` // kitchen station for routed KOTs; "" = main KOT printer
+	ReprintOfID  string     `json:"reprint_of_id,omitempty" gorm:"type:varchar(36);index"` // original job when this is a staff reprint
+	SpoolID      string     `json:"spool_id,omitempty" gorm:"type:varchar(64);uniqueIndex:idx_print_jobs_restaurant_spool"` // agent's local id when printed from its LAN spool offline
+	OpenDrawer   bool       `json:"open_drawer" gorm:"default:false"` // agent pulses the drawer-kick pin after the cut
+	Buzzer       bool       `json:"buzzer" gorm:"default:false"`      // agent sounds the printer buzzer after the cut
+	PrinterIndex int        `json:"printer_index" gorm:"default:0"`   // position in the failover chain; 0 = the configured printer
+	ReroutedFrom string     `json:"rerouted_from,omitempty" gorm:"type:varchar(255)"` // printer that was unreachable before the last reroute
 	PayloadText  string     `json:"payload_text" gorm:"type:text;not null"`
 	Status       string     `json:"status" gorm:"type:varchar(16);default:pending;index"` // pending|claimed|done|failed
 	ClaimedBy    string     `json:"claimed_by,omitempty" gorm:"type:varchar(120)"`
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderTender is one payment applied to an order: a cash amount, a UPI transfer, a card
+// swipe on a standalone terminal or a wallet payment. An order may be settled by any
+// number of tenders whose amounts add up to its total.
+type OrderTender struct {
+	ID           string `gorm:"primaryKey" json:"id"`
+	OrderID      string `json:"order_id" gorm:"type:varchar(36);index;not null"`
+	RestaurantID string `json:"restaurant_id" gorm:"index;not null"`
+	Method       string `json:"method" gorm:"type:varchar(16);not null;index"` // cash | upi | card | wallet
+	// Provider names the wallet or card network when known (e.g. "paytm", "phonepe", "visa").
+	Provider string  `json:"provider,omitempty" gorm:"type:varchar(40)"`
+	Amount   float64 `json:"amount" gorm:"type:numeric(10,2);not null"`
+	// Reference is the UPI transaction ID, card terminal approval code or wallet reference.
+	Reference string    `json:"reference,omitempty" gorm:"type:varchar(120)"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (OrderTender) TableName() string {
+	return "order_tenders"
+}
+
+func (t *OrderTender) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
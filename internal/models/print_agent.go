@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PrintAgentStatus is the last heartbeat received from one on-site print agent.
+// Online flips to false when the agent misses heartbeats; the dashboard reads this
+// through the print settings API.
+type PrintAgentStatus struct {
+	ID            string          `gorm:"primaryKey" json:"id"`
+	RestaurantID  string          `json:"restaurant_id" gorm:"not null;uniqueIndex:idx_print_agents_restaurant_agent"`
+	AgentID       string          `json:"agent_id" gorm:"type:varchar(120);not null;uniqueIndex:idx_print_agents_restaurant_agent"`
+	Version       string          `json:"version" gorm:"type:varchar(40)"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Printers      json.RawMessage `json:"printers" gorm:"type:jsonb"` // []PrintAgentPrinterStatus as last reported
+	RemoteIP      string          `json:"remote_ip" gorm:"type:varchar(64)"`
+	SpoolURL      string          `json:"spool_url,omitempty" gorm:"type:varchar(255)"` // LAN fallback endpoint for POS devices
+	SpoolPending  int             `json:"spool_pending"`                                // offline prints not yet synced
+	Online        bool            `json:"online" gorm:"default:false;index"`
+	LastSeenAt    time.Time       `json:"last_seen_at" gorm:"index"`
+	WentOfflineAt *time.Time      `json:"went_offline_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (PrintAgentStatus) TableName() string {
+	return "print_agents"
+}
+
+func (a *PrintAgentStatus) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ZReport is the frozen end-of-day closing report for one business date. The snapshot and
+// slip text never change after creation, so every reprint and email is identical.
+type ZReport struct {
+	ID                string          `gorm:"primaryKey" json:"id"`
+	RestaurantID      string          `json:"restaurant_id" gorm:"not null;uniqueIndex:idx_z_reports_restaurant_date;uniqueIndex:idx_z_reports_restaurant_number"`
+	BusinessDate      string          `json:"business_date" gorm:"type:varchar(10);not null;uniqueIndex:idx_z_reports_restaurant_date"` // YYYY-MM-DD
+	ReportNumber      int             `json:"report_number" gorm:"not null;uniqueIndex:idx_z_reports_restaurant_number"`
+	PeriodStart       time.Time       `json:"period_start"`
+	PeriodEnd         time.Time       `json:"period_end"`
+	Snapshot          json.RawMessage `json:"snapshot" gorm:"type:jsonb"`
+	SlipText          string          `json:"slip_text" gorm:"type:text"`
+	GeneratedByUserID string          `json:"generated_by_user_id" gorm:"type:varchar(36)"`
+	PrintCount        int             `json:"print_count" gorm:"default:0"`
+	LastEmailedTo     string          `json:"last_emailed_to,omitempty" gorm:"type:varchar(255)"`
+	LastEmailedAt     *time.Time      `json:"last_emailed_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ZReport) TableName() string {
+	return "z_reports"
+}
+
+func (z *ZReport) BeforeCreate(tx *gorm.DB) error {
+	if z.ID == "" {
+		z.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisSeqKeyPrefix = "billgenie:wsseq:"
+	redisLogKeyPrefix = "billgenie:wslog:"
+
+	// eventLogSize is how many recent events each restaurant keeps for replay; a client
+	// further behind than this is told to resync.
+	eventLogSize = 500
+	// eventLogTTL drops the counter and log of restaurants that have gone quiet.
+	eventLogTTL = 24 * time.Hour
+)
+
+// EventLog numbers WebSocket events per room and keeps the most recent ones so a client
+// reconnecting with last_seq can be sent what it missed. With REDIS_URL the counter and log
+// are shared by every instance; otherwise they live in this process.
+//
+// A room's counter starts from the wall clock (milliseconds × 1000) the first time it is
+// used, so sequence numbers keep rising across restarts and expired Redis keys, and a
+// last_seq from an older counter falls outside the log and asks for a resync instead of
+// replaying unrelated events.
+type EventLog struct {
+	client *redis.Client
+	size   int
+	mu     sync.Mutex
+	rooms  map[string]*roomLog
+}
+
+type roomLog struct {
+	head   int64
+	events []models.NotificationEvent // oldest first, at most size
+}
+
+// NewEventLog creates the log; uses Redis when REDIS_URL is set and reachable.
+func NewEventLog() *EventLog {
+	l := newMemoryEventLog(eventLogSize)
+	client, err := dialRedis()
+	if err != nil {
+		log.Printf("⚠️  %v — WebSocket replay log is local-only", err)
+		return l
+	}
+	l.client = client
+	return l
+}
+
+func newMemoryEventLog(size int) *EventLog {
+	return &EventLog{size: size, rooms: make(map[string]*roomLog)}
+}
+
+func seqBase() int64 {
+	return time.Now().UnixMilli() * 1000
+}
+
+// Append stamps event with the room's next sequence number and records it. If Redis is
+// unreachable the event keeps seq 0: it is still delivered live but cannot be replayed.
+func (l *EventLog) Append(roomID string, event *models.NotificationEvent) {
+	if l.client != nil {
+		if err := l.appendRedis(roomID, event); err != nil {
+			log.Printf("⚠️  WS event log append error: %v", err)
+			event.Seq = 0
+		}
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.rooms[roomID]
+	if r == nil {
+		r = &roomLog{head: seqBase()}
+		l.rooms[roomID] = r
+	}
+	r.head++
+	event.Seq = r.head
+	if len(r.events) >= l.size {
+		r.events = append(r.events[:0], r.events[len(r.events)-l.size+1:]...)
+	}
+	r.events = append(r.events, *event)
+}
+
+// Head returns the room's latest sequence number, or 0 when nothing has been published.
+func (l *EventLog) Head(roomID string) int64 {
+	if l.client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		head, err := l.client.Get(ctx, redisSeqKeyPrefix+roomID).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			log.Printf("⚠️  WS event log head error: %v", err)
+		}
+		return head
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r := l.rooms[roomID]; r != nil {
+		return r.head
+	}
+	return 0
+}
+
+// Since returns the room's events after lastSeq, oldest first, with the current head.
+// ok is false when the log no longer reaches back to lastSeq, or lastSeq belongs to an
+// older counter; the client must then refetch its state.
+func (l *EventLog) Since(roomID string, lastSeq int64) (events []models.NotificationEvent, head int64, ok bool) {
+	if l.client != nil {
+		events, head, err := l.sinceRedis(roomID, lastSeq)
+		if err != nil {
+			log.Printf("⚠️  WS event log replay error: %v", err)
+			return nil, head, false
+		}
+		return events, head, replayCovers(events, head, lastSeq)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.rooms[roomID]
+	if r == nil {
+		return nil, 0, false
+	}
+	for i, e := range r.events {
+		if e.Seq > lastSeq {
+			events = append([]models.NotificationEvent(nil), r.events[i:]...)
+			break
+		}
+	}
+	return events, r.head, replayCovers(events, r.head, lastSeq)
+}
+
+// replayCovers reports whether events pick up right after lastSeq. The newest events may
+// still be on their way into a shared log; those reach the client live.
+func replayCovers(events []models.NotificationEvent, head, lastSeq int64) bool {
+	if lastSeq <= 0 || lastSeq > head {
+		return false
+	}
+	if lastSeq == head {
+		return true
+	}
+	return len(events) > 0 && events[0].Seq == lastSeq+1
+}
+
+func (l *EventLog) appendRedis(roomID string, event *models.NotificationEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	seqKey, logKey := redisSeqKeyPrefix+roomID, redisLogKeyPrefix+roomID
+
+	var incr *redis.IntCmd
+	if _, err := l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, seqKey, seqBase(), 0)
+		incr = pipe.Incr(ctx, seqKey)
+		pipe.Expire(ctx, seqKey, eventLogTTL)
+		return nil
+	}); err != nil {
+		return err
+	}
+	event.Seq = incr.Val()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, logKey, redis.Z{Score: float64(event.Seq), Member: payload})
+		pipe.ZRemRangeByRank(ctx, logKey, 0, int64(-l.size-1))
+		pipe.Expire(ctx, logKey, eventLogTTL)
+		return nil
+	})
+	return err
+}
+
+func (l *EventLog) sinceRedis(roomID string, lastSeq int64) ([]models.NotificationEvent, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var headCmd *redis.StringCmd
+	var rangeCmd *redis.StringSliceCmd
+	_, err := l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		headCmd = pipe.Get(ctx, redisSeqKeyPrefix+roomID)
+		rangeCmd = pipe.ZRangeByScore(ctx, redisLogKeyPrefix+roomID, &redis.ZRangeBy{
+			Min: "(" + strconv.FormatInt(lastSeq, 10),
+			Max: "+inf",
+		})
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+	head, _ := headCmd.Int64()
+
+	members := rangeCmd.Val()
+	events := make([]models.NotificationEvent, 0, len(members))
+	for _, m := range members {
+		var event models.NotificationEvent
+		if err := json.Unmarshal([]byte(m), &event); err != nil {
+			return nil, head, err
+		}
+		events = append(events, event)
+	}
+	return events, head, nil
+}
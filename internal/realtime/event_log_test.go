@@ -0,0 +1,43 @@
+package realtime
+
+import (
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestMemoryEventLogReplay(t *testing.T) {
+	l := newMemoryEventLog(3)
+	if _, _, ok := l.Since("r1", 10); ok {
+		t.Fatal("an unknown room cannot be replayed")
+	}
+
+	seqs := make([]int64, 5)
+	for i := range seqs {
+		event := models.NotificationEvent{Type: "order_updated"}
+		l.Append("r1", &event)
+		seqs[i] = event.Seq
+		if i > 0 && seqs[i] != seqs[i-1]+1 {
+			t.Fatalf("seq should rise by one: %v", seqs)
+		}
+	}
+	other := models.NotificationEvent{}
+	l.Append("r2", &other)
+	if l.Head("r1") != seqs[4] {
+		t.Fatalf("head = %d, want %d", l.Head("r1"), seqs[4])
+	}
+
+	events, head, ok := l.Since("r1", seqs[2])
+	if !ok || head != seqs[4] || len(events) != 2 || events[0].Seq != seqs[3] {
+		t.Fatalf("replay after %d: ok=%v head=%d events=%+v", seqs[2], ok, head, events)
+	}
+	if events, _, ok := l.Since("r1", seqs[4]); !ok || len(events) != 0 {
+		t.Fatalf("an up-to-date client replays nothing: ok=%v events=%d", ok, len(events))
+	}
+	if _, _, ok := l.Since("r1", seqs[0]); ok {
+		t.Fatal("events trimmed from the log must ask for a resync")
+	}
+	if _, _, ok := l.Since("r1", seqs[4]+50); ok {
+		t.Fatal("a last_seq ahead of the head comes from another counter")
+	}
+}
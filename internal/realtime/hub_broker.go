@@ -0,0 +1,87 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisHubChannelPrefix = "billgenie:hub:"
+
+// HubBroker carries SSE hub messages (order tracking, table assistance, print agent
+// wake-ups) between API instances over Redis pub/sub, so a stream attached to one machine
+// sees updates made on another. Without REDIS_URL it hands them straight to this
+// instance's hubs.
+type HubBroker struct {
+	client   *redis.Client
+	mu       sync.RWMutex
+	handlers map[string]func(key string, payload []byte)
+}
+
+// NewHubBroker creates the broker; uses Redis when REDIS_URL is set and reachable.
+func NewHubBroker() *HubBroker {
+	b := &HubBroker{handlers: make(map[string]func(key string, payload []byte))}
+	client, err := dialRedis()
+	if err != nil {
+		log.Printf("⚠️  %v — SSE hubs stay local-only", err)
+		return b
+	}
+	if client == nil {
+		log.Println("ℹ️  REDIS_URL not set — SSE hub fan-out is local-only (single instance)")
+		return b
+	}
+	b.client = client
+	go b.listen()
+	log.Println("✅ Redis pub/sub connected for SSE hub fan-out")
+	return b
+}
+
+// Subscribe registers the local hub that delivers messages for topic.
+func (b *HubBroker) Subscribe(topic string, deliver func(key string, payload []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = deliver
+}
+
+// Publish sends payload to topic subscribers for key on every instance, this one included.
+func (b *HubBroker) Publish(topic, key string, payload []byte) {
+	if b.client == nil {
+		b.deliver(topic, key, payload)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := b.client.Publish(ctx, redisHubChannelPrefix+topic+":"+key, payload).Err(); err != nil {
+		log.Printf("⚠️  Redis hub publish error: %v", err)
+		// Fallback to local delivery if Redis fails
+		b.deliver(topic, key, payload)
+	}
+}
+
+func (b *HubBroker) deliver(topic, key string, payload []byte) {
+	b.mu.RLock()
+	handler := b.handlers[topic]
+	b.mu.RUnlock()
+	if handler != nil {
+		handler(key, payload)
+	}
+}
+
+func (b *HubBroker) listen() {
+	ctx := context.Background()
+	pubsub := b.client.PSubscribe(ctx, redisHubChannelPrefix+"*")
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		// billgenie:hub:<topic>:<key>; topics never contain ':'.
+		topic, key, ok := strings.Cut(strings.TrimPrefix(msg.Channel, redisHubChannelPrefix), ":")
+		if !ok {
+			continue
+		}
+		b.deliver(topic, key, []byte(msg.Payload))
+	}
+}
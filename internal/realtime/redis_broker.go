@@ -3,6 +3,7 @@ package realtime
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -30,16 +31,35 @@ type RedisBroker struct {
 
 // NewRedisBroker connects to Redis when REDIS_URL is set. Returns nil if unset.
 func NewRedisBroker(broadcaster RoomBroadcaster) *RedisBroker {
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
+	client, err := dialRedis()
+	if err != nil {
+		log.Printf("⚠️  %v — continuing without Redis", err)
+		return nil
+	}
+	if client == nil {
 		log.Println("ℹ️  REDIS_URL not set — WebSocket fan-out is local-only (single instance)")
 		return nil
 	}
 
+	broker := &RedisBroker{
+		client:      client,
+		broadcaster: broadcaster,
+	}
+	broker.Start()
+	log.Println("✅ Redis pub/sub connected for WebSocket fan-out")
+	return broker
+}
+
+// dialRedis connects to REDIS_URL; it returns a nil client when the variable is unset.
+func dialRedis() (*redis.Client, error) {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		return nil, nil
+	}
+
 	opts, err := redis.ParseURL(redisURL)
 	if err != nil {
-		log.Printf("⚠️  Invalid REDIS_URL: %v — continuing without Redis", err)
-		return nil
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
 	}
 
 	client := redis.NewClient(opts)
@@ -47,17 +67,10 @@ func NewRedisBroker(broadcaster RoomBroadcaster) *RedisBroker {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
-		log.Printf("⚠️  Redis ping failed: %v — continuing without Redis", err)
-		return nil
-	}
-
-	broker := &RedisBroker{
-		client:      client,
-		broadcaster: broadcaster,
+		_ = client.Close()
+		return nil, fmt.Errorf("redis ping failed: %w", err)
 	}
-	broker.Start()
-	log.Println("✅ Redis pub/sub connected for WebSocket fan-out")
-	return broker
+	return client, nil
 }
 
 // Start subscribes to all restaurant channels on this instance.
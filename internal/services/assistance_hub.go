@@ -1,11 +1,16 @@
 package services
 
-import "sync"
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
 
 // AssistanceHub fans out table assistance status to customer SSE subscribers per assistance token.
 type AssistanceHub struct {
-	mu   sync.RWMutex
-	subs map[string]map[chan AssistanceStatus]struct{}
+	mu     sync.RWMutex
+	subs   map[string]map[chan AssistanceStatus]struct{}
+	broker HubBroker
 }
 
 func NewAssistanceHub() *AssistanceHub {
@@ -14,6 +19,21 @@ func NewAssistanceHub() *AssistanceHub {
 	}
 }
 
+// UseBroker publishes through broker so pages attached to other instances get updates too.
+func (h *AssistanceHub) UseBroker(broker HubBroker) {
+	broker.Subscribe(hubTopicAssistance, func(token string, payload []byte) {
+		var status AssistanceStatus
+		if err := json.Unmarshal(payload, &status); err != nil {
+			log.Printf("⚠️  assistance hub message parse error: %v", err)
+			return
+		}
+		h.deliver(token, status)
+	})
+	h.mu.Lock()
+	h.broker = broker
+	h.mu.Unlock()
+}
+
 func (h *AssistanceHub) Subscribe(token string) chan AssistanceStatus {
 	ch := make(chan AssistanceStatus, 4)
 	h.mu.Lock()
@@ -38,6 +58,21 @@ func (h *AssistanceHub) Unsubscribe(token string, ch chan AssistanceStatus) {
 }
 
 func (h *AssistanceHub) Publish(token string, status AssistanceStatus) {
+	h.mu.RLock()
+	broker := h.broker
+	h.mu.RUnlock()
+	if broker != nil {
+		payload, err := json.Marshal(status)
+		if err == nil {
+			broker.Publish(hubTopicAssistance, token, payload)
+			return
+		}
+		log.Printf("⚠️  assistance hub marshal error: %v", err)
+	}
+	h.deliver(token, status)
+}
+
+func (h *AssistanceHub) deliver(token string, status AssistanceStatus) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for ch := range h.subs[token] {
@@ -208,6 +208,7 @@ func (s *AuthService) Register(req RegisterRequest) (*models.Restaurant, *models
 		IsActive:                 true,
 		IsSelfService:            isSelfService,
 		CounterServiceModes:      counterModes,
+		Timezone:                 DefaultRestaurantTimezone(),
 		SubscriptionEnd:          subscriptionEnd,
 		SubscriptionPlan:         "custom",
 		SubscriptionMonthlyPrice: deal.MonthlyPrice,
@@ -70,6 +70,12 @@ func BuildBillPDF(summary BillSummaryView) ([]byte, error) {
 	if g := strings.TrimSpace(summary.GstNumber); g != "" {
 		centerText("GSTIN: "+g, 9, false)
 	}
+	if inv := strings.TrimSpace(summary.InvoiceNumber); inv != "" {
+		centerText("Invoice No: "+inv, 9, true)
+	}
+	if summary.PlaceOfSupply != "" && len(summary.TaxSummary) > 0 {
+		centerText("Place of supply: "+PlaceOfSupplyLabel(summary.PlaceOfSupply), 9, false)
+	}
 
 	meta := billPDFMetaLine(summary)
 	if meta != "" {
@@ -136,7 +142,12 @@ func BuildBillPDF(summary BillSummaryView) ([]byte, error) {
 		totalsRow(label, formatPDFMoney(summary.SubTotal), false, 9)
 	}
 	if summary.TaxAmount > 0 && !summary.CompositeScheme {
-		totalsRow("GST (5%)", formatPDFMoney(summary.TaxAmount), false, 9)
+		if len(summary.TaxBreakdown) == 0 {
+			totalsRow("GST", formatPDFMoney(summary.TaxAmount), false, 9)
+		}
+		for _, line := range GSTComponentLines(summary.TaxBreakdown) {
+			totalsRow(line.Label, formatPDFMoney(line.Amount), false, 9)
+		}
 	}
 	if summary.DiscountAmount > 0 {
 		totalsRow("Discount", "-"+formatPDFMoney(summary.DiscountAmount), false, 9)
@@ -149,11 +160,54 @@ func BuildBillPDF(summary BillSummaryView) ([]byte, error) {
 	body.WriteString("0 g\n")
 	space(12)
 	totalsRow("Total", formatPDFMoney(summary.Total), true, 12)
-	if summary.IsPaid && strings.TrimSpace(summary.PaymentMethod) != "" {
+	if summary.IsPaid && len(summary.Tenders) > 0 {
+		for _, tender := range summary.Tenders {
+			space(2)
+			label := tender.Label
+			if tender.Reference != "" {
+				label += " - Ref " + tender.Reference
+			}
+			totalsRow(label, formatPDFMoney(tender.Amount), false, 9)
+		}
+	} else if summary.IsPaid && strings.TrimSpace(summary.PaymentMethod) != "" {
 		space(2)
 		totalsRow("Payment", strings.ToUpper(summary.PaymentMethod), false, 9)
 	}
 
+	// HSN/SAC-wise tax summary required on GST invoices.
+	if len(summary.TaxSummary) > 0 {
+		space(8)
+		hline()
+		const colRate = left + 96
+		const colTaxable = left + 160
+		const colTax1 = left + 220
+		writeText("HSN/SAC", 7, left, y, true)
+		rightText("RATE", 7, colRate, true)
+		rightText("TAXABLE", 7, colTaxable, true)
+		if summary.InterState {
+			rightText("IGST", 7, right, true)
+		} else {
+			rightText("CGST", 7, colTax1, true)
+			rightText("SGST", 7, right, true)
+		}
+		y -= 12
+		for _, row := range summary.TaxSummary {
+			if y < 60 {
+				break
+			}
+			writeText(row.HSNCode, 8, left, y, false)
+			rightText(formatGSTRate(row.Rate)+"%", 8, colRate, false)
+			rightText(formatPDFMoney(row.TaxableValue), 8, colTaxable, false)
+			if summary.InterState {
+				rightText(formatPDFMoney(row.IGST), 8, right, false)
+			} else {
+				rightText(formatPDFMoney(row.CGST), 8, colTax1, false)
+				rightText(formatPDFMoney(row.SGST), 8, right, false)
+			}
+			y -= 12
+		}
+	}
+
 	space(16)
 	centerText("Thank you for dining with us.", 9, false)
 	space(8)
@@ -49,6 +49,35 @@ func TestBuildBillPDF_LooksLikeReceipt(t *testing.T) {
 	}
 }
 
+func TestBuildBillPDF_PrintsGSTComponentsAndSummary(t *testing.T) {
+	slabs := SplitGSTSlabs([]GSTSlab{{Rate: 5, TaxableValue: 200, TaxAmount: 10}}, false)
+	pdf, err := BuildBillPDF(BillSummaryView{
+		RestaurantName: "Test Kitchen",
+		Items:          []BillItemView{{Name: "Masala Dosa", Quantity: 2, UnitRate: 100, Total: 200}},
+		SubTotal:       200,
+		TaxAmount:      10,
+		Total:          210,
+		TaxBreakdown:   slabs,
+		TaxSummary: []GSTTaxSummaryRow{
+			{HSNCode: DefaultRestaurantSAC, Rate: 5, TaxableValue: 200, CGST: 5, SGST: 5, TaxAmount: 10},
+		},
+		PlaceOfSupply: "29",
+		InvoiceNumber: "BG/25-26/000123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(pdf)
+	for _, want := range []string{"CGST @ 2.5%", "SGST @ 2.5%", "HSN/SAC", "996331", "Invoice No: BG/25-26/000123", "Place of supply: Karnataka \\(29\\)"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("PDF missing %q", want)
+		}
+	}
+	if strings.Contains(body, "IGST") {
+		t.Fatal("intra-state bill should not print IGST")
+	}
+}
+
 func TestHelveticaStringWidth_CentersTitles(t *testing.T) {
 	// Bold uppercase should be wider than the old len*0.48 heuristic.
 	w := helveticaStringWidth("BILL SUMMARY", 8, true)
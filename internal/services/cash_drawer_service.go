@@ -0,0 +1,405 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CashSessionOpen   = "open"
+	CashSessionClosed = "closed"
+
+	CashMovementPayout = "payout"
+	CashMovementDrop   = "drop"
+)
+
+var (
+	ErrCashSessionNotFound    = errors.New("cash session not found")
+	ErrCashSessionClosed      = errors.New("cash session is already closed")
+	ErrCashSessionAlreadyOpen = errors.New("you already have an open cash drawer; close it first")
+)
+
+// CashDrawerService manages per-cashier till sessions. Cash sales and refunds are read
+// from the transaction ledger rows stamped with the session id, not from order columns.
+type CashDrawerService struct {
+	db *gorm.DB
+}
+
+func NewCashDrawerService(db *gorm.DB) *CashDrawerService {
+	return &CashDrawerService{db: db}
+}
+
+// CashSessionTotals are the running figures for a drawer.
+type CashSessionTotals struct {
+	CashSales    float64 `json:"cash_sales"`
+	CashRefunds  float64 `json:"cash_refunds"`
+	Payouts      float64 `json:"payouts"`
+	Drops        float64 `json:"drops"`
+	ExpectedCash float64 `json:"expected_cash"`
+}
+
+// ExpectedDrawerCash is what should be in the till: float plus cash taken, minus cash handed back
+// and cash removed as payouts or drops.
+func ExpectedDrawerCash(openingFloat float64, t CashSessionTotals) float64 {
+	return roundPaise(openingFloat + t.CashSales - t.CashRefunds - t.Payouts - t.Drops)
+}
+
+// openCashSessionID returns the user's open drawer, or "" when they have none.
+func openCashSessionID(db *gorm.DB, restaurantID, userID string) (string, error) {
+	var ids []string
+	if err := db.Model(&models.CashDrawerSession{}).
+		Where("restaurant_id = ? AND cashier_user_id = ? AND status = ?", restaurantID, userID, CashSessionOpen).
+		Order("opened_at DESC").
+		Limit(1).
+		Pluck("id", &ids).Error; err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
+// Open starts a drawer for the cashier with an opening float.
+func (s *CashDrawerService) Open(restaurantID, cashierUserID string, openingFloat float64, notes string) (*models.CashDrawerSession, error) {
+	if openingFloat < 0 {
+		return nil, errors.New("opening_float cannot be negative")
+	}
+	session := models.CashDrawerSession{
+		RestaurantID:  restaurantID,
+		CashierUserID: cashierUserID,
+		Status:        CashSessionOpen,
+		OpeningFloat:  roundPaise(openingFloat),
+		OpenNotes:     strings.TrimSpace(notes),
+		OpenedAt:      time.Now(),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Serialise opens per cashier on the user row so two taps cannot open two drawers.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ? AND restaurant_id = ?", cashierUserID, restaurantID).
+			First(&models.User{}).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New("cashier not found")
+			}
+			return err
+		}
+		existing, err := openCashSessionID(tx, restaurantID, cashierUserID)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			return ErrCashSessionAlreadyOpen
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Current returns the cashier's open drawer with running totals, or nil when none is open.
+func (s *CashDrawerService) Current(restaurantID, cashierUserID string) (*models.CashDrawerSession, error) {
+	id, err := openCashSessionID(s.db, restaurantID, cashierUserID)
+	if err != nil || id == "" {
+		return nil, err
+	}
+	return s.Get(restaurantID, id)
+}
+
+// Get loads a drawer with its movements. Open drawers get live totals filled in.
+func (s *CashDrawerService) Get(restaurantID, sessionID string) (*models.CashDrawerSession, error) {
+	var session models.CashDrawerSession
+	if err := s.db.Preload("Movements", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Cashier").
+		Where("id = ? AND restaurant_id = ?", sessionID, restaurantID).
+		First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrCashSessionNotFound
+		}
+		return nil, err
+	}
+	if session.Status == CashSessionOpen {
+		totals, err := s.totals(s.db, &session)
+		if err != nil {
+			return nil, err
+		}
+		applyCashTotals(&session, totals)
+	}
+	return &session, nil
+}
+
+func (s *CashDrawerService) totals(db *gorm.DB, session *models.CashDrawerSession) (CashSessionTotals, error) {
+	var t CashSessionTotals
+	if err := db.Model(&models.Transaction{}).
+		Where("cash_session_id = ? AND LOWER(payment_method) = 'cash'", session.ID).
+		Select(`
+			COALESCE(SUM(CASE WHEN transaction_type = 'payment' THEN amount ELSE 0 END), 0) AS cash_sales,
+			COALESCE(SUM(CASE WHEN transaction_type = 'refund' THEN amount ELSE 0 END), 0) AS cash_refunds`).
+		Scan(&t).Error; err != nil {
+		return t, err
+	}
+	if err := db.Model(&models.CashDrawerMovement{}).
+		Where("session_id = ?", session.ID).
+		Select(`
+			COALESCE(SUM(CASE WHEN kind = 'payout' THEN amount ELSE 0 END), 0) AS payouts,
+			COALESCE(SUM(CASE WHEN kind = 'drop' THEN amount ELSE 0 END), 0) AS drops`).
+		Scan(&t).Error; err != nil {
+		return t, err
+	}
+	t.ExpectedCash = ExpectedDrawerCash(session.OpeningFloat, t)
+	return t, nil
+}
+
+func applyCashTotals(session *models.CashDrawerSession, t CashSessionTotals) {
+	session.CashSales = roundPaise(t.CashSales)
+	session.CashRefunds = roundPaise(t.CashRefunds)
+	session.Payouts = roundPaise(t.Payouts)
+	session.Drops = roundPaise(t.Drops)
+	session.ExpectedCash = t.ExpectedCash
+}
+
+// RecordMovement takes cash out of an open drawer. Payouts leave the business and are also
+// written to the ledger; drops only move cash to the safe.
+func (s *CashDrawerService) RecordMovement(restaurantID, sessionID, userID, kind string, amount float64, reason string) (*models.CashDrawerMovement, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind != CashMovementPayout && kind != CashMovementDrop {
+		return nil, errors.New("kind must be payout or drop")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	reason = strings.TrimSpace(reason)
+	if kind == CashMovementPayout && reason == "" {
+		return nil, errors.New("reason is required for payouts")
+	}
+
+	movement := models.CashDrawerMovement{
+		SessionID:    sessionID,
+		RestaurantID: restaurantID,
+		Kind:         kind,
+		Amount:       roundPaise(amount),
+		Reason:       reason,
+		UserID:       userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session, err := lockCashSession(tx, restaurantID, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != CashSessionOpen {
+			return ErrCashSessionClosed
+		}
+		if err := tx.Create(&movement).Error; err != nil {
+			return err
+		}
+		if kind != CashMovementPayout {
+			return nil
+		}
+		return RecordTransaction(tx, models.Transaction{
+			RestaurantID:    restaurantID,
+			Amount:          movement.Amount,
+			TransactionType: TransactionTypePayout,
+			PaymentMethod:   "cash",
+			UserID:          userID,
+			CashSessionID:   &session.ID,
+			ReferenceID:     movement.ID,
+			Notes:           reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// Close freezes the drawer totals against the counted cash.
+func (s *CashDrawerService) Close(restaurantID, sessionID, userID string, counted float64, notes string) (*models.CashDrawerSession, error) {
+	if counted < 0 {
+		return nil, errors.New("counted_cash cannot be negative")
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session, err := lockCashSession(tx, restaurantID, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != CashSessionOpen {
+			return ErrCashSessionClosed
+		}
+		totals, err := s.totals(tx, session)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		countedCash := roundPaise(counted)
+		return tx.Model(session).Updates(map[string]interface{}{
+			"status":            CashSessionClosed,
+			"cash_sales":        roundPaise(totals.CashSales),
+			"cash_refunds":      roundPaise(totals.CashRefunds),
+			"payouts":           roundPaise(totals.Payouts),
+			"drops":             roundPaise(totals.Drops),
+			"expected_cash":     totals.ExpectedCash,
+			"counted_cash":      countedCash,
+			"over_short":        roundPaise(countedCash - totals.ExpectedCash),
+			"close_notes":       strings.TrimSpace(notes),
+			"closed_by_user_id": userID,
+			"closed_at":         now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(restaurantID, sessionID)
+}
+
+func lockCashSession(tx *gorm.DB, restaurantID, sessionID string) (*models.CashDrawerSession, error) {
+	var session models.CashDrawerSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND restaurant_id = ?", sessionID, restaurantID).
+		First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrCashSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CashSessionFilter narrows a drawer listing. Zero values are ignored.
+type CashSessionFilter struct {
+	Status        string
+	CashierUserID string
+	From          time.Time
+	ToEnd         time.Time
+}
+
+// List returns drawers opened in the window, newest first.
+func (s *CashDrawerService) List(restaurantID string, filter CashSessionFilter, limit, offset int) ([]models.CashDrawerSession, int64, error) {
+	query := s.db.Model(&models.CashDrawerSession{}).Where("restaurant_id = ?", restaurantID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CashierUserID != "" {
+		query = query.Where("cashier_user_id = ?", filter.CashierUserID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("opened_at >= ?", filter.From)
+	}
+	if !filter.ToEnd.IsZero() {
+		query = query.Where("opened_at < ?", filter.ToEnd)
+	}
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var sessions []models.CashDrawerSession
+	if err := query.Session(&gorm.Session{}).
+		Preload("Cashier").
+		Order("opened_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, count, nil
+}
+
+// CashierOverShort sums closed drawers for one cashier.
+type CashierOverShort struct {
+	CashierUserID string  `json:"cashier_user_id"`
+	CashierName   string  `json:"cashier_name"`
+	Sessions      int64   `json:"sessions"`
+	CashSales     float64 `json:"cash_sales"`
+	ExpectedCash  float64 `json:"expected_cash"`
+	CountedCash   float64 `json:"counted_cash"`
+	OverShort     float64 `json:"over_short"`
+}
+
+// CashierReport returns over/short per cashier for drawers closed in [from, toEnd).
+func (s *CashDrawerService) CashierReport(restaurantID string, from, toEnd time.Time) ([]CashierOverShort, error) {
+	var rows []CashierOverShort
+	err := s.db.Table("cash_drawer_sessions AS s").
+		Select(`s.cashier_user_id,
+			COALESCE(MAX(u.name), '') AS cashier_name,
+			COUNT(*) AS sessions,
+			COALESCE(SUM(s.cash_sales), 0) AS cash_sales,
+			COALESCE(SUM(s.expected_cash), 0) AS expected_cash,
+			COALESCE(SUM(s.counted_cash), 0) AS counted_cash,
+			COALESCE(SUM(s.over_short), 0) AS over_short`).
+		Joins("LEFT JOIN users u ON u.id = s.cashier_user_id").
+		Where("s.restaurant_id = ? AND s.status = ? AND s.closed_at >= ? AND s.closed_at < ?", restaurantID, CashSessionClosed, from, toEnd).
+		Group("s.cashier_user_id").
+		Order("cashier_name ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CashSessionSlip is the printable summary of a drawer.
+func CashSessionSlip(restaurantName string, session *models.CashDrawerSession, cal BusinessCalendar, width int) string {
+	if width <= 0 {
+		width = 32
+	}
+	loc := cal.location()
+	divider := strings.Repeat("-", width)
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	if name := strings.TrimSpace(restaurantName); name != "" {
+		line(printCenterLine(printSafeText(name), width))
+	}
+	title := "CASH DRAWER - OPEN"
+	if session.Status == CashSessionClosed {
+		title = "CASH DRAWER CLOSE"
+	}
+	line(printCenterLine(title, width))
+	line(divider)
+	cashier := session.CashierUserID
+	if session.Cashier != nil && strings.TrimSpace(session.Cashier.Name) != "" {
+		cashier = session.Cashier.Name
+	}
+	line(printPadLine("Cashier", printSafeText(cashier), width))
+	line(printPadLine("Opened", session.OpenedAt.In(loc).Format("02 Jan 03:04 PM"), width))
+	if session.ClosedAt != nil {
+		line(printPadLine("Closed", session.ClosedAt.In(loc).Format("02 Jan 03:04 PM"), width))
+	}
+	line(divider)
+	line(printPadLine("Opening float", money(session.OpeningFloat), width))
+	line(printPadLine("+ Cash sales", money(session.CashSales), width))
+	line(printPadLine("- Cash refunds", money(session.CashRefunds), width))
+	line(printPadLine("- Payouts", money(session.Payouts), width))
+	line(printPadLine("- Drops", money(session.Drops), width))
+	line(divider)
+	line(printPadLine("Expected cash", money(session.ExpectedCash), width))
+	if session.CountedCash != nil {
+		line(printPadLine("Counted cash", money(*session.CountedCash), width))
+		label := "Over"
+		if session.OverShort < 0 {
+			label = "Short"
+		}
+		line(printPadLine(label, money(session.OverShort), width))
+	}
+	if len(session.Movements) > 0 {
+		line(divider)
+		for _, m := range session.Movements {
+			label := strings.ToUpper(m.Kind[:1]) + m.Kind[1:]
+			if m.Reason != "" {
+				label += " " + printSafeText(m.Reason)
+			}
+			line(printPadLine(label, money(m.Amount), width))
+		}
+	}
+	line(divider)
+	line(printCenterLine("Printed "+time.Now().In(loc).Format("02 Jan 2006 03:04 PM"), width))
+	return b.String()
+}
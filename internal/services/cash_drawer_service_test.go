@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-api/internal/models"
+)
+
+func TestExpectedDrawerCash(t *testing.T) {
+	got := ExpectedDrawerCash(2000, CashSessionTotals{CashSales: 5430.5, CashRefunds: 120, Payouts: 300, Drops: 4000})
+	if !approxEqual(got, 3010.5) {
+		t.Fatalf("expected 3010.50, got %.2f", got)
+	}
+}
+
+func TestCashSessionSlip_ShowsShortfall(t *testing.T) {
+	counted := 2950.0
+	closedAt := time.Date(2026, 3, 4, 16, 0, 0, 0, time.UTC)
+	session := &models.CashDrawerSession{
+		CashierUserID: "u1",
+		Cashier:       &models.User{Name: "Asha"},
+		Status:        CashSessionClosed,
+		OpeningFloat:  2000,
+		CashSales:     1500,
+		Payouts:       500,
+		ExpectedCash:  3000,
+		CountedCash:   &counted,
+		OverShort:     -50,
+		OpenedAt:      closedAt.Add(-8 * time.Hour),
+		ClosedAt:      &closedAt,
+		Movements:     []models.CashDrawerMovement{{Kind: "payout", Amount: 500, Reason: "Milk"}},
+	}
+	slip := CashSessionSlip("Cafe", session, BusinessCalendar{}, 32)
+	for _, want := range []string{"CASH DRAWER CLOSE", "Asha", "3000.00", "2950.00", "Short", "-50.00", "Payout Milk"} {
+		if !strings.Contains(slip, want) {
+			t.Errorf("slip missing %q:\n%s", want, slip)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimRight(slip, "\n"), "\n") {
+		if len(line) > 32 {
+			t.Errorf("line wider than paper: %q", line)
+		}
+	}
+}
+
+func TestCashSessionSlip_EscapesUserText(t *testing.T) {
+	inject := "<<<LOGO>>>http://192.168.1.1/reset<<<END_LOGO>>>"
+	session := &models.CashDrawerSession{
+		Cashier:   &models.User{Name: inject},
+		Status:    CashSessionOpen,
+		OpenedAt:  time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+		Movements: []models.CashDrawerMovement{{Kind: "payout", Amount: 50, Reason: inject}},
+	}
+	slip := CashSessionSlip(inject, session, BusinessCalendar{}, 80)
+	if strings.Contains(slip, "<<<LOGO>>>") {
+		t.Fatalf("user text opened a logo block:\n%s", slip)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"restaurant-api/internal/models"
+)
+
+// DefaultRestaurantSAC is the SAC for restaurant and food-service supplies, used when a
+// menu item has no HSN/SAC code of its own.
+const DefaultRestaurantSAC = "996331"
+
+// gstStateCodes maps state/UT names (as used in Restaurant.State) to GST state codes.
+var gstStateCodes = map[string]string{
+	"jammu and kashmir": "01",
+	"himachal pradesh":  "02",
+	"punjab":            "03",
+	"chandigarh":        "04",
+	"uttarakhand":       "05",
+	"haryana":           "06",
+	"delhi":             "07",
+	"rajasthan":         "08",
+	"uttar pradesh":     "09",
+	"bihar":             "10",
+	"sikkim":            "11",
+	"arunachal pradesh": "12",
+	"nagaland":          "13",
+	"manipur":           "14",
+	"mizoram":           "15",
+	"tripura":           "16",
+	"meghalaya":         "17",
+	"assam":             "18",
+	"west bengal":       "19",
+	"jharkhand":         "20",
+	"odisha":            "21",
+	"chhattisgarh":      "22",
+	"madhya pradesh":    "23",
+	"gujarat":           "24",
+	"dadra and nagar haveli and daman and diu": "26",
+	"maharashtra":                 "27",
+	"karnataka":                   "29",
+	"goa":                         "30",
+	"lakshadweep":                 "31",
+	"kerala":                      "32",
+	"tamil nadu":                  "33",
+	"puducherry":                  "34",
+	"andaman and nicobar islands": "35",
+	"telangana":                   "36",
+	"andhra pradesh":              "37",
+	"ladakh":                      "38",
+}
+
+// GSTStateCode resolves a state name, two-digit GST state code, or GSTIN to its state code.
+func GSTStateCode(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if len(value) == 15 && value[0] >= '0' && value[0] <= '9' && value[1] >= '0' && value[1] <= '9' {
+		value = value[:2] // GSTIN: first two digits are the state code
+	}
+	if len(value) == 2 {
+		if _, err := strconv.Atoi(value); err == nil {
+			for _, code := range gstStateCodes {
+				if code == value {
+					return code
+				}
+			}
+			return ""
+		}
+	}
+	key := strings.ReplaceAll(normalizeLocationKey(value), "&", "and")
+	return gstStateCodes[key]
+}
+
+// GSTStateName returns the display name for a GST state code ("29" → "Karnataka").
+func GSTStateName(code string) string {
+	for name, c := range gstStateCodes {
+		if c != code {
+			continue
+		}
+		words := strings.Fields(name)
+		for i, w := range words {
+			if w != "and" {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	return ""
+}
+
+// PlaceOfSupplyLabel formats a state code for invoices, e.g. "Karnataka (29)".
+func PlaceOfSupplyLabel(code string) string {
+	if name := GSTStateName(code); name != "" {
+		return name + " (" + code + ")"
+	}
+	return code
+}
+
+// NormalizePlaceOfSupply validates a place of supply (state name, state code or customer GSTIN)
+// and returns its two-digit GST state code. Blank means the restaurant's own state.
+func NormalizePlaceOfSupply(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	code := GSTStateCode(value)
+	if code == "" {
+		return "", errors.New("place_of_supply must be an Indian state, GST state code or GSTIN")
+	}
+	return code, nil
+}
+
+// IsInterStateSupply reports whether a sale is taxed as IGST: the place of supply is set
+// and resolves to a different state than the restaurant. Unknown states stay intra-state.
+func IsInterStateSupply(restaurant *models.Restaurant, placeOfSupply string) bool {
+	if restaurant == nil {
+		return false
+	}
+	supply := GSTStateCode(placeOfSupply)
+	if supply == "" {
+		return false
+	}
+	home := GSTStateCode(restaurant.GstNumber)
+	if home == "" {
+		home = GSTStateCode(restaurant.State)
+	}
+	return home != "" && home != supply
+}
+
+// SplitGSTSlabs fills the CGST/SGST halves (intra-state) or IGST (inter-state) on each slab.
+func SplitGSTSlabs(slabs []GSTSlab, interState bool) []GSTSlab {
+	out := make([]GSTSlab, len(slabs))
+	for i, slab := range slabs {
+		slab.CGST, slab.SGST, slab.IGST = 0, 0, 0
+		if interState {
+			slab.IGST = slab.TaxAmount
+		} else {
+			slab.CGST = math.Round(slab.TaxAmount*50) / 100
+			slab.SGST = slab.TaxAmount - slab.CGST
+		}
+		out[i] = slab
+	}
+	return out
+}
+
+// GSTComponentLine is one tax row on a bill ("CGST @ 2.5%", "IGST @ 18%").
+type GSTComponentLine struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// GSTComponentLines expands split slabs into bill rows.
+func GSTComponentLines(slabs []GSTSlab) []GSTComponentLine {
+	lines := make([]GSTComponentLine, 0, len(slabs)*2)
+	for _, slab := range slabs {
+		if slab.IGST > 0 {
+			lines = append(lines, GSTComponentLine{Label: "IGST @ " + formatGSTRate(slab.Rate) + "%", Amount: slab.IGST})
+			continue
+		}
+		half := formatGSTRate(slab.Rate/2) + "%"
+		lines = append(lines,
+			GSTComponentLine{Label: "CGST @ " + half, Amount: slab.CGST},
+			GSTComponentLine{Label: "SGST @ " + half, Amount: slab.SGST},
+		)
+	}
+	return lines
+}
+
+func formatGSTRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+// GSTTaxSummaryRow is one HSN/SAC + rate line of the tax summary table printed under the bill.
+type GSTTaxSummaryRow struct {
+	HSNCode      string  `json:"hsn_code"`
+	Rate         float64 `json:"rate"`
+	TaxableValue float64 `json:"taxable_value"`
+	CGST         float64 `json:"cgst"`
+	SGST         float64 `json:"sgst"`
+	IGST         float64 `json:"igst"`
+	TaxAmount    float64 `json:"tax_amount"`
+}
+
+// MenuItemHSNCode returns the menu item's HSN/SAC code, or the restaurant-service SAC.
+func MenuItemHSNCode(menuItem *models.MenuItem) string {
+	if menuItem != nil {
+		if code := strings.TrimSpace(menuItem.HSNCode); code != "" {
+			return code
+		}
+	}
+	return DefaultRestaurantSAC
+}
+
+// OrderItemHSNCode returns the HSN/SAC code snapshotted on the line, falling back to the menu item.
+func OrderItemHSNCode(item models.OrderItem) string {
+	if code := strings.TrimSpace(item.HSNCode); code != "" {
+		return code
+	}
+	return MenuItemHSNCode(item.MenuItem)
+}
+
+// NormalizeHSNCode validates an HSN (goods) or SAC (services) code: 4, 6 or 8 digits.
+func NormalizeHSNCode(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := strconv.Atoi(value); err != nil || (len(value) != 4 && len(value) != 6 && len(value) != 8) {
+		return "", errors.New("hsn_code must be a 4, 6 or 8 digit HSN/SAC code")
+	}
+	return value, nil
+}
+
+// BuildGSTTaxSummary apportions each split slab across the HSN/SAC codes of the lines taxed
+// at that rate, by line gross. Rows are ordered by rate then code.
+func BuildGSTTaxSummary(items []models.OrderItem, slabs []GSTSlab) []GSTTaxSummaryRow {
+	type key struct {
+		rate float64
+		code string
+	}
+	grossByKey := make(map[key]float64)
+	grossByRate := make(map[float64]float64)
+	for _, item := range items {
+		if item.Status == "cancelled" {
+			continue
+		}
+		rate := OrderItemGSTRate(item)
+		if rate <= 0 {
+			continue
+		}
+		grossByKey[key{rate, OrderItemHSNCode(item)}] += item.Total
+		grossByRate[rate] += item.Total
+	}
+
+	rows := make([]GSTTaxSummaryRow, 0, len(grossByKey))
+	for _, slab := range slabs {
+		rateGross := grossByRate[slab.Rate]
+		if rateGross <= 0 {
+			continue
+		}
+		for k, gross := range grossByKey {
+			if k.rate != slab.Rate {
+				continue
+			}
+			share := gross / rateGross
+			rows = append(rows, GSTTaxSummaryRow{
+				HSNCode:      k.code,
+				Rate:         slab.Rate,
+				TaxableValue: slab.TaxableValue * share,
+				CGST:         slab.CGST * share,
+				SGST:         slab.SGST * share,
+				IGST:         slab.IGST * share,
+				TaxAmount:    slab.TaxAmount * share,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Rate != rows[j].Rate {
+			return rows[i].Rate < rows[j].Rate
+		}
+		return rows[i].HSNCode < rows[j].HSNCode
+	})
+	return rows
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestGSTStateCode(t *testing.T) {
+	cases := map[string]string{
+		"Karnataka":       "29",
+		" tamil  nadu ":   "33",
+		"29":              "29",
+		"27AAAAA0000A1Z5": "27",
+		"Jammu & Kashmir": "01",
+		"Atlantis":        "",
+		"99":              "",
+	}
+	for in, want := range cases {
+		if got := GSTStateCode(in); got != want {
+			t.Errorf("GSTStateCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if name := GSTStateName("36"); name != "Telangana" {
+		t.Errorf("GSTStateName(36) = %q", name)
+	}
+}
+
+func TestIsInterStateSupply(t *testing.T) {
+	r := &models.Restaurant{State: "Karnataka"}
+	if IsInterStateSupply(r, "") {
+		t.Fatal("blank place of supply should be intra-state")
+	}
+	if IsInterStateSupply(r, "29") {
+		t.Fatal("same state should be intra-state")
+	}
+	if !IsInterStateSupply(r, "Maharashtra") {
+		t.Fatal("different state should be inter-state")
+	}
+	// GSTIN state wins over the profile state.
+	if IsInterStateSupply(&models.Restaurant{State: "Karnataka", GstNumber: "27AAAAA0000A1Z5"}, "27") {
+		t.Fatal("GSTIN state should be the home state")
+	}
+}
+
+func TestSplitGSTSlabsAndLines(t *testing.T) {
+	slabs := []GSTSlab{{Rate: 5, TaxableValue: 200, TaxAmount: 10.01}, {Rate: 18, TaxableValue: 100, TaxAmount: 18}}
+	intra := SplitGSTSlabs(slabs, false)
+	if !approxEqual(intra[0].CGST+intra[0].SGST, 10.01) || intra[0].IGST != 0 {
+		t.Fatalf("unexpected intra split %+v", intra[0])
+	}
+	lines := GSTComponentLines(intra)
+	if len(lines) != 4 || lines[0].Label != "CGST @ 2.5%" || lines[3].Label != "SGST @ 9%" {
+		t.Fatalf("unexpected lines %+v", lines)
+	}
+	inter := SplitGSTSlabs(slabs, true)
+	lines = GSTComponentLines(inter)
+	if len(lines) != 2 || lines[1].Label != "IGST @ 18%" || !approxEqual(lines[1].Amount, 18) {
+		t.Fatalf("unexpected inter lines %+v", lines)
+	}
+}
+
+func TestBuildGSTTaxSummaryGroupsByHSN(t *testing.T) {
+	five, eighteen := 5.0, 18.0
+	items := []models.OrderItem{
+		{Total: 150, TaxRate: &five},
+		{Total: 50, TaxRate: &five, HSNCode: "2202"},
+		{Total: 100, TaxRate: &eighteen, HSNCode: "2202"},
+		{Total: 80, TaxRate: &five, Status: "cancelled"},
+	}
+	slabs := SplitGSTSlabs(CalculateRestaurantOrderTax(orderItemsGrossByRate(items), 0, RestaurantTaxSettings{}).Slabs, false)
+	rows := BuildGSTTaxSummary(items, slabs)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", rows)
+	}
+	if rows[0].HSNCode != "2202" || rows[0].Rate != 5 || !approxEqual(rows[0].TaxableValue, 50) || !approxEqual(rows[0].TaxAmount, 2.5) {
+		t.Fatalf("unexpected first row %+v", rows[0])
+	}
+	if rows[1].HSNCode != DefaultRestaurantSAC || !approxEqual(rows[1].CGST, 3.75) {
+		t.Fatalf("unexpected SAC row %+v", rows[1])
+	}
+	if rows[2].Rate != 18 || !approxEqual(rows[2].SGST, 9) {
+		t.Fatalf("unexpected 18%% row %+v", rows[2])
+	}
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	GuestOrderStatusPending  = "pending"
+	GuestOrderStatusApproved = "approved"
+	GuestOrderStatusRejected = "rejected"
+)
+
+const (
+	maxGuestOrderLines      = 30
+	maxGuestOrderQuantity   = 20
+	maxGuestOrdersPending   = 3 // per table, so a QR page can't flood the floor staff
+	guestOrderNotesMaxChars = 200
+	// guestOrderRecentWindow is how long decided carts stay on the table page.
+	guestOrderRecentWindow = 2 * time.Hour
+)
+
+var (
+	ErrGuestOrderingOff      = errors.New("ordering from the table is not enabled here; please call a waiter")
+	ErrGuestOrderNotFound    = errors.New("guest order not found")
+	ErrGuestOrderDecided     = errors.New("guest order was already approved or rejected")
+	ErrGuestOrderBillPending = errors.New("the bill has been requested for this table; please call a waiter to add items")
+	ErrGuestOrderTooMany     = errors.New("your previous orders are still waiting for staff; please wait a moment")
+)
+
+// GuestOrderInput is the cart a guest submits from /a/:token.
+type GuestOrderInput struct {
+	Items []CreateOrderItemRequest `json:"items"`
+}
+
+// AssistanceGuestOrder is a guest cart as the table page shows it.
+type AssistanceGuestOrder struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	ItemCount    int       `json:"item_count"`
+	RejectReason string    `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GuestOrderApproval is what an approval changed, for the caller to broadcast and print.
+type GuestOrderApproval struct {
+	Request     *models.GuestOrderRequest
+	Order       *models.Order
+	Table       *models.RestaurantTable
+	NewOrder    bool
+	Ingredients []models.Ingredient
+}
+
+// GuestOrderService turns guest carts from the table QR page into order batches.
+type GuestOrderService struct {
+	db     *gorm.DB
+	orders *OrderService
+}
+
+func NewGuestOrderService(db *gorm.DB, orders *OrderService) *GuestOrderService {
+	if orders == nil {
+		orders = NewOrderService(db)
+	}
+	return &GuestOrderService{db: db, orders: orders}
+}
+
+// normalizeGuestOrderItems applies the order item rules to a guest cart and merges repeated
+// lines (same item, portion and notes).
+func normalizeGuestOrderItems(items []CreateOrderItemRequest) ([]CreateOrderItemRequest, error) {
+	if len(items) == 0 {
+		return nil, errors.New("add at least one item")
+	}
+	out := make([]CreateOrderItemRequest, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		item.MenuItemID = strings.TrimSpace(item.MenuItemID)
+		item.VariantID = strings.TrimSpace(item.VariantID)
+		item.Notes = strings.TrimSpace(item.Notes)
+		if item.MenuItemID == "" {
+			return nil, errors.New("menu_item_id is required for each item")
+		}
+		if item.Quantity < 1 || item.Quantity > maxGuestOrderQuantity {
+			return nil, fmt.Errorf("item quantity must be between 1 and %d", maxGuestOrderQuantity)
+		}
+		if len([]rune(item.Notes)) > guestOrderNotesMaxChars {
+			return nil, fmt.Errorf("item notes must be at most %d characters", guestOrderNotesMaxChars)
+		}
+		key := item.MenuItemID + "|" + item.VariantID + "|" + item.Notes
+		if i, ok := index[key]; ok {
+			out[i].Quantity += item.Quantity
+			if out[i].Quantity > maxGuestOrderQuantity {
+				return nil, fmt.Errorf("item quantity must be between 1 and %d", maxGuestOrderQuantity)
+			}
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, item)
+	}
+	if len(out) > maxGuestOrderLines {
+		return nil, fmt.Errorf("at most %d different items per order", maxGuestOrderLines)
+	}
+	return out, nil
+}
+
+// tableOpenOrder is the table's current order while it can still take items.
+func tableOpenOrder(db *gorm.DB, table *models.RestaurantTable) (*models.Order, error) {
+	if table.CurrentOrderID == nil || strings.TrimSpace(*table.CurrentOrderID) == "" {
+		return nil, nil
+	}
+	var order models.Order
+	err := db.Where("id = ? AND restaurant_id = ?", *table.CurrentOrderID, table.RestaurantID).First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == "completed" || order.Status == "cancelled" {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+// Submit validates a guest cart against the dine-in menu and stores it as a pending
+// request. autoApprove reports the restaurant's setting; the caller then approves it.
+func (s *GuestOrderService) Submit(table *models.RestaurantTable, input GuestOrderInput) (req *models.GuestOrderRequest, autoApprove bool, err error) {
+	if table == nil {
+		return nil, false, errors.New("table not found")
+	}
+	var restaurant models.Restaurant
+	if err := s.db.Select("id", "guest_ordering", "guest_order_auto_approve", "category_display_blocklist").
+		Where("id = ?", table.RestaurantID).First(&restaurant).Error; err != nil {
+		return nil, false, err
+	}
+	if !restaurant.GuestOrdering {
+		return nil, false, ErrGuestOrderingOff
+	}
+	items, err := normalizeGuestOrderItems(input.Items)
+	if err != nil {
+		return nil, false, err
+	}
+
+	order, err := tableOpenOrder(s.db, table)
+	if err != nil {
+		return nil, false, err
+	}
+	if order != nil && strings.TrimSpace(order.BillToken) != "" &&
+		(order.BillExpiresAt == nil || order.BillExpiresAt.After(time.Now())) {
+		return nil, false, ErrGuestOrderBillPending
+	}
+
+	var pending int64
+	if err := s.db.Model(&models.GuestOrderRequest{}).
+		Where("table_id = ? AND status = ?", table.ID, GuestOrderStatusPending).
+		Count(&pending).Error; err != nil {
+		return nil, false, err
+	}
+	if pending >= maxGuestOrdersPending {
+		return nil, false, ErrGuestOrderTooMany
+	}
+
+	menuByID, err := loadMenuItemsMap(s.db, table.RestaurantID, uniqueMenuItemIDs(items))
+	if err != nil {
+		return nil, false, err
+	}
+	blocklist := ParseCategoryDisplayBlocklist(restaurant.CategoryDisplayBlocklist)
+	lines := make([]models.GuestOrderLine, 0, len(items))
+	total := 0.0
+	for _, item := range items {
+		menuItem := menuByID[item.MenuItemID]
+		if !menuItemOnDineInChannel(&menuItem) {
+			return nil, false, fmt.Errorf("%s is not available right now", menuItem.Name)
+		}
+		price, label, _, _, _, err := ResolveOrderVariant(
+			s.db, table.RestaurantID, menuItem.ID, item.VariantID, menuItem.Price,
+			MenuChannelForOrder("dine_in", ""), menuItem.ChannelPrices,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+		lines = append(lines, models.GuestOrderLine{
+			MenuItemID:   menuItem.ID,
+			VariantID:    item.VariantID,
+			Name:         FormatItemDisplayName(menuItem.Name, menuItem.Category, "", blocklist),
+			VariantLabel: label,
+			Quantity:     item.Quantity,
+			UnitPrice:    price,
+			Notes:        item.Notes,
+		})
+		total += price * float64(item.Quantity)
+	}
+
+	req = &models.GuestOrderRequest{
+		RestaurantID:   table.RestaurantID,
+		TableID:        table.ID,
+		TableNumber:    table.Name,
+		Status:         GuestOrderStatusPending,
+		Items:          lines,
+		EstimatedTotal: total,
+	}
+	if err := s.db.Create(req).Error; err != nil {
+		return nil, false, err
+	}
+	log.Printf("🔵 [GuestOrder] Table %s sent %d line(s), request %s", table.Name, len(lines), req.ID)
+	return req, restaurant.GuestOrderAutoApprove, nil
+}
+
+// List returns the restaurant's guest orders, newest first; status "" means pending.
+func (s *GuestOrderService) List(restaurantID, status string, limit int) ([]models.GuestOrderRequest, error) {
+	if status == "" {
+		status = GuestOrderStatusPending
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var out []models.GuestOrderRequest
+	q := s.db.Where("restaurant_id = ?", restaurantID)
+	if status != "all" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Order("created_at DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+func (s *GuestOrderService) get(restaurantID, requestID string) (*models.GuestOrderRequest, error) {
+	var req models.GuestOrderRequest
+	if err := s.db.Where("id = ? AND restaurant_id = ?", requestID, restaurantID).First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGuestOrderNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// decide moves a pending request to approved or rejected. Only one caller wins, so two
+// staff tapping Approve at once cannot send the batch to the kitchen twice.
+func (s *GuestOrderService) decide(db *gorm.DB, req *models.GuestOrderRequest, status, userID, reason string, auto bool) error {
+	now := time.Now()
+	res := db.Model(&models.GuestOrderRequest{}).
+		Where("id = ? AND status = ?", req.ID, GuestOrderStatusPending).
+		Updates(map[string]interface{}{
+			"status":             status,
+			"decided_by_user_id": userID,
+			"reject_reason":      reason,
+			"auto_approved":      auto,
+			"decided_at":         now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrGuestOrderDecided
+	}
+	req.Status = status
+	req.DecidedByUserID = userID
+	req.RejectReason = reason
+	req.AutoApproved = auto
+	req.DecidedAt = &now
+	return nil
+}
+
+// Approve adds a pending guest cart to the table's open order, or opens one and seats the
+// table, through the same CreateOrder/UpdateOrder path staff orders take. userID is blank
+// when the restaurant auto-approves.
+//
+// The decision and the order write share one transaction with the table row locked, so
+// approvals for the same table (staff and auto-approve racing, or two carts) queue up and
+// each sees the order the previous one opened. If anything fails, nothing is written and
+// the request stays pending for staff to retry or reject.
+func (s *GuestOrderService) Approve(restaurantID, requestID, userID string) (*GuestOrderApproval, error) {
+	req, err := s.get(restaurantID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != GuestOrderStatusPending {
+		return nil, ErrGuestOrderDecided
+	}
+
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	var table models.RestaurantTable
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND restaurant_id = ?", req.TableID, restaurantID).First(&table).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("table not found")
+	}
+	if err := s.decide(tx, req, GuestOrderStatusApproved, userID, "", userID == ""); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	result, err := s.applyApproved(tx, req, &table, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	order, err := s.orders.reloadOrderWithItems(result.Order.ID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	result.Order = order
+	log.Printf("✅ [GuestOrder] Request %s approved into order #%d (table %s)", req.ID, result.Order.OrderNumber, table.Name)
+	return result, nil
+}
+
+func (s *GuestOrderService) applyApproved(tx *gorm.DB, req *models.GuestOrderRequest, table *models.RestaurantTable, userID string) (*GuestOrderApproval, error) {
+	orderReq := CreateOrderRequest{
+		RestaurantID: req.RestaurantID,
+		TableNumber:  table.Name,
+		TableID:      &table.ID,
+		OrderType:    "dine_in",
+		BatchSubID:   req.ID,
+	}
+	for _, line := range req.Items {
+		orderReq.Items = append(orderReq.Items, CreateOrderItemRequest{
+			MenuItemID: line.MenuItemID,
+			VariantID:  line.VariantID,
+			Quantity:   line.Quantity,
+			Notes:      line.Notes,
+		})
+	}
+
+	result := &GuestOrderApproval{Request: req, Table: table}
+	open, err := tableOpenOrder(tx, table)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		result.Order, result.Ingredients, err = s.orders.updateOrderTx(tx, req.RestaurantID, open.ID, orderReq)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var restaurant models.Restaurant
+		if err := tx.Where("id = ?", req.RestaurantID).First(&restaurant).Error; err != nil {
+			return nil, err
+		}
+		limits, err := LoadSubscriptionLimits(tx, &restaurant)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateOrderCreate(limits, orderReq); err != nil {
+			return nil, err
+		}
+		result.Order, result.Ingredients, err = s.orders.createOrderTx(tx, req.RestaurantID, userID, orderReq)
+		if err != nil {
+			return nil, err
+		}
+		result.NewOrder = true
+		if err := tx.Model(table).Updates(map[string]interface{}{
+			"is_occupied":      true,
+			"current_order_id": result.Order.ID,
+		}).Error; err != nil {
+			return nil, err
+		}
+		table.IsOccupied = true
+		table.CurrentOrderID = &result.Order.ID
+	}
+
+	if err := tx.Model(&models.GuestOrderRequest{}).Where("id = ?", req.ID).
+		Update("order_id", result.Order.ID).Error; err != nil {
+		return nil, err
+	}
+	req.OrderID = &result.Order.ID
+	return result, nil
+}
+
+// Reject declines a pending guest cart; the reason is shown on the table page.
+func (s *GuestOrderService) Reject(restaurantID, requestID, userID, reason string) (*models.GuestOrderRequest, error) {
+	req, err := s.get(restaurantID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if r := []rune(reason); len(r) > 200 {
+		reason = string(r[:200])
+	}
+	if err := s.decide(s.db, req, GuestOrderStatusRejected, userID, reason, false); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// recentTableGuestOrders lists the table's pending carts and those decided lately.
+func recentTableGuestOrders(db *gorm.DB, tableID string) []AssistanceGuestOrder {
+	var rows []models.GuestOrderRequest
+	if err := db.Where("table_id = ? AND (status = ? OR created_at > ?)",
+		tableID, GuestOrderStatusPending, time.Now().Add(-guestOrderRecentWindow)).
+		Order("created_at DESC").Limit(10).Find(&rows).Error; err != nil {
+		return nil
+	}
+	out := make([]AssistanceGuestOrder, 0, len(rows))
+	for _, row := range rows {
+		count := 0
+		for _, line := range row.Items {
+			count += line.Quantity
+		}
+		out = append(out, AssistanceGuestOrder{
+			ID:           row.ID,
+			Status:       row.Status,
+			ItemCount:    count,
+			RejectReason: row.RejectReason,
+			CreatedAt:    row.CreatedAt,
+		})
+	}
+	return out
+}
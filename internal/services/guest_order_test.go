@@ -0,0 +1,33 @@
+package services
+
+import "testing"
+
+func TestNormalizeGuestOrderItems(t *testing.T) {
+	items, err := normalizeGuestOrderItems([]CreateOrderItemRequest{
+		{MenuItemID: " dal ", Quantity: 1},
+		{MenuItemID: "naan", VariantID: "butter", Quantity: 2},
+		{MenuItemID: "dal", Quantity: 2},
+		{MenuItemID: "naan", VariantID: "butter", Quantity: 1, Notes: "crispy"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0].MenuItemID != "dal" || items[0].Quantity != 3 {
+		t.Fatalf("repeated lines should merge: %+v", items)
+	}
+	if items[2].Notes != "crispy" || items[2].Quantity != 1 {
+		t.Fatalf("a line with its own notes stays separate: %+v", items[2])
+	}
+
+	for name, bad := range map[string][]CreateOrderItemRequest{
+		"empty cart":     nil,
+		"missing item":   {{Quantity: 1}},
+		"zero quantity":  {{MenuItemID: "dal"}},
+		"huge quantity":  {{MenuItemID: "dal", Quantity: maxGuestOrderQuantity + 1}},
+		"merged too big": {{MenuItemID: "dal", Quantity: 15}, {MenuItemID: "dal", Quantity: 15}},
+	} {
+		if _, err := normalizeGuestOrderItems(bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
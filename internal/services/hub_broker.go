@@ -0,0 +1,15 @@
+package services
+
+// HubBroker fans SSE hub messages out to every API instance. Each hub subscribes to its
+// own topic; the key is the hub's subscription key (a token or restaurant ID).
+// realtime.HubBroker implements it over Redis pub/sub.
+type HubBroker interface {
+	Publish(topic, key string, payload []byte)
+	Subscribe(topic string, deliver func(key string, payload []byte))
+}
+
+const (
+	hubTopicTracking    = "tracking"
+	hubTopicAssistance  = "assistance"
+	hubTopicPrintNotify = "print"
+)
@@ -0,0 +1,62 @@
+package services
+
+import "testing"
+
+// loopbackBroker stands in for Redis: it records what went out and delivers it back.
+type loopbackBroker struct {
+	handlers  map[string]func(key string, payload []byte)
+	published []string
+}
+
+func (b *loopbackBroker) Publish(topic, key string, payload []byte) {
+	b.published = append(b.published, topic+":"+key)
+	if deliver := b.handlers[topic]; deliver != nil {
+		deliver(key, payload)
+	}
+}
+
+func (b *loopbackBroker) Subscribe(topic string, deliver func(key string, payload []byte)) {
+	b.handlers[topic] = deliver
+}
+
+func TestHubsPublishThroughBroker(t *testing.T) {
+	broker := &loopbackBroker{handlers: map[string]func(string, []byte){}}
+
+	tracking := NewOrderTrackingHub()
+	tracking.UseBroker(broker)
+	trackCh := tracking.Subscribe("tok1")
+	tracking.Publish("tok1", TrackingStatus{TicketNumber: 42, Color: "green"})
+	if got := <-trackCh; got.TicketNumber != 42 || got.Color != "green" {
+		t.Fatalf("tracking status after round trip: %+v", got)
+	}
+
+	assistance := NewAssistanceHub()
+	assistance.UseBroker(broker)
+	assistCh := assistance.Subscribe("tok2")
+	assistance.Publish("tok2", AssistanceStatus{TableName: "T4", Items: []AssistanceBillItem{{Name: "Dal", Quantity: 2}}})
+	if got := <-assistCh; got.TableName != "T4" || len(got.Items) != 1 || got.Items[0].Quantity != 2 {
+		t.Fatalf("assistance status after round trip: %+v", got)
+	}
+
+	notify := NewPrintNotifyHub()
+	notify.UseBroker(broker)
+	wakeCh := notify.Subscribe("rest1")
+	notify.Notify("rest1")
+	notify.Notify("rest1") // coalesces with the pending wake
+	<-wakeCh
+	select {
+	case <-wakeCh:
+		t.Fatal("wake-ups should coalesce")
+	default:
+	}
+
+	want := []string{"tracking:tok1", "assistance:tok2", "print:rest1", "print:rest1"}
+	if len(broker.published) != len(want) {
+		t.Fatalf("published %v, want %v", broker.published, want)
+	}
+	for i := range want {
+		if broker.published[i] != want[i] {
+			t.Fatalf("published %v, want %v", broker.published, want)
+		}
+	}
+}
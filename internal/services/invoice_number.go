@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxInvoicePrefixLen keeps "PRE/25-26/000123" within GST's 16-character invoice number limit.
+const maxInvoicePrefixLen = 3
+
+// NormalizeInvoicePrefix validates an invoice prefix (letters/digits, up to 3) and upper-cases it.
+func NormalizeInvoicePrefix(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) > maxInvoicePrefixLen {
+		return "", fmt.Errorf("invoice_prefix must be at most %d characters", maxInvoicePrefixLen)
+	}
+	for _, r := range value {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", errors.New("invoice_prefix may only contain letters and digits")
+		}
+	}
+	return value, nil
+}
+
+// FinancialYearFor returns the Indian financial year (April–March) containing a business date, e.g. "2025-26".
+func FinancialYearFor(businessDate time.Time) string {
+	start := businessDate.Year()
+	if businessDate.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// FormatInvoiceNumber renders "PREFIX/25-26/000123" (prefix segment omitted when blank).
+func FormatInvoiceNumber(prefix, financialYear string, seq int) string {
+	fy := financialYear
+	if len(fy) == 7 {
+		fy = fy[2:] // "2025-26" → "25-26"
+	}
+	number := fmt.Sprintf("%s/%06d", fy, seq)
+	if prefix = strings.TrimSpace(prefix); prefix != "" {
+		number = prefix + "/" + number
+	}
+	return number
+}
+
+// AssignInvoiceNumber takes the next invoice number for the restaurant's current financial year.
+// It must run inside the payment transaction: the upsert holds the sequence row lock until
+// commit, so concurrent checkouts serialise and a rolled-back payment releases its number.
+func AssignInvoiceNumber(tx *gorm.DB, restaurantID string, at time.Time) (number, financialYear string, seq int, err error) {
+	restaurant, financialYear, seq, err := nextDocumentSeq(tx, restaurantID, models.InvoiceSeriesInvoice, at)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return FormatInvoiceNumber(restaurant.InvoicePrefix, financialYear, seq), financialYear, seq, nil
+}
+
+// AssignCreditNoteNumber takes the next credit note number ("CN/25-26/000004"), a series
+// separate from invoices as GST requires. Same transaction rules as AssignInvoiceNumber.
+func AssignCreditNoteNumber(tx *gorm.DB, restaurantID string, at time.Time) (number, financialYear string, seq int, err error) {
+	_, financialYear, seq, err = nextDocumentSeq(tx, restaurantID, models.InvoiceSeriesCreditNote, at)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return FormatInvoiceNumber("CN", financialYear, seq), financialYear, seq, nil
+}
+
+func nextDocumentSeq(tx *gorm.DB, restaurantID, series string, at time.Time) (models.Restaurant, string, int, error) {
+	var restaurant models.Restaurant
+	if err := tx.Select("id", "timezone", "business_day_start", "invoice_prefix").
+		Where("id = ?", restaurantID).
+		First(&restaurant).Error; err != nil {
+		return restaurant, "", 0, err
+	}
+	financialYear := FinancialYearFor(BusinessCalendarForRestaurant(&restaurant).BusinessDate(at))
+
+	var seq int
+	if err := tx.Raw(`
+		INSERT INTO invoice_sequences (restaurant_id, series, financial_year, last_number, updated_at)
+		VALUES (?, ?, ?, 1, NOW())
+		ON CONFLICT (restaurant_id, series, financial_year)
+		DO UPDATE SET last_number = invoice_sequences.last_number + 1, updated_at = NOW()
+		RETURNING last_number
+	`, restaurantID, series, financialYear).Scan(&seq).Error; err != nil {
+		return restaurant, "", 0, err
+	}
+	if seq <= 0 {
+		return restaurant, "", 0, errors.New("failed to allocate document number")
+	}
+	return restaurant, financialYear, seq, nil
+}
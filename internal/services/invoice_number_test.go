@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFinancialYearFor(t *testing.T) {
+	cases := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), "2025-26"},
+		{time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC), "2025-26"},
+		{time.Date(2099, time.December, 1, 0, 0, 0, 0, time.UTC), "2099-00"},
+	}
+	for _, tc := range cases {
+		if got := FinancialYearFor(tc.date); got != tc.want {
+			t.Errorf("FinancialYearFor(%s) = %q, want %q", tc.date.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	if got := FormatInvoiceNumber("BG", "2025-26", 123); got != "BG/25-26/000123" {
+		t.Fatalf("got %q", got)
+	}
+	if got := FormatInvoiceNumber("", "2025-26", 7); got != "25-26/000007" {
+		t.Fatalf("got %q", got)
+	}
+	if got := FormatInvoiceNumber("ABC", "2025-26", 999999); len(got) > 16 {
+		t.Fatalf("%q exceeds the GST 16-character limit", got)
+	}
+}
+
+func TestNormalizeInvoicePrefix(t *testing.T) {
+	if got, err := NormalizeInvoicePrefix(" bg "); err != nil || got != "BG" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	for _, bad := range []string{"ABCD", "B/G", "B G"} {
+		if _, err := NormalizeInvoicePrefix(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
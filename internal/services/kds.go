@@ -0,0 +1,521 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kitchen display SLA colours, by ticket age against the restaurant's thresholds.
+const (
+	KDSOnTime = "green"
+	KDSWarn   = "amber"
+	KDSLate   = "red"
+)
+
+const (
+	defaultKDSWarnMinutes = 10
+	defaultKDSLateMinutes = 20
+	// kdsRecallWindow bounds how far back "recall" reaches; older bumps have been served.
+	kdsRecallWindow = 30 * time.Minute
+)
+
+// kdsOpenStatuses are the line statuses the kitchen still has to cook.
+var kdsOpenStatuses = []string{"pending", "cooking"}
+
+var (
+	ErrKDSTicketNotFound  = errors.New("no open kitchen ticket for this batch and station")
+	ErrKDSNothingToRecall = errors.New("no recent bump to recall")
+	ErrKDSOrderClosed     = errors.New("the order is already completed or cancelled")
+)
+
+// KDSTicket is one KOT batch (OrderItem.SubId) of an order as a single station sees it.
+// A ticket stays on the board while any of its lines is pending or cooking.
+type KDSTicket struct {
+	ID           string          `json:"id"` // order_id:sub_id:station_id
+	OrderID      string          `json:"order_id"`
+	OrderNumber  int             `json:"order_number"`
+	TicketNumber int             `json:"ticket_number,omitempty"`
+	OrderType    string          `json:"order_type"`
+	TableNo      string          `json:"table_no"`
+	CustomerName string          `json:"customer_name,omitempty"`
+	SubID        string          `json:"sub_id"`
+	StationID    string          `json:"station_id"`
+	StationName  string          `json:"station_name"`
+	Items        []KDSTicketItem `json:"items"`
+	StartedAt    time.Time       `json:"started_at"`
+	AgeSeconds   int64           `json:"age_seconds"`
+	SLA          string          `json:"sla"` // green | amber | red
+}
+
+// KDSTicketItem is a ticket line; ready, served and cancelled lines stay on the ticket
+// so the cook sees the whole batch.
+type KDSTicketItem struct {
+	ID         string `json:"id"`
+	MenuItemID string `json:"menu_item_id"`
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+	Status     string `json:"status"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// KDSAllDayCount is how many of a dish are pending or cooking across the board.
+type KDSAllDayCount struct {
+	MenuItemID string `json:"menu_item_id"`
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+}
+
+// KDSBoard is the kitchen display: open tickets oldest first, and the all-day counts.
+// Clients tick AgeSeconds on from ServerTime between refreshes.
+type KDSBoard struct {
+	ServerTime  time.Time        `json:"server_time"`
+	StationID   string           `json:"station_id,omitempty"`
+	WarnMinutes int              `json:"warn_minutes"`
+	LateMinutes int              `json:"late_minutes"`
+	Tickets     []KDSTicket      `json:"tickets"`
+	AllDay      []KDSAllDayCount `json:"all_day"`
+}
+
+// KDSTicketKey names the ticket to bump.
+type KDSTicketKey struct {
+	OrderID   string `json:"order_id" binding:"required"`
+	SubID     string `json:"sub_id"`
+	StationID string `json:"station_id"` // "" or "main" for the main KOT printer
+}
+
+// KDSChange is a bump or recall with the order reloaded for broadcasting.
+type KDSChange struct {
+	Bump  *models.KDSBump
+	Order *models.Order
+}
+
+// KDSService backs the kitchen display: the ticket board, bump and recall.
+type KDSService struct {
+	db *gorm.DB
+}
+
+func NewKDSService(db *gorm.DB) *KDSService {
+	return &KDSService{db: db}
+}
+
+func kdsThresholds(restaurant *models.Restaurant) (warn, late int) {
+	warn, late = restaurant.KDSWarnMinutes, restaurant.KDSLateMinutes
+	if warn <= 0 {
+		warn = defaultKDSWarnMinutes
+	}
+	if late <= warn {
+		late = max(defaultKDSLateMinutes, warn+1)
+	}
+	return warn, late
+}
+
+// NormalizeKDSMinutes validates an SLA threshold from the restaurant profile.
+func NormalizeKDSMinutes(minutes int) (int, error) {
+	if minutes < 1 || minutes > 240 {
+		return 0, errors.New("kitchen display minutes must be between 1 and 240")
+	}
+	return minutes, nil
+}
+
+func kdsSLA(age time.Duration, warn, late int) string {
+	switch {
+	case age >= time.Duration(late)*time.Minute:
+		return KDSLate
+	case age >= time.Duration(warn)*time.Minute:
+		return KDSWarn
+	default:
+		return KDSOnTime
+	}
+}
+
+func kdsStationKey(stationID string) string {
+	if stationID == "" {
+		return MainKOTStation
+	}
+	return stationID
+}
+
+func isKDSOpenStatus(status string) bool {
+	return status == "pending" || status == "cooking"
+}
+
+// Board returns open tickets for the restaurant, or for one station ("main" for the main
+// KOT printer) when stationID is set. Orders whose type the plan keeps off the kitchen
+// are left out.
+func (s *KDSService) Board(restaurantID, stationID string) (*KDSBoard, error) {
+	var restaurant models.Restaurant
+	if err := s.db.Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
+		return nil, err
+	}
+	limits, err := LoadSubscriptionLimits(s.db, &restaurant)
+	if err != nil {
+		return nil, err
+	}
+	stations, err := stationsForRestaurant(s.db, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	openOrderIDs := s.db.Model(&models.OrderItem{}).Select("order_id").Where("status IN ?", kdsOpenStatuses)
+	var orders []models.Order
+	if err := s.db.Preload("Items").
+		Preload("Items.MenuItem").
+		Where("restaurant_id = ? AND status NOT IN ? AND id IN (?)", restaurantID, []string{"completed", "cancelled"}, openOrderIDs).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	kitchenOrders := orders[:0]
+	for _, order := range orders {
+		if OrderUsesKitchen(limits, &order) {
+			kitchenOrders = append(kitchenOrders, order)
+		}
+	}
+
+	now := time.Now()
+	warn, late := kdsThresholds(&restaurant)
+	tickets, allDay := buildKDSTickets(kitchenOrders, stations, strings.TrimSpace(stationID), now, warn, late)
+	return &KDSBoard{
+		ServerTime:  now,
+		StationID:   strings.TrimSpace(stationID),
+		WarnMinutes: warn,
+		LateMinutes: late,
+		Tickets:     tickets,
+		AllDay:      allDay,
+	}, nil
+}
+
+// buildKDSTickets splits each order by batch, then by station, keeps the tickets that
+// still have lines to cook and counts those lines by dish.
+func buildKDSTickets(orders []models.Order, stations []models.KitchenStation, stationFilter string, now time.Time, warn, late int) ([]KDSTicket, []KDSAllDayCount) {
+	tickets := []KDSTicket{}
+	counts := map[string]*KDSAllDayCount{}
+	for _, order := range orders {
+		batches := map[string][]models.OrderItem{}
+		var batchOrder []string
+		for _, item := range order.Items {
+			if _, seen := batches[item.SubId]; !seen {
+				batchOrder = append(batchOrder, item.SubId)
+			}
+			batches[item.SubId] = append(batches[item.SubId], item)
+		}
+
+		for _, subID := range batchOrder {
+			for _, group := range RouteKOTItems(batches[subID], stations) {
+				stationID, stationName := MainKOTStation, "Main kitchen"
+				if group.Station != nil {
+					stationID, stationName = group.Station.ID, group.Station.Name
+				}
+				if stationFilter != "" && stationFilter != stationID {
+					continue
+				}
+				ticket, open := kdsTicketFromGroup(order, subID, stationID, stationName, group.Items)
+				if !open {
+					continue
+				}
+				ticket.AgeSeconds = int64(now.Sub(ticket.StartedAt).Seconds())
+				ticket.SLA = kdsSLA(now.Sub(ticket.StartedAt), warn, late)
+				tickets = append(tickets, ticket)
+
+				for _, item := range group.Items {
+					if !isKDSOpenStatus(item.Status) {
+						continue
+					}
+					name, _ := printItemNameAndCategory(item)
+					name = FormatOrderItemDisplayName(name, item.VariantLabel)
+					key := item.MenuID + "|" + item.VariantLabel
+					if counts[key] == nil {
+						counts[key] = &KDSAllDayCount{MenuItemID: item.MenuID, Name: name}
+					}
+					counts[key].Quantity += item.Quantity
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].StartedAt.Before(tickets[j].StartedAt)
+	})
+	allDay := make([]KDSAllDayCount, 0, len(counts))
+	for _, c := range counts {
+		allDay = append(allDay, *c)
+	}
+	sort.Slice(allDay, func(i, j int) bool {
+		if allDay[i].Quantity != allDay[j].Quantity {
+			return allDay[i].Quantity > allDay[j].Quantity
+		}
+		return allDay[i].Name < allDay[j].Name
+	})
+	return tickets, allDay
+}
+
+func kdsTicketFromGroup(order models.Order, subID, stationID, stationName string, items []models.OrderItem) (KDSTicket, bool) {
+	ticket := KDSTicket{
+		ID:           order.ID + ":" + subID + ":" + stationID,
+		OrderID:      order.ID,
+		OrderNumber:  order.OrderNumber,
+		TicketNumber: order.TicketNumber,
+		OrderType:    order.OrderType,
+		TableNo:      order.TableNumber,
+		CustomerName: order.CustomerName,
+		SubID:        subID,
+		StationID:    stationID,
+		StationName:  stationName,
+		Items:        make([]KDSTicketItem, 0, len(items)),
+	}
+	open := false
+	for _, item := range items {
+		if isKDSOpenStatus(item.Status) {
+			open = true
+		}
+		if ticket.StartedAt.IsZero() || item.CreatedAt.Before(ticket.StartedAt) {
+			ticket.StartedAt = item.CreatedAt
+		}
+		name, _ := printItemNameAndCategory(item)
+		ticket.Items = append(ticket.Items, KDSTicketItem{
+			ID:         item.ID,
+			MenuItemID: item.MenuID,
+			Name:       FormatOrderItemDisplayName(name, item.VariantLabel),
+			Quantity:   item.Quantity,
+			Status:     item.Status,
+			Notes:      item.Notes,
+		})
+	}
+	return ticket, open
+}
+
+func isKDSOrderClosed(status string) bool {
+	return status == "completed" || status == "cancelled"
+}
+
+// kdsBumpItemIDs returns the lines of the ticket key names: the order's KOT batch as routed
+// to the station. Lines of every status are returned; the bump itself only moves the open
+// ones.
+func kdsBumpItemIDs(order *models.Order, stations []models.KitchenStation, key KDSTicketKey) ([]string, error) {
+	if isKDSOrderClosed(order.Status) {
+		return nil, ErrKDSOrderClosed
+	}
+	stationID := kdsStationKey(strings.TrimSpace(key.StationID))
+	var batch []models.OrderItem
+	for _, item := range order.Items {
+		if item.SubId == key.SubID {
+			batch = append(batch, item)
+		}
+	}
+	var ids []string
+	for _, group := range RouteKOTItems(batch, stations) {
+		groupStation := MainKOTStation
+		if group.Station != nil {
+			groupStation = group.Station.ID
+		}
+		if groupStation != stationID {
+			continue
+		}
+		for _, item := range group.Items {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrKDSTicketNotFound
+	}
+	return ids, nil
+}
+
+// Bump marks every pending or cooking line of the ticket ready and records their previous
+// statuses for recall. Tickets of completed or cancelled orders can't be bumped.
+func (s *KDSService) Bump(restaurantID, userID string, key KDSTicketKey) (*KDSChange, error) {
+	var order models.Order
+	if err := s.db.Preload("Items").
+		Preload("Items.MenuItem").
+		Where("id = ? AND restaurant_id = ?", key.OrderID, restaurantID).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKDSTicketNotFound
+		}
+		return nil, err
+	}
+	stations, err := stationsForRestaurant(s.db, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	ticketItemIDs, err := kdsBumpItemIDs(&order, stations, key)
+	if err != nil {
+		return nil, err
+	}
+
+	bump := models.KDSBump{
+		RestaurantID:   restaurantID,
+		OrderID:        order.ID,
+		SubID:          key.SubID,
+		StationID:      kdsStationKey(strings.TrimSpace(key.StationID)),
+		BumpedByUserID: userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so a bill settled meanwhile is seen before any line moves.
+		var locked models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			Where("id = ?", order.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		if isKDSOrderClosed(locked.Status) {
+			return ErrKDSOrderClosed
+		}
+		var open []models.OrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND order_id = ? AND status IN ?", ticketItemIDs, order.ID, kdsOpenStatuses).
+			Find(&open).Error; err != nil {
+			return err
+		}
+		if len(open) == 0 {
+			return ErrKDSTicketNotFound
+		}
+		ids := make([]string, 0, len(open))
+		for _, item := range open {
+			ids = append(ids, item.ID)
+			bump.Items = append(bump.Items, models.KDSBumpItem{ItemID: item.ID, PrevStatus: item.Status})
+		}
+		if err := tx.Model(&models.OrderItem{}).Where("id IN ?", ids).Update("status", "ready").Error; err != nil {
+			return err
+		}
+		return tx.Create(&bump).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := NewOrderService(s.db).GetOrderByID(restaurantID, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &KDSChange{Bump: &bump, Order: updated}, nil
+}
+
+// latestKDSRecall picks the bump Recall undoes: the newest one not yet recalled, made
+// within kdsRecallWindow of now, on stationID when it is set. Bumps on closedOrders are
+// skipped, so a closed order doesn't hide the bumps before it.
+func latestKDSRecall(bumps []models.KDSBump, stationID string, now time.Time, closedOrders map[string]bool) (*models.KDSBump, bool) {
+	var latest *models.KDSBump
+	for i := range bumps {
+		b := &bumps[i]
+		if b.RecalledAt != nil || !b.CreatedAt.After(now.Add(-kdsRecallWindow)) || closedOrders[b.OrderID] {
+			continue
+		}
+		if stationID != "" && b.StationID != stationID {
+			continue
+		}
+		if latest == nil || b.CreatedAt.After(latest.CreatedAt) {
+			latest = b
+		}
+	}
+	return latest, latest != nil
+}
+
+// kdsRecallRestores maps each bumped line still ready to the status it had before the bump.
+// Lines served or cancelled since are left alone.
+func kdsRecallRestores(bump *models.KDSBump, items []models.OrderItem) map[string]string {
+	ready := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.OrderID == bump.OrderID && item.Status == "ready" {
+			ready[item.ID] = true
+		}
+	}
+	restores := make(map[string]string)
+	for _, line := range bump.Items {
+		if ready[line.ItemID] {
+			restores[line.ItemID] = line.PrevStatus
+		}
+	}
+	return restores
+}
+
+// Recall undoes the latest bump from the last half hour (on stationID when set): lines
+// still ready go back to the status they had. Lines served since stay served, and bumps
+// on orders that have been closed are passed over for the one before. A bump with no line
+// left to restore is retired, so the next recall reaches the one before it, and
+// ErrKDSNothingToRecall is returned.
+func (s *KDSService) Recall(restaurantID, stationID string) (*KDSChange, error) {
+	stationID = strings.TrimSpace(stationID)
+	var bump models.KDSBump
+	restored := int64(0)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var recent []models.KDSBump
+		if err := tx.Where("restaurant_id = ? AND recalled_at IS NULL AND created_at > ?", restaurantID, now.Add(-kdsRecallWindow)).
+			Find(&recent).Error; err != nil {
+			return err
+		}
+		orderIDs := make([]string, 0, len(recent))
+		for _, b := range recent {
+			orderIDs = append(orderIDs, b.OrderID)
+		}
+		closed := make(map[string]bool)
+		if len(orderIDs) > 0 {
+			var orders []models.Order
+			if err := tx.Select("id", "status").Where("id IN ?", orderIDs).Find(&orders).Error; err != nil {
+				return err
+			}
+			for _, o := range orders {
+				if isKDSOrderClosed(o.Status) {
+					closed[o.ID] = true
+				}
+			}
+		}
+		latest, ok := latestKDSRecall(recent, stationID, now, closed)
+		if !ok {
+			return ErrKDSNothingToRecall
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND recalled_at IS NULL", latest.ID).First(&bump).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrKDSNothingToRecall
+			}
+			return err
+		}
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			Where("id = ?", bump.OrderID).First(&order).Error; err != nil {
+			return err
+		}
+		if isKDSOrderClosed(order.Status) {
+			return ErrKDSOrderClosed
+		}
+		itemIDs := make([]string, 0, len(bump.Items))
+		for _, line := range bump.Items {
+			itemIDs = append(itemIDs, line.ItemID)
+		}
+		var items []models.OrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND order_id = ?", itemIDs, bump.OrderID).Find(&items).Error; err != nil {
+			return err
+		}
+		for itemID, prev := range kdsRecallRestores(&bump, items) {
+			res := tx.Model(&models.OrderItem{}).
+				Where("id = ? AND order_id = ? AND status = ?", itemID, bump.OrderID, "ready").
+				Update("status", prev)
+			if res.Error != nil {
+				return res.Error
+			}
+			restored += res.RowsAffected
+		}
+		bump.RecalledAt = &now
+		return tx.Model(&bump).Update("recalled_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if restored == 0 {
+		return nil, ErrKDSNothingToRecall
+	}
+
+	order, err := NewOrderService(s.db).GetOrderByID(restaurantID, bump.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	return &KDSChange{Bump: &bump, Order: order}, nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"restaurant-api/internal/models"
+)
+
+func TestBuildKDSTickets(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	line := func(id, menuID, name, category, subID, status string, qty int, age time.Duration) models.OrderItem {
+		item := kotTestItem(menuID, name, category)
+		item.ID, item.SubId, item.Status, item.Quantity, item.CreatedAt = id, subID, status, qty, now.Add(-age)
+		return item
+	}
+	stations := []models.KitchenStation{
+		{ID: "tandoor", Name: "Tandoor", IsActive: true, Rules: []models.KitchenStationRule{{Category: "breads"}}},
+	}
+	orders := []models.Order{
+		{ID: "o1", OrderNumber: 7, TableNumber: "4", Items: []models.OrderItem{
+			line("a", "naan", "Butter Naan", "Breads", "b1", "pending", 2, 25*time.Minute),
+			line("b", "dal", "Dal Makhani", "Mains", "b1", "ready", 1, 25*time.Minute),
+			line("c", "naan", "Butter Naan", "Breads", "b2", "cooking", 1, 12*time.Minute),
+			line("d", "paneer", "Paneer Tikka", "Mains", "b2", "served", 1, 12*time.Minute),
+		}},
+		{ID: "o2", OrderNumber: 8, Items: []models.OrderItem{
+			line("e", "dal", "Dal Makhani", "Mains", "b3", "cooking", 3, 2*time.Minute),
+		}},
+	}
+
+	tickets, allDay := buildKDSTickets(orders, stations, "", now, 10, 20)
+	if len(tickets) != 3 {
+		t.Fatalf("expected the two tandoor tickets and o2's main ticket, got %+v", tickets)
+	}
+	if tickets[0].ID != "o1:b1:tandoor" || tickets[0].SLA != KDSLate || tickets[0].AgeSeconds != 25*60 {
+		t.Fatalf("oldest ticket first and late: %+v", tickets[0])
+	}
+	if tickets[1].SubID != "b2" || tickets[1].SLA != KDSWarn || tickets[2].OrderID != "o2" || tickets[2].SLA != KDSOnTime {
+		t.Fatalf("unexpected order or colours: %+v", tickets[1:])
+	}
+	if len(allDay) != 2 || allDay[0].MenuItemID != "naan" || allDay[0].Quantity != 3 || allDay[1].Quantity != 3 {
+		t.Fatalf("all-day counts only pending and cooking lines: %+v", allDay)
+	}
+
+	main, _ := buildKDSTickets(orders, stations, MainKOTStation, now, 10, 20)
+	if len(main) != 1 || main[0].StationID != MainKOTStation || main[0].OrderID != "o2" {
+		t.Fatalf("o1's main ticket is finished, only o2 should show: %+v", main)
+	}
+}
+
+func TestKDSBumpItemIDs(t *testing.T) {
+	line := func(id, menuID, name, category, subID, status string) models.OrderItem {
+		item := kotTestItem(menuID, name, category)
+		item.ID, item.SubId, item.Status = id, subID, status
+		return item
+	}
+	stations := []models.KitchenStation{
+		{ID: "tandoor", Name: "Tandoor", IsActive: true, Rules: []models.KitchenStationRule{{Category: "breads"}}},
+	}
+	order := models.Order{ID: "o1", Status: "pending", Items: []models.OrderItem{
+		line("a", "naan", "Butter Naan", "Breads", "b1", "pending"),
+		line("b", "dal", "Dal Makhani", "Mains", "b1", "cooking"),
+		line("c", "roti", "Tandoori Roti", "Breads", "b2", "pending"),
+	}}
+
+	ids, err := kdsBumpItemIDs(&order, stations, KDSTicketKey{OrderID: "o1", SubID: "b1", StationID: "tandoor"})
+	if err != nil || len(ids) != 1 || ids[0] != "a" {
+		t.Fatalf("tandoor ticket of b1: ids=%v err=%v", ids, err)
+	}
+	if ids, err := kdsBumpItemIDs(&order, stations, KDSTicketKey{OrderID: "o1", SubID: "b1"}); err != nil || len(ids) != 1 || ids[0] != "b" {
+		t.Fatalf("a blank station is the main KOT ticket: ids=%v err=%v", ids, err)
+	}
+	if _, err := kdsBumpItemIDs(&order, stations, KDSTicketKey{OrderID: "o1", SubID: "b2", StationID: MainKOTStation}); err != ErrKDSTicketNotFound {
+		t.Fatalf("b2 has nothing for the main station, got %v", err)
+	}
+	for _, status := range []string{"completed", "cancelled"} {
+		closed := order
+		closed.Status = status
+		if _, err := kdsBumpItemIDs(&closed, stations, KDSTicketKey{OrderID: "o1", SubID: "b1", StationID: "tandoor"}); err != ErrKDSOrderClosed {
+			t.Fatalf("%s order: got %v, want ErrKDSOrderClosed", status, err)
+		}
+	}
+}
+
+func TestLatestKDSRecall(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	recalled := now.Add(-time.Minute)
+	bumps := []models.KDSBump{
+		{ID: "old", StationID: "tandoor", CreatedAt: now.Add(-31 * time.Minute)},
+		{ID: "edge", StationID: "tandoor", CreatedAt: now.Add(-kdsRecallWindow)},
+		{ID: "tandoor", StationID: "tandoor", CreatedAt: now.Add(-20 * time.Minute)},
+		{ID: "main", StationID: MainKOTStation, CreatedAt: now.Add(-10 * time.Minute)},
+		{ID: "undone", StationID: MainKOTStation, CreatedAt: now.Add(-2 * time.Minute), RecalledAt: &recalled},
+	}
+
+	if b, ok := latestKDSRecall(bumps, "", now, nil); !ok || b.ID != "main" {
+		t.Fatalf("latest bump on any station should be main, got %+v", b)
+	}
+	if b, ok := latestKDSRecall(bumps, "tandoor", now, nil); !ok || b.ID != "tandoor" {
+		t.Fatalf("station filter: got %+v", b)
+	}
+	if b, ok := latestKDSRecall(bumps, "grill", now, nil); ok {
+		t.Fatalf("no bump on grill, got %+v", b)
+	}
+	if b, ok := latestKDSRecall(bumps, "tandoor", now.Add(11*time.Minute), nil); ok {
+		t.Fatalf("bumps older than %v are out of reach, got %+v", kdsRecallWindow, b)
+	}
+	if b, ok := latestKDSRecall(bumps[:2], "", now, nil); ok {
+		t.Fatalf("a bump exactly %v old is out of reach, got %+v", kdsRecallWindow, b)
+	}
+}
+
+func TestLatestKDSRecall_SkipsClosedOrders(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	bumps := []models.KDSBump{
+		{ID: "earlier", OrderID: "o1", StationID: "tandoor", CreatedAt: now.Add(-15 * time.Minute)},
+		{ID: "paid", OrderID: "o2", StationID: "tandoor", CreatedAt: now.Add(-5 * time.Minute)},
+	}
+	if b, ok := latestKDSRecall(bumps, "tandoor", now, map[string]bool{"o2": true}); !ok || b.ID != "earlier" {
+		t.Fatalf("a bump on a closed order should not block the one before it, got %+v", b)
+	}
+	if b, ok := latestKDSRecall(bumps, "tandoor", now, map[string]bool{"o1": true, "o2": true}); ok {
+		t.Fatalf("every bump is on a closed order, got %+v", b)
+	}
+}
+
+func TestKDSRecallRestores(t *testing.T) {
+	bump := models.KDSBump{OrderID: "o1", Items: []models.KDSBumpItem{
+		{ItemID: "a", PrevStatus: "pending"},
+		{ItemID: "b", PrevStatus: "cooking"},
+		{ItemID: "c", PrevStatus: "cooking"},
+	}}
+	items := []models.OrderItem{
+		{ID: "a", OrderID: "o1", Status: "ready"},
+		{ID: "b", OrderID: "o1", Status: "served"},
+		{ID: "c", OrderID: "o2", Status: "ready"},
+	}
+	got := kdsRecallRestores(&bump, items)
+	if len(got) != 1 || got["a"] != "pending" {
+		t.Fatalf("only a is still ready on o1: %v", got)
+	}
+	items[0].Status = "served"
+	if got := kdsRecallRestores(&bump, items); len(got) != 0 {
+		t.Fatalf("everything served, nothing to recall: %v", got)
+	}
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrKitchenStationNotFound = errors.New("kitchen station not found")
+	ErrKitchenStationName     = errors.New("station name is required")
+	ErrKitchenStationExists   = errors.New("a station with this name already exists")
+)
+
+// KitchenStationService manages named kitchen stations and their routing rules.
+type KitchenStationService struct {
+	db *gorm.DB
+}
+
+func NewKitchenStationService(db *gorm.DB) *KitchenStationService {
+	return &KitchenStationService{db: db}
+}
+
+// KitchenStationInput creates or patches a station. Nil fields are left unchanged on update.
+type KitchenStationInput struct {
+	Name         *string                   `json:"name"`
+	PrinterHost  *string                   `json:"printer_host"`
+	PrinterPort  *int                      `json:"printer_port"`
+	PaperWidthMm *int                      `json:"paper_width_mm"`
+	SortOrder    *int                      `json:"sort_order"`
+	IsActive     *bool                     `json:"is_active"`
+	Buzzer       *bool                     `json:"buzzer"`
+	PlainText    *bool                     `json:"plain_text"`
+	RasterText   *string                   `json:"raster_text"`
+	Failover     *[]models.PrinterEndpoint `json:"failover"`
+}
+
+// KitchenStationRulesInput replaces a station's routing rules.
+type KitchenStationRulesInput struct {
+	Categories  []string `json:"categories"`
+	MenuItemIDs []string `json:"menu_item_ids"`
+}
+
+func normalizePrinterPort(port int) int {
+	if port <= 0 || port > 65535 {
+		return 9100
+	}
+	return port
+}
+
+// List returns the restaurant's stations with their rules, in display order.
+func (s *KitchenStationService) List(restaurantID string) ([]models.KitchenStation, error) {
+	var stations []models.KitchenStation
+	err := s.db.Preload("Rules").
+		Where("restaurant_id = ?", restaurantID).
+		Order("sort_order ASC, name ASC").
+		Find(&stations).Error
+	return stations, err
+}
+
+func (s *KitchenStationService) Get(restaurantID, stationID string) (*models.KitchenStation, error) {
+	var station models.KitchenStation
+	if err := s.db.Preload("Rules").
+		Where("id = ? AND restaurant_id = ?", stationID, restaurantID).
+		First(&station).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKitchenStationNotFound
+		}
+		return nil, err
+	}
+	return &station, nil
+}
+
+func (s *KitchenStationService) nameTaken(restaurantID, name, exceptID string) (bool, error) {
+	var count int64
+	q := s.db.Model(&models.KitchenStation{}).
+		Where("restaurant_id = ? AND LOWER(name) = LOWER(?)", restaurantID, name)
+	if exceptID != "" {
+		q = q.Where("id <> ?", exceptID)
+	}
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+// Create adds a station. New stations are active.
+func (s *KitchenStationService) Create(restaurantID string, input KitchenStationInput) (*models.KitchenStation, error) {
+	name := ""
+	if input.Name != nil {
+		name = strings.TrimSpace(*input.Name)
+	}
+	if name == "" {
+		return nil, ErrKitchenStationName
+	}
+	if taken, err := s.nameTaken(restaurantID, name, ""); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrKitchenStationExists
+	}
+	station := models.KitchenStation{
+		RestaurantID: restaurantID,
+		Name:         name,
+		PrinterPort:  9100,
+		PaperWidthMm: 58,
+		IsActive:     true,
+	}
+	if input.PrinterHost != nil {
+		station.PrinterHost = strings.TrimSpace(*input.PrinterHost)
+	}
+	if input.PrinterPort != nil {
+		station.PrinterPort = normalizePrinterPort(*input.PrinterPort)
+	}
+	if input.PaperWidthMm != nil {
+		station.PaperWidthMm = normalizePaperWidthMm(*input.PaperWidthMm)
+	}
+	if input.SortOrder != nil {
+		station.SortOrder = *input.SortOrder
+	}
+	if input.Buzzer != nil {
+		station.Buzzer = *input.Buzzer
+	}
+	if input.PlainText != nil {
+		station.PlainText = *input.PlainText
+	}
+	if input.RasterText != nil {
+		mode, err := normalizePrintRasterText(*input.RasterText)
+		if err != nil {
+			return nil, err
+		}
+		station.RasterText = mode
+	}
+	if input.Failover != nil {
+		list, err := normalizePrinterFailover(*input.Failover)
+		if err != nil {
+			return nil, err
+		}
+		station.Failover = list
+	}
+	if err := s.db.Create(&station).Error; err != nil {
+		return nil, err
+	}
+	if input.IsActive != nil && !*input.IsActive {
+		if err := s.db.Model(&station).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(restaurantID, station.ID)
+}
+
+// Update patches a station's name, printer and ordering.
+func (s *KitchenStationService) Update(restaurantID, stationID string, input KitchenStationInput) (*models.KitchenStation, error) {
+	station, err := s.Get(restaurantID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return nil, ErrKitchenStationName
+		}
+		if taken, err := s.nameTaken(restaurantID, name, stationID); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrKitchenStationExists
+		}
+		updates["name"] = name
+	}
+	if input.PrinterHost != nil {
+		updates["printer_host"] = strings.TrimSpace(*input.PrinterHost)
+	}
+	if input.PrinterPort != nil {
+		updates["printer_port"] = normalizePrinterPort(*input.PrinterPort)
+	}
+	if input.PaperWidthMm != nil {
+		updates["paper_width_mm"] = normalizePaperWidthMm(*input.PaperWidthMm)
+	}
+	if input.SortOrder != nil {
+		updates["sort_order"] = *input.SortOrder
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+	if input.Buzzer != nil {
+		updates["buzzer"] = *input.Buzzer
+	}
+	if input.PlainText != nil {
+		updates["plain_text"] = *input.PlainText
+	}
+	if input.RasterText != nil {
+		mode, err := normalizePrintRasterText(*input.RasterText)
+		if err != nil {
+			return nil, err
+		}
+		updates["raster_text"] = mode
+	}
+	if input.Failover != nil {
+		list, err := normalizePrinterFailover(*input.Failover)
+		if err != nil {
+			return nil, err
+		}
+		updates["failover"] = printerFailoverJSON(list)
+	}
+	if len(updates) == 0 {
+		return station, nil
+	}
+	if err := s.db.Model(station).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(restaurantID, stationID)
+}
+
+// Delete removes a station and its rules; its categories fall back to the main KOT printer.
+func (s *KitchenStationService) Delete(restaurantID, stationID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND restaurant_id = ?", stationID, restaurantID).Delete(&models.KitchenStation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrKitchenStationNotFound
+		}
+		return tx.Where("station_id = ?", stationID).Delete(&models.KitchenStationRule{}).Error
+	})
+}
+
+// SetRules replaces the station's rules. A category or item can belong to one station
+// only, so claiming it here removes it from any other station.
+func (s *KitchenStationService) SetRules(restaurantID, stationID string, input KitchenStationRulesInput) (*models.KitchenStation, error) {
+	if _, err := s.Get(restaurantID, stationID); err != nil {
+		return nil, err
+	}
+	categories := uniqueTrimmed(input.Categories, true)
+	itemIDs := uniqueTrimmed(input.MenuItemIDs, false)
+
+	if len(itemIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.MenuItem{}).
+			Where("restaurant_id = ? AND id IN ?", restaurantID, itemIDs).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(itemIDs) {
+			return nil, errors.New("one or more menu items were not found")
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("station_id = ?", stationID).Delete(&models.KitchenStationRule{}).Error; err != nil {
+			return err
+		}
+		if len(categories) > 0 {
+			if err := tx.Where("restaurant_id = ? AND LOWER(category) IN ?", restaurantID, categories).
+				Delete(&models.KitchenStationRule{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(itemIDs) > 0 {
+			if err := tx.Where("restaurant_id = ? AND menu_item_id IN ?", restaurantID, itemIDs).
+				Delete(&models.KitchenStationRule{}).Error; err != nil {
+				return err
+			}
+		}
+		rules := make([]models.KitchenStationRule, 0, len(categories)+len(itemIDs))
+		for _, category := range categories {
+			rules = append(rules, models.KitchenStationRule{RestaurantID: restaurantID, StationID: stationID, Category: category})
+		}
+		for _, id := range itemIDs {
+			rules = append(rules, models.KitchenStationRule{RestaurantID: restaurantID, StationID: stationID, MenuItemID: id})
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(restaurantID, stationID)
+}
+
+func uniqueTrimmed(values []string, lower bool) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// KOTStationGroup is the slice of an order batch that prints on one station.
+// Station is nil for items that go to the main KOT printer.
+type KOTStationGroup struct {
+	Station *models.KitchenStation
+	Items   []models.OrderItem
+}
+
+// RouteKOTItems splits KOT lines by station: an item rule wins, then a rule for the
+// item's menu category; anything else stays on the main KOT printer. Inactive stations
+// are ignored. Groups keep station display order, with the main printer first.
+func RouteKOTItems(items []models.OrderItem, stations []models.KitchenStation) []KOTStationGroup {
+	byItem := map[string]int{}
+	byCategory := map[string]int{}
+	for i, station := range stations {
+		if !station.IsActive {
+			continue
+		}
+		for _, rule := range station.Rules {
+			if rule.MenuItemID != "" {
+				byItem[rule.MenuItemID] = i
+			} else if c := strings.ToLower(strings.TrimSpace(rule.Category)); c != "" {
+				byCategory[c] = i
+			}
+		}
+	}
+
+	grouped := map[int][]models.OrderItem{}
+	for _, item := range items {
+		idx := -1
+		if i, ok := byItem[item.MenuID]; ok {
+			idx = i
+		} else if _, category := printItemNameAndCategory(item); category != "" {
+			if i, ok := byCategory[strings.ToLower(category)]; ok {
+				idx = i
+			}
+		}
+		grouped[idx] = append(grouped[idx], item)
+	}
+
+	keys := make([]int, 0, len(grouped))
+	for k := range grouped {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	groups := make([]KOTStationGroup, 0, len(keys))
+	for _, k := range keys {
+		group := KOTStationGroup{Items: grouped[k]}
+		if k >= 0 {
+			station := stations[k]
+			group.Station = &station
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// MainKOTStation stands for the restaurant's main KOT printer in station lists.
+const MainKOTStation = "main"
+
+// ItemStationIDs lists the stations the items route to, MainKOTStation for unrouted
+// lines. It returns nil when the stations can't be loaded.
+func (s *KitchenStationService) ItemStationIDs(restaurantID string, items []models.OrderItem) []string {
+	stations, err := stationsForRestaurant(s.db, restaurantID)
+	if err != nil {
+		return nil
+	}
+	return routedStationIDs(items, stations)
+}
+
+func routedStationIDs(items []models.OrderItem, stations []models.KitchenStation) []string {
+	groups := RouteKOTItems(items, stations)
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group.Station == nil {
+			ids = append(ids, MainKOTStation)
+		} else {
+			ids = append(ids, group.Station.ID)
+		}
+	}
+	return ids
+}
+
+// stationsForRestaurant loads active stations with rules for KOT routing.
+func stationsForRestaurant(db *gorm.DB, restaurantID string) ([]models.KitchenStation, error) {
+	var stations []models.KitchenStation
+	err := db.Preload("Rules").
+		Where("restaurant_id = ? AND is_active = ?", restaurantID, true).
+		Order("sort_order ASC, name ASC").
+		Find(&stations).Error
+	return stations, err
+}
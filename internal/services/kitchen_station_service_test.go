@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func kotTestItem(menuID, name, category string) models.OrderItem {
+	return models.OrderItem{
+		MenuID:   menuID,
+		Quantity: 1,
+		MenuItem: &models.MenuItem{ID: menuID, Name: name, Category: category},
+	}
+}
+
+func TestRouteKOTItems_SplitsByStation(t *testing.T) {
+	stations := []models.KitchenStation{
+		{ID: "tandoor", Name: "Tandoor", IsActive: true, Rules: []models.KitchenStationRule{{Category: "breads"}}},
+		{ID: "bar", Name: "Bar", IsActive: true, Rules: []models.KitchenStationRule{{Category: "Drinks"}, {MenuItemID: "lassi"}}},
+		{ID: "chinese", Name: "Chinese", IsActive: false, Rules: []models.KitchenStationRule{{Category: "chinese"}}},
+	}
+	items := []models.OrderItem{
+		kotTestItem("naan", "Butter Naan", "Breads"),
+		kotTestItem("cola", "Cola", "drinks"),
+		kotTestItem("lassi", "Sweet Lassi", "Desserts"),
+		kotTestItem("noodles", "Hakka Noodles", "Chinese"),
+		kotTestItem("dal", "Dal Makhani", "Mains"),
+	}
+
+	groups := RouteKOTItems(items, stations)
+	if len(groups) != 3 {
+		t.Fatalf("expected main + 2 station groups, got %d", len(groups))
+	}
+	if groups[0].Station != nil || len(groups[0].Items) != 2 {
+		t.Fatalf("main printer group: %+v", groups[0])
+	}
+	if groups[1].Station == nil || groups[1].Station.ID != "tandoor" || len(groups[1].Items) != 1 {
+		t.Fatalf("tandoor group: %+v", groups[1])
+	}
+	if groups[2].Station == nil || groups[2].Station.ID != "bar" || len(groups[2].Items) != 2 {
+		t.Fatalf("bar group (category + item rule): %+v", groups[2])
+	}
+}
+
+func TestBuildKOTPayload_StationTitle(t *testing.T) {
+	order := &models.Order{OrderNumber: 12, TableNumber: "4"}
+	text := buildKOTPayload(models.Restaurant{}, order, []models.OrderItem{kotTestItem("naan", "Naan", "")}, true, "Tandoor", 80)
+	if !strings.Contains(StripPrintMarkup(text), "KOT - TANDOOR (ADD-ON)") {
+		t.Fatalf("missing station title:\n%s", text)
+	}
+	if !strings.Contains(text, strings.Repeat("-", 48)) {
+		t.Fatalf("expected 80mm divider:\n%s", text)
+	}
+}
+
+func TestResolveJobPrinter_StationFallsBackToMain(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{KotPrinterHost: "10.0.0.5", KotPrinterPort: 9100}
+	stations := map[string]models.KitchenStation{
+		"bar":   {ID: "bar", PrinterHost: "10.0.0.9", PrinterPort: 9101},
+		"grill": {ID: "grill"},
+	}
+	if host, port := resolveJobPrinter(settings, stations, models.PrintJob{Target: PrintTargetKOT, StationID: "bar"}); host != "10.0.0.9" || port != 9101 {
+		t.Fatalf("bar: %s:%d", host, port)
+	}
+	if host, _ := resolveJobPrinter(settings, stations, models.PrintJob{Target: PrintTargetKOT, StationID: "grill"}); host != "10.0.0.5" {
+		t.Fatalf("grill without host should use main printer, got %s", host)
+	}
+	if host, _ := resolveJobPrinter(settings, stations, models.PrintJob{Target: PrintTargetKOT, StationID: "deleted"}); host != "10.0.0.5" {
+		t.Fatalf("deleted station should use main printer, got %s", host)
+	}
+}
@@ -20,6 +20,8 @@ type VariantInput struct {
 	IsDefault     bool               `json:"is_default"`
 	IsAvailable   *bool              `json:"is_available,omitempty"`
 	SortOrder     int                `json:"sort_order"`
+	// GSTRate overrides the item's GST slab for this portion; omit to inherit.
+	GSTRate *float64 `json:"gst_rate,omitempty"`
 }
 
 func normalizeRecipeScale(scale float64) float64 {
@@ -85,6 +87,14 @@ func SyncMenuItemVariants(db *gorm.DB, item models.MenuItem, variants []VariantI
 			available = *in.IsAvailable
 		}
 		scale := normalizeRecipeScale(in.RecipeScale)
+		var gstRate *float64
+		if in.GSTRate != nil {
+			rate, err := NormalizeGSTRate(*in.GSTRate)
+			if err != nil {
+				return nil, err
+			}
+			gstRate = &rate
+		}
 		channelPrices, err := NormalizeVariantChannelPrices(in.ChannelPrices)
 		if err != nil {
 			return nil, err
@@ -110,6 +120,7 @@ func SyncMenuItemVariants(db *gorm.DB, item models.MenuItem, variants []VariantI
 			existing.Label = label
 			existing.Price = in.Price
 			existing.RecipeScale = scale
+			existing.GSTRate = gstRate
 			existing.ChannelPrices = channelPrices
 			existing.IsDefault = isDefault
 			existing.IsAvailable = available
@@ -129,6 +140,7 @@ func SyncMenuItemVariants(db *gorm.DB, item models.MenuItem, variants []VariantI
 			Label:         label,
 			Price:         in.Price,
 			RecipeScale:   scale,
+			GSTRate:       gstRate,
 			ChannelPrices: channelPrices,
 			IsDefault:     isDefault,
 			IsAvailable:   available,
@@ -189,13 +201,14 @@ func SyncMenuItemVariants(db *gorm.DB, item models.MenuItem, variants []VariantI
 // ResolveOrderVariant picks the variant for an order line.
 // Empty variantID uses the default (or sole) variant; missing variants fall back to menu price.
 // When channel is set, prefers variant.channel_prices[channel], then item channel prices for Regular.
+// gstRate is the chosen variant's GST slab override (nil inherits the menu item's rate).
 func ResolveOrderVariant(
 	db *gorm.DB,
 	restaurantID, menuItemID, variantID string,
 	menuPrice float64,
 	channel string,
 	itemChannelPrices map[string]float64,
-) (price float64, label string, scale float64, variantIDOut *string, err error) {
+) (price float64, label string, scale float64, variantIDOut *string, gstRate *float64, err error) {
 	price = menuPrice
 	label = ""
 	scale = 1
@@ -204,7 +217,7 @@ func ResolveOrderVariant(
 	if err := db.Where("menu_item_id = ? AND restaurant_id = ?", menuItemID, restaurantID).
 		Order("sort_order ASC, created_at ASC").
 		Find(&variants).Error; err != nil {
-		return price, label, scale, nil, err
+		return price, label, scale, nil, nil, err
 	}
 	if len(variants) == 0 {
 		if channel != "" && itemChannelPrices != nil {
@@ -212,7 +225,7 @@ func ResolveOrderVariant(
 				price = p
 			}
 		}
-		return price, label, scale, nil, nil
+		return price, label, scale, nil, nil, nil
 	}
 
 	var chosen *models.MenuItemVariant
@@ -224,10 +237,10 @@ func ResolveOrderVariant(
 			}
 		}
 		if chosen == nil {
-			return 0, "", 0, nil, errors.New("variant does not belong to this menu item")
+			return 0, "", 0, nil, nil, errors.New("variant does not belong to this menu item")
 		}
 		if !chosen.IsAvailable {
-			return 0, "", 0, nil, errors.New("selected variant is not available")
+			return 0, "", 0, nil, nil, errors.New("selected variant is not available")
 		}
 	} else {
 		for i := range variants {
@@ -250,7 +263,7 @@ func ResolveOrderVariant(
 	}
 
 	id := chosen.ID
-	return resolveVariantUnitPrice(chosen, channel, itemChannelPrices), chosen.Label, normalizeRecipeScale(chosen.RecipeScale), &id, nil
+	return resolveVariantUnitPrice(chosen, channel, itemChannelPrices), chosen.Label, normalizeRecipeScale(chosen.RecipeScale), &id, chosen.GSTRate, nil
 }
 
 func resolveVariantUnitPrice(
@@ -6,9 +6,18 @@ import (
 
 	"restaurant-api/internal/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// kotBatchSubID is the sub_id shared by the lines one create or update adds.
+func kotBatchSubID(req CreateOrderRequest) string {
+	if id := strings.TrimSpace(req.BatchSubID); id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
 func uniqueMenuItemIDs(items []CreateOrderItemRequest) []string {
 	ids := make([]string, 0, len(items))
 	seen := make(map[string]struct{}, len(items))
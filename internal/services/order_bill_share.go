@@ -21,6 +21,15 @@ type BillItemView struct {
 	Quantity int     `json:"quantity"`
 	UnitRate float64 `json:"unit_rate"`
 	Total    float64 `json:"total"`
+	HSNCode  string  `json:"hsn_code,omitempty"`
+}
+
+// BillTenderView is one payment shown on a paid bill.
+type BillTenderView struct {
+	Label     string  `json:"label"`
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference,omitempty"`
 }
 
 // BillSummaryView is the public bill payload rendered for customers.
@@ -32,6 +41,7 @@ type BillSummaryView struct {
 	TableNumber      string         `json:"table_number"`
 	OrderNumber      int            `json:"order_number"`
 	TicketNumber     int            `json:"ticket_number,omitempty"`
+	InvoiceNumber    string         `json:"invoice_number,omitempty"`
 	ServiceMode      string         `json:"service_mode,omitempty"`
 	CustomerName     string         `json:"customer_name,omitempty"`
 	CustomerPhone    string         `json:"customer_phone,omitempty"`
@@ -43,8 +53,18 @@ type BillSummaryView struct {
 	Total            float64        `json:"total"`
 	IsPaid           bool           `json:"is_paid"`
 	PaymentMethod    string         `json:"payment_method,omitempty"`
+	// Tenders lists each payment (method, amount, reference) once the bill is paid.
+	Tenders          []BillTenderView `json:"tenders,omitempty"`
 	PricesIncludeGST bool           `json:"prices_include_gst"`
 	CompositeScheme  bool           `json:"composite_scheme"`
+	// TaxBreakdown lists taxable value and GST per slab, split into CGST/SGST or IGST
+	// (empty for composite scheme).
+	TaxBreakdown []GSTSlab `json:"tax_breakdown"`
+	// TaxSummary is the HSN/SAC-wise table printed at the foot of GST invoices.
+	TaxSummary []GSTTaxSummaryRow `json:"tax_summary"`
+	// PlaceOfSupply is the GST state code; InterState means IGST was charged instead of CGST+SGST.
+	PlaceOfSupply string `json:"place_of_supply,omitempty"`
+	InterState    bool   `json:"inter_state"`
 	CreatedAt        time.Time      `json:"created_at"`
 }
 
@@ -57,8 +77,11 @@ func BuildBillURL(token string) string {
 }
 
 func orderItemsGross(items []models.OrderItem) float64 {
-	taxable, nonTaxable := orderItemsGrossSplit(items)
-	return taxable + nonTaxable
+	gross := 0.0
+	for _, amount := range orderItemsGrossByRate(items) {
+		gross += amount
+	}
+	return gross
 }
 
 func resolveBillItemName(item models.OrderItem, extraBlocklist []string) string {
@@ -100,10 +123,8 @@ func BuildBillSummary(order *models.Order, restaurant *models.Restaurant) BillSu
 		categoryBlocklist = ParseCategoryDisplayBlocklist(restaurant.CategoryDisplayBlocklist)
 	}
 
-	taxableGross, nonTaxableGross := orderItemsGrossSplit(order.Items)
-	subTotal, taxAmount, total := CalculateRestaurantOrderTax(
-		taxableGross,
-		nonTaxableGross,
+	orderTax := CalculateRestaurantOrderTax(
+		orderItemsGrossByRate(order.Items),
 		discount,
 		RestaurantTaxSettings{
 			CompositeScheme:  compositeScheme,
@@ -111,6 +132,13 @@ func BuildBillSummary(order *models.Order, restaurant *models.Restaurant) BillSu
 		},
 	)
 
+	interState := IsInterStateSupply(restaurant, order.PlaceOfSupply)
+	slabs := SplitGSTSlabs(orderTax.Slabs, interState)
+	placeOfSupply := GSTStateCode(order.PlaceOfSupply)
+	if placeOfSupply == "" && restaurant != nil {
+		placeOfSupply = GSTStateCode(restaurant.State)
+	}
+
 	items := make([]BillItemView, 0, len(order.Items))
 	for _, item := range order.Items {
 		if item.Status == "cancelled" {
@@ -121,13 +149,23 @@ func BuildBillSummary(order *models.Order, restaurant *models.Restaurant) BillSu
 			Quantity: item.Quantity,
 			UnitRate: item.UnitRate,
 			Total:    item.Total,
+			HSNCode:  OrderItemHSNCode(item),
 		})
 	}
 
 	isPaid := order.Status == "completed"
 	paymentMethod := ""
+	var tenders []BillTenderView
 	if isPaid {
 		paymentMethod = order.PaymentMethod
+		for _, tender := range order.Tenders {
+			tenders = append(tenders, BillTenderView{
+				Label:     TenderLabel(tender.Method, tender.Provider),
+				Method:    tender.Method,
+				Amount:    tender.Amount,
+				Reference: tender.Reference,
+			})
+		}
 	}
 
 	ticketNumber := order.TicketNumber
@@ -143,19 +181,25 @@ func BuildBillSummary(order *models.Order, restaurant *models.Restaurant) BillSu
 		TableNumber:      order.TableNumber,
 		OrderNumber:      order.OrderNumber,
 		TicketNumber:     ticketNumber,
+		InvoiceNumber:    order.InvoiceNumber,
 		ServiceMode:      order.ServiceMode,
 		CustomerName:     order.CustomerName,
 		CustomerPhone:    strings.TrimSpace(order.CustomerPhone),
 		AttendedByName:   AttendedByName(order),
 		Items:            items,
-		SubTotal:         subTotal,
-		TaxAmount:        taxAmount,
+		SubTotal:         orderTax.SubTotal,
+		TaxAmount:        orderTax.TaxAmount,
 		DiscountAmount:   discount,
-		Total:            total,
+		Total:            orderTax.Total,
 		IsPaid:           isPaid,
 		PaymentMethod:    paymentMethod,
+		Tenders:          tenders,
 		PricesIncludeGST: pricesIncludeGST,
 		CompositeScheme:  compositeScheme,
+		TaxBreakdown:     slabs,
+		TaxSummary:       BuildGSTTaxSummary(order.Items, slabs),
+		PlaceOfSupply:    placeOfSupply,
+		InterState:       interState,
 		CreatedAt:        order.CreatedAt,
 	}
 }
@@ -207,6 +251,7 @@ func (s *OrderService) GetOrderByBillToken(token string) (*models.Order, *models
 	var order models.Order
 	err := s.db.Preload("Items.MenuItem").
 		Preload("AttendedBy").
+		Preload("Tenders").
 		Where("bill_token = ?", token).
 		First(&order).Error
 	if err != nil {
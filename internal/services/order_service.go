@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderService struct {
@@ -24,10 +26,17 @@ type CreateOrderRequest struct {
 	TableID      *string                  `json:"table_id"` // Link to RestaurantTable for dine-in orders
 	CustomerName  string                   `json:"customer_name"`
 	CustomerPhone string                   `json:"customer_phone"`
+	// Covers is the number of guests at the table; on update, 0 leaves it unchanged.
+	Covers        int                      `json:"covers,omitempty"`
 	OrderType     string                   `json:"order_type"`   // dine_in | counter
 	ServiceMode  string                   `json:"service_mode"` // eat_here | takeaway (counter only)
+	// PlaceOfSupply is the buyer's state (name, GST code or GSTIN) for inter-state invoices.
+	PlaceOfSupply string                  `json:"place_of_supply,omitempty"`
 	Items        []CreateOrderItemRequest `json:"items" validate:"omitempty,dive"`
 	Notes        string                   `json:"notes"`
+	// BatchSubID fixes the KOT batch sub_id of the added lines (guest orders use their
+	// request ID); blank generates one.
+	BatchSubID string `json:"-"`
 }
 
 type CreateOrderItemRequest struct {
@@ -72,6 +81,9 @@ func (s *OrderService) GetDB() *gorm.DB {
 // ValidateCreateOrderRequest enforces item rules after struct validation.
 func ValidateCreateOrderRequest(req CreateOrderRequest) error {
 	orderType := inferOrderType(req)
+	if req.Covers < 0 || req.Covers > 500 {
+		return errors.New("covers must be between 0 and 500")
+	}
 	if len(req.Items) == 0 {
 		if orderType == "counter" {
 			return errors.New("at least one item is required for counter orders")
@@ -93,17 +105,6 @@ func ValidateCreateOrderRequest(req CreateOrderRequest) error {
 }
 
 func (s *OrderService) CreateOrder(restaurantID string, userID string, req CreateOrderRequest) (*models.Order, []models.Ingredient, error) {
-	if err := ValidateCreateOrderRequest(req); err != nil {
-		return nil, nil, err
-	}
-
-	// Validate items exist (batch load — one query instead of N)
-	menuItemIDs := uniqueMenuItemIDs(req.Items)
-	menuItemsByID, err := loadMenuItemsMap(s.db, restaurantID, menuItemIDs)
-	if err != nil {
-		return nil, nil, err
-	}
-
 	// Start transaction
 	log.Printf("🔵 [CreateOrder] Starting transaction for restaurant: %s", restaurantID)
 	tx := s.db.Begin()
@@ -115,9 +116,56 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		}
 	}()
 
+	order, updatedIngredients, err := s.createOrderTx(tx, restaurantID, userID, req)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	// Commit transaction
+	log.Printf("🔵 [CreateOrder] Committing transaction for order #%d", order.OrderNumber)
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("❌ [CreateOrder] Transaction commit failed: %v", err)
+		return nil, nil, err
+	}
+	log.Printf("✅ [CreateOrder] Transaction committed successfully for order #%d with ID: %s", order.OrderNumber, order.ID)
+
+	// Reload with items and totals for API response and WebSocket broadcast
+	if err := s.db.Preload("Items").
+		Preload("Items.MenuItem").
+		Where("id = ? AND restaurant_id = ?", order.ID, restaurantID).
+		First(order).Error; err != nil {
+		log.Printf("❌ [CreateOrder] Failed to reload order after create: %v", err)
+		return nil, nil, err
+	}
+
+	log.Printf("✅ [CreateOrder] Order created successfully: Order #%d, ID: %s, Total: ₹%.2f, Items: %d",
+		order.OrderNumber, order.ID, order.Total, len(order.Items))
+
+	return order, updatedIngredients, nil
+}
+
+// createOrderTx writes a new order and its first KOT batch in tx; the caller commits or
+// rolls back.
+func (s *OrderService) createOrderTx(tx *gorm.DB, restaurantID string, userID string, req CreateOrderRequest) (*models.Order, []models.Ingredient, error) {
+	if err := ValidateCreateOrderRequest(req); err != nil {
+		return nil, nil, err
+	}
+	placeOfSupply, err := NormalizePlaceOfSupply(req.PlaceOfSupply)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate items exist (batch load — one query instead of N)
+	menuItemIDs := uniqueMenuItemIDs(req.Items)
+	menuItemsByID, err := loadMenuItemsMap(tx, restaurantID, menuItemIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Determine order type and allocate numbers
 	orderType := inferOrderType(req)
-	todayStart := StartOfRestaurantDay(time.Now())
+	todayStart := StartOfRestaurantDay(time.Now(), LoadBusinessCalendar(tx, restaurantID))
 
 	var orderNumber int
 	var ticketNumber int
@@ -129,7 +177,6 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		ticketNumber, err = allocateCounterTicket(tx, restaurantID, todayStart)
 		if err != nil {
 			log.Printf("❌ [CreateOrder] Failed to allocate counter ticket: %v", err)
-			tx.Rollback()
 			return nil, nil, err
 		}
 		orderNumber = ticketNumber
@@ -137,7 +184,6 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		tableID = nil // counter orders are not tied to restaurant tables
 	} else {
 		if strings.TrimSpace(tableNumber) == "" {
-			tx.Rollback()
 			return nil, nil, errors.New("table_number is required for dine-in orders")
 		}
 		var lastOrder models.Order
@@ -171,10 +217,12 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		TableID:         tableID,
 		CustomerName:    customerName,
 		CustomerPhone:   strings.TrimSpace(req.CustomerPhone),
+		Covers:          req.Covers,
 		OrderNumber:     orderNumber,
 		OrderType:       orderType,
 		TicketNumber:    ticketNumber,
 		ServiceMode:     req.ServiceMode,
+		PlaceOfSupply:   placeOfSupply,
 		Status:          "pending",
 		SubTotal:        0,
 		TaxAmount:       0,
@@ -197,22 +245,20 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 
 	if createResult.Error != nil {
 		log.Printf("❌ [CreateOrder] Failed to create order: %v", createResult.Error)
-		tx.Rollback()
 		return nil, nil, createResult.Error
 	}
 
 	log.Printf("✅ [CreateOrder] Order created with ID: %s (RowsAffected: %d)", order.ID, createResult.RowsAffected)
 
 	// Create order items (inventory deduction is now optional)
-	var taxableGross, nonTaxableGross float64
-	batchSubID := uuid.New().String()
+	grossByRate := make(map[float64]float64)
+	batchSubID := kotBatchSubID(req)
 	log.Printf("🔵 [CreateOrder] KOT batch sub_id: %s", batchSubID)
 	log.Printf("🔵 [CreateOrder] Processing %d items for order #%d", len(req.Items), orderNumber)
 
 	inventoryByMenuID, err := loadInventoryByMenuMap(tx, restaurantID, menuItemIDs)
 	if err != nil {
 		log.Printf("❌ [CreateOrder] Failed to batch-load inventory: %v", err)
-		tx.Rollback()
 		return nil, nil, err
 	}
 
@@ -221,19 +267,17 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		menuItem, ok := menuItemsByID[itemReq.MenuItemID]
 		if !ok {
 			log.Printf("❌ [CreateOrder] Item %d: Menu item not found for ID: %s", i+1, itemReq.MenuItemID)
-			tx.Rollback()
 			return nil, nil, errors.New("menu item not found")
 		}
 
 		// Create order item with explicit UUID generation
 		itemID := uuid.New().String()
-		unitPrice, variantLabel, recipeScale, variantIDPtr, err := ResolveOrderVariant(
+		unitPrice, variantLabel, recipeScale, variantIDPtr, variantGSTRate, err := ResolveOrderVariant(
 			tx, restaurantID, menuItem.ID, itemReq.VariantID, menuItem.Price,
 			MenuChannelForOrder(orderType, req.ServiceMode), menuItem.ChannelPrices,
 		)
 		if err != nil {
 			log.Printf("❌ [CreateOrder] Item %d: variant resolve failed: %v", i+1, err)
-			tx.Rollback()
 			return nil, nil, err
 		}
 		variantID := ""
@@ -246,6 +290,7 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 			RecipeScale: recipeScale,
 			VariantID:   variantID,
 		})
+		taxRate := MenuItemGSTRate(&menuItem, &models.MenuItemVariant{GSTRate: variantGSTRate})
 		orderItem := &models.OrderItem{
 			ID:           itemID,
 			OrderID:      order.ID,
@@ -255,6 +300,8 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 			Quantity:     itemReq.Quantity,
 			UnitRate:     unitPrice,
 			Total:        unitPrice * float64(itemReq.Quantity),
+			TaxRate:      &taxRate,
+			HSNCode:      MenuItemHSNCode(&menuItem),
 			Status:       InitialOrderItemStatus(menuItem),
 			Notes:        itemReq.Notes,
 			SubId:        batchSubID,
@@ -263,16 +310,11 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		log.Printf("🔵 [CreateOrder] Item %d: Creating OrderItem - ID: %s, MenuID: %s, Qty: %d, Total: ₹%.2f", i+1, itemID, menuItem.ID, itemReq.Quantity, orderItem.Total)
 		if err := tx.Create(orderItem).Error; err != nil {
 			log.Printf("❌ [CreateOrder] Item %d: Failed to create order item: %v", i+1, err)
-			tx.Rollback()
 			return nil, nil, err
 		}
 		log.Printf("✅ [CreateOrder] Item %d: Created with ID: %s", i+1, orderItem.ID)
 
-		if menuItem.IsTaxable {
-			taxableGross += orderItem.Total
-		} else {
-			nonTaxableGross += orderItem.Total
-		}
+		grossByRate[taxRate] += orderItem.Total
 
 		// Attempt to deduct inventory if it exists
 		if inventory, ok := inventoryByMenuID[menuItem.ID]; ok {
@@ -282,7 +324,6 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 					Where("restaurant_id = ? AND menu_item_id = ?", restaurantID, menuItem.ID).
 					Update("quantity", gorm.Expr("quantity - ?", itemReq.Quantity)).Error; err != nil {
 					log.Printf("❌ [CreateOrder] Item %d: Failed to deduct inventory: %v", i+1, err)
-					tx.Rollback()
 					return nil, nil, err
 				}
 				log.Printf("✅ [CreateOrder] Inventory deducted: %s - %d units from stock", menuItem.Name, itemReq.Quantity)
@@ -297,10 +338,10 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 	var restaurant models.Restaurant
 	if err := tx.Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
 		log.Printf("❌ [CreateOrder] Restaurant not found: %v", err)
-		tx.Rollback()
 		return nil, nil, err
 	}
-	subTotal, taxAmount, total := CalculateRestaurantOrderTax(taxableGross, nonTaxableGross, 0, SettingsFromRestaurant(&restaurant))
+	orderTax := CalculateRestaurantOrderTax(grossByRate, 0, SettingsFromRestaurant(&restaurant))
+	subTotal, taxAmount, total := orderTax.SubTotal, orderTax.TaxAmount, orderTax.Total
 
 	// Update order totals
 	log.Printf("🔵 [CreateOrder] Updating order totals - SubTotal: ₹%.2f, Tax: ₹%.2f, Total: ₹%.2f (composite=%v, prices_include_gst=%v)", subTotal, taxAmount, total, restaurant.CompositeScheme, restaurant.PricesIncludeGST)
@@ -310,7 +351,6 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		"total":      total,
 	}).Error; err != nil {
 		log.Printf("❌ [CreateOrder] Failed to update order totals: %v", err)
-		tx.Rollback()
 		return nil, nil, err
 	}
 
@@ -320,60 +360,67 @@ func (s *OrderService) CreateOrder(restaurantID string, userID string, req Creat
 		updatedIngredients, deductErr = DeductIngredientsForMenuItems(tx, restaurantID, stockQuantities)
 		if deductErr != nil {
 			log.Printf("❌ [CreateOrder] Ingredient stock deduction failed: %v", deductErr)
-			tx.Rollback()
 			return nil, nil, deductErr
 		}
 	}
 
-	// Commit transaction
-	log.Printf("🔵 [CreateOrder] Committing transaction for order #%d", orderNumber)
+	return order, updatedIngredients, nil
+}
+
+// UpdateOrder adds items to an existing order
+func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req CreateOrderRequest) (*models.Order, []models.Ingredient, error) {
+	// Start transaction
+	log.Printf("🔵 [UpdateOrder] Starting transaction for order: %s", orderID)
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ [UpdateOrder] Transaction panic, rolling back: %v", r)
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	order, updatedIngredients, err := s.updateOrderTx(tx, restaurantID, orderID, req)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	log.Printf("🔵 [UpdateOrder] Committing transaction for order #%d", order.OrderNumber)
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("❌ [CreateOrder] Transaction commit failed: %v", err)
+		log.Printf("❌ [UpdateOrder] Transaction commit failed: %v", err)
 		return nil, nil, err
 	}
-	log.Printf("✅ [CreateOrder] Transaction committed successfully for order #%d with ID: %s", orderNumber, order.ID)
 
-	// Reload with items and totals for API response and WebSocket broadcast
-	if err := s.db.Preload("Items").
+	// Reload order with new items
+	if err := s.db.Where("id = ?", orderID).
+		Preload("Items").
 		Preload("Items.MenuItem").
-		Where("id = ? AND restaurant_id = ?", order.ID, restaurantID).
 		First(order).Error; err != nil {
-		log.Printf("❌ [CreateOrder] Failed to reload order after create: %v", err)
+		log.Printf("❌ [UpdateOrder] Failed to reload order: %v", err)
 		return nil, nil, err
 	}
 
-	log.Printf("✅ [CreateOrder] Order created successfully: Order #%d, ID: %s, Total: ₹%.2f, Items: %d",
-		order.OrderNumber, order.ID, order.Total, len(order.Items))
+	log.Printf("✅ [UpdateOrder] Order updated successfully: Order #%d, New Total: ₹%.2f", order.OrderNumber, order.Total)
 
 	return order, updatedIngredients, nil
 }
 
-// UpdateOrder adds items to an existing order
-func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req CreateOrderRequest) (*models.Order, []models.Ingredient, error) {
+// updateOrderTx adds req's items to the order as one KOT batch in tx; the caller commits
+// or rolls back.
+func (s *OrderService) updateOrderTx(tx *gorm.DB, restaurantID string, orderID string, req CreateOrderRequest) (*models.Order, []models.Ingredient, error) {
 	menuItemIDs := uniqueMenuItemIDs(req.Items)
-	menuItemsByID, err := loadMenuItemsMap(s.db, restaurantID, menuItemIDs)
+	menuItemsByID, err := loadMenuItemsMap(tx, restaurantID, menuItemIDs)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Start transaction
-	log.Printf("🔵 [UpdateOrder] Starting transaction for order: %s", orderID)
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("❌ [UpdateOrder] Transaction panic, rolling back: %v", r)
-			tx.Rollback()
-			panic(r)
-		}
-	}()
-
 	// Get existing order
 	var order models.Order
 	if err := tx.Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
 		Preload("Items").
 		Preload("Items.MenuItem").
 		First(&order).Error; err != nil {
-		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil, errors.New("order not found")
 		}
@@ -392,35 +439,38 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 		if customerPhone != order.CustomerPhone {
 			updates["customer_phone"] = customerPhone
 		}
+		if req.Covers > 0 && req.Covers != order.Covers {
+			updates["covers"] = req.Covers
+		}
 		if len(updates) > 0 {
 			if err := tx.Model(&order).Updates(updates).Error; err != nil {
-				tx.Rollback()
 				return nil, nil, err
 			}
 			order.CustomerName = customerName
 			order.CustomerPhone = customerPhone
+			if req.Covers > 0 {
+				order.Covers = req.Covers
+			}
 		}
 	}
 
 	// Add new items to the order (one KOT batch per update)
 	var totalAdded float64 = 0
-	batchSubID := uuid.New().String()
+	batchSubID := kotBatchSubID(req)
 	stockQuantities := make([]MenuItemQuantity, 0, len(req.Items))
 	log.Printf("🔵 [UpdateOrder] KOT batch sub_id: %s", batchSubID)
 	for _, itemReq := range req.Items {
 		menuItem, ok := menuItemsByID[itemReq.MenuItemID]
 		if !ok {
-			tx.Rollback()
 			return nil, nil, errors.New("menu item not found")
 		}
 
 		itemID := uuid.New().String()
-		unitPrice, variantLabel, recipeScale, variantIDPtr, err := ResolveOrderVariant(
+		unitPrice, variantLabel, recipeScale, variantIDPtr, variantGSTRate, err := ResolveOrderVariant(
 			tx, restaurantID, menuItem.ID, itemReq.VariantID, menuItem.Price,
 			MenuChannelForOrder(order.OrderType, order.ServiceMode), menuItem.ChannelPrices,
 		)
 		if err != nil {
-			tx.Rollback()
 			return nil, nil, err
 		}
 		variantID := ""
@@ -433,6 +483,7 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 			RecipeScale: recipeScale,
 			VariantID:   variantID,
 		})
+		taxRate := MenuItemGSTRate(&menuItem, &models.MenuItemVariant{GSTRate: variantGSTRate})
 		orderItem := models.OrderItem{
 			ID:           itemID,
 			OrderID:      orderID,
@@ -442,13 +493,14 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 			Quantity:     itemReq.Quantity,
 			UnitRate:     unitPrice,
 			Total:        unitPrice * float64(itemReq.Quantity),
+			TaxRate:      &taxRate,
+			HSNCode:      MenuItemHSNCode(&menuItem),
 			Status:       InitialOrderItemStatus(menuItem),
 			Notes:        itemReq.Notes,
 			SubId:        batchSubID,
 		}
 
 		if err := tx.Create(&orderItem).Error; err != nil {
-			tx.Rollback()
 			log.Printf("❌ [UpdateOrder] Failed to create order item: %v", err)
 			return nil, nil, err
 		}
@@ -460,7 +512,6 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 		if err := tx.Model(&models.Inventory{}).
 			Where("restaurant_id = ? AND menu_item_id = ?", restaurantID, menuItem.ID).
 			Update("quantity", gorm.Expr("quantity - ?", itemReq.Quantity)).Error; err != nil {
-			tx.Rollback()
 			log.Printf("❌ [UpdateOrder] Inventory deduction failed: %v", err)
 			return nil, nil, err
 		}
@@ -469,17 +520,15 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 	// Recalculate order totals from all line items
 	var restaurant models.Restaurant
 	if err := tx.Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
-		tx.Rollback()
 		return nil, nil, err
 	}
 
 	var allItems []models.OrderItem
 	if err := tx.Preload("MenuItem").Where("order_id = ?", orderID).Find(&allItems).Error; err != nil {
-		tx.Rollback()
 		return nil, nil, err
 	}
-	taxableGross, nonTaxableGross := orderItemsGrossSplit(allItems)
-	subTotal, taxAmount, total := CalculateRestaurantOrderTax(taxableGross, nonTaxableGross, order.DiscountAmount, SettingsFromRestaurant(&restaurant))
+	orderTax := CalculateRestaurantOrderTax(orderItemsGrossByRate(allItems), order.DiscountAmount, SettingsFromRestaurant(&restaurant))
+	subTotal, taxAmount, total := orderTax.SubTotal, orderTax.TaxAmount, orderTax.Total
 	order.SubTotal = subTotal
 	order.TaxAmount = taxAmount
 	order.Total = total
@@ -489,7 +538,6 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 		"tax_amount": order.TaxAmount,
 		"total":      order.Total,
 	}).Error; err != nil {
-		tx.Rollback()
 		log.Printf("❌ [UpdateOrder] Failed to update order totals: %v", err)
 		return nil, nil, err
 	}
@@ -500,28 +548,10 @@ func (s *OrderService) UpdateOrder(restaurantID string, orderID string, req Crea
 		updatedIngredients, deductErr = DeductIngredientsForMenuItems(tx, restaurantID, stockQuantities)
 		if deductErr != nil {
 			log.Printf("❌ [UpdateOrder] Ingredient stock deduction failed: %v", deductErr)
-			tx.Rollback()
 			return nil, nil, deductErr
 		}
 	}
 
-	log.Printf("🔵 [UpdateOrder] Committing transaction for order #%d", order.OrderNumber)
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("❌ [UpdateOrder] Transaction commit failed: %v", err)
-		return nil, nil, err
-	}
-
-	// Reload order with new items
-	if err := s.db.Where("id = ?", orderID).
-		Preload("Items").
-		Preload("Items.MenuItem").
-		First(&order).Error; err != nil {
-		log.Printf("❌ [UpdateOrder] Failed to reload order: %v", err)
-		return nil, nil, err
-	}
-
-	log.Printf("✅ [UpdateOrder] Order updated successfully: Order #%d, New Total: ₹%.2f", order.OrderNumber, order.Total)
-
 	return &order, updatedIngredients, nil
 }
 
@@ -536,6 +566,7 @@ func (s *OrderService) reloadOrderWithItems(orderID, restaurantID string) (*mode
 	if err := s.db.Preload("Items").
 		Preload("Items.MenuItem").
 		Preload("AttendedBy").
+		Preload("Tenders").
 		Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
 		First(&order).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -599,7 +630,9 @@ func (s *OrderService) CompleteOrder(restaurantID string, orderID string) (*mode
 	return s.reloadOrderWithItems(orderID, restaurantID)
 }
 
-// OrderPaymentDetails captures cash/UPI/split payment completion.
+// OrderPaymentDetails captures payment completion. Tenders lists every payment applied
+// (cash, UPI, card, wallet) with its reference; when empty, tenders are derived from the
+// legacy PaymentMethod + CashAmount/UpiAmount fields.
 type OrderPaymentDetails struct {
 	PaymentMethod    string
 	AmountReceived   float64
@@ -607,19 +640,24 @@ type OrderPaymentDetails struct {
 	CashAmount       float64
 	UpiAmount        float64
 	UpiTransactionID string
+	// Reference and Provider describe a single card/wallet payment sent without Tenders.
+	Reference string
+	Provider  string
+	Tenders   []PaymentTender
 	AttendedByUserID string
 	// DiscountAmount is applied when HasDiscount is true (recalculates tax/total).
 	DiscountAmount float64
 	HasDiscount    bool
+	// PlaceOfSupply overrides the order's GST place of supply when non-nil ("" resets to home state).
+	PlaceOfSupply *string
+	// RecordedByUserID is the staff member taking the payment, written to the transaction ledger.
+	RecordedByUserID string
 }
 
 // CompleteOrderWithPayment completes order with payment details
 func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID string, payment OrderPaymentDetails) (*models.Order, error) {
-	paymentMethod := payment.PaymentMethod
 	amountReceived := payment.AmountReceived
 	changeReturned := payment.ChangeReturned
-	cashAmount := payment.CashAmount
-	upiAmount := payment.UpiAmount
 	attendedByUserID := payment.AttendedByUserID
 	if attendedByUserID != "" {
 		if err := s.validateAttendant(restaurantID, attendedByUserID); err != nil {
@@ -634,8 +672,12 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 		}
 	}()
 
+	// Lock the order row so two tills settling the same bill queue up: the second sees the
+	// first one's invoice number and payment, and neither allocates a second invoice nor
+	// writes the ledger twice.
 	var order models.Order
-	if err := tx.Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
 		First(&order).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
@@ -643,6 +685,7 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 		}
 		return nil, err
 	}
+	alreadyPaid := IsOrderPaid(&order)
 
 	log.Printf("🔵 [CompleteOrderWithPayment] BEFORE - Order #%d Status: %s, Total: [redacted]", order.OrderNumber, order.Status)
 
@@ -662,10 +705,8 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 			tx.Rollback()
 			return nil, err
 		}
-		taxableGross, nonTaxableGross := orderItemsGrossSplit(allItems)
-		subTotal, taxAmount, total := CalculateRestaurantOrderTax(
-			taxableGross, nonTaxableGross, discount, SettingsFromRestaurant(&restaurant),
-		)
+		orderTax := CalculateRestaurantOrderTax(orderItemsGrossByRate(allItems), discount, SettingsFromRestaurant(&restaurant))
+		subTotal, taxAmount, total := orderTax.SubTotal, orderTax.TaxAmount, orderTax.Total
 		order.DiscountAmount = discount
 		order.BillPreviewDiscount = discount
 		order.SubTotal = subTotal
@@ -684,16 +725,15 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 		}
 	}
 
-	if paymentMethod == "split" {
-		if cashAmount <= 0 || upiAmount <= 0 {
-			tx.Rollback()
-			return nil, errors.New("split payment requires cash_amount and upi_amount greater than zero")
-		}
-		totalPaid := cashAmount + upiAmount
-		if totalPaid < order.Total-0.02 || totalPaid > order.Total+0.02 {
-			tx.Rollback()
-			return nil, fmt.Errorf("cash and upi amounts must equal order total (%.2f)", order.Total)
-		}
+	tenders, err := ResolvePaymentTenders(payment, order.Total)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	paymentMethod, cashAmount, upiAmount, upiTransactionID := TenderPaymentColumns(tenders)
+	if paymentMethod == "" {
+		// Zero-total bill: nothing tendered, keep the method the cashier picked.
+		paymentMethod = strings.ToLower(strings.TrimSpace(payment.PaymentMethod))
 	}
 
 	isCounter := order.OrderType == "counter"
@@ -714,12 +754,31 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 		"upi_amount":      upiAmount,
 		"updated_at":      now,
 	}
-	if txnID := strings.TrimSpace(payment.UpiTransactionID); txnID != "" {
-		updates["upi_transaction_id"] = txnID
+	if upiTransactionID != "" {
+		updates["upi_transaction_id"] = upiTransactionID
 	}
 	if attendedByUserID != "" {
 		updates["attended_by_user_id"] = attendedByUserID
 	}
+	if strings.TrimSpace(order.InvoiceNumber) == "" {
+		invoiceNumber, invoiceYear, invoiceSeq, err := AssignInvoiceNumber(tx, restaurantID, now)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("❌ [CompleteOrderWithPayment] Invoice number allocation failed: %v", err)
+			return nil, err
+		}
+		updates["invoice_number"] = invoiceNumber
+		updates["invoice_year"] = invoiceYear
+		updates["invoice_seq"] = invoiceSeq
+	}
+	if payment.PlaceOfSupply != nil {
+		placeOfSupply, err := NormalizePlaceOfSupply(*payment.PlaceOfSupply)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		updates["place_of_supply"] = placeOfSupply
+	}
 
 	if isCounter {
 		// Paid at counter — keep pending so kitchen can prepare items
@@ -744,6 +803,33 @@ func (s *OrderService) CompleteOrderWithPayment(restaurantID string, orderID str
 		log.Printf("❌ [CompleteOrderWithPayment] Update failed: %v", err)
 		return nil, err
 	}
+	tenderRows, err := replaceOrderTenders(tx, &order, tenders)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("❌ [CompleteOrderWithPayment] Tender write failed: %v", err)
+		return nil, err
+	}
+
+	// Only the first settlement is money in; re-submitting payment details on a paid
+	// counter order corrects the order columns but must not double the ledger.
+	if !alreadyPaid {
+		paid := order
+		paid.PaymentMethod = paymentMethod
+		paid.CashAmount = cashAmount
+		paid.UpiAmount = upiAmount
+		paid.UpiTransactionID = upiTransactionID
+		paid.Tenders = tenderRows
+		if invoiceNumber, ok := updates["invoice_number"].(string); ok {
+			paid.InvoiceNumber = invoiceNumber
+		}
+		for _, leg := range PaymentLedgerLegs(&paid, payment.RecordedByUserID) {
+			if err := RecordTransaction(tx, leg); err != nil {
+				tx.Rollback()
+				log.Printf("❌ [CompleteOrderWithPayment] Ledger write failed: %v", err)
+				return nil, err
+			}
+		}
+	}
 
 	// Dine-in checkout frees the table (same as cancel) so clients can't leave it occupied.
 	if !isCounter && order.TableID != nil && strings.TrimSpace(*order.TableID) != "" {
@@ -866,6 +952,7 @@ func (s *OrderService) GetOrderByID(restaurantID string, orderID string) (*model
 	if err := s.db.Preload("Items").
 		Preload("Items.MenuItem").
 		Preload("AttendedBy").
+		Preload("Tenders").
 		Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
 		First(&order).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -974,8 +1061,18 @@ type SalesSummary struct {
 	CounterOrders     int64   `json:"counter_orders"`
 	CounterRevenue    float64 `json:"counter_revenue"`
 	TotalGST          float64 `json:"total_gst"`
+	// GSTByRate splits TotalGST by slab (taxable value and tax per rate).
+	GSTByRate         []GSTSlab `json:"gst_by_rate"`
 	CashAmount        float64 `json:"cash_amount"`
 	UpiAmount         float64 `json:"upi_amount"`
+	// Tenders is money taken per method and provider (cash, UPI, card, wallets).
+	Tenders []TenderTotal `json:"tenders"`
+	// Refunds are credit notes issued in the period; Net* subtract them from the gross figures.
+	RefundCount  int64   `json:"refund_count"`
+	RefundAmount float64 `json:"refund_amount"`
+	RefundGST    float64 `json:"refund_gst"`
+	NetRevenue   float64 `json:"net_revenue"`
+	NetGST       float64 `json:"net_gst"`
 }
 
 // SalesDayPoint is one day's revenue in a sales chart series.
@@ -1124,7 +1221,7 @@ func (s *OrderService) ListOrdersSummary(restaurantID string, status string, lim
 // period: today | this_month | last_month | current_quarter | last_quarter | range
 // orderType: all | dine_in | counter
 func (s *OrderService) GetSalesSummary(restaurantID, period, orderType, fromStr, toStr string) (*SalesSummary, error) {
-	window, err := ResolveSalesPeriodWindow(period, fromStr, toStr)
+	window, err := ResolveSalesPeriodWindow(period, fromStr, toStr, LoadBusinessCalendar(s.db, restaurantID))
 	if err != nil {
 		return nil, err
 	}
@@ -1134,8 +1231,6 @@ func (s *OrderService) GetSalesSummary(restaurantID, period, orderType, fromStr,
 		TotalOrders  int64
 		TotalRevenue float64
 		TotalGST     float64
-		CashAmount   float64
-		UpiAmount    float64
 	}
 
 	applySalesWindow := func(db *gorm.DB) *gorm.DB {
@@ -1149,17 +1244,7 @@ func (s *OrderService) GetSalesSummary(restaurantID, period, orderType, fromStr,
 	paymentSelect := `
 		COUNT(*) AS total_orders,
 		COALESCE(SUM(total), 0) AS total_revenue,
-		COALESCE(SUM(tax_amount), 0) AS total_gst,
-		COALESCE(SUM(CASE
-			WHEN LOWER(payment_method) = 'cash' THEN total
-			WHEN LOWER(payment_method) = 'split' THEN cash_amount
-			ELSE 0
-		END), 0) AS cash_amount,
-		COALESCE(SUM(CASE
-			WHEN LOWER(payment_method) = 'upi' THEN total
-			WHEN LOWER(payment_method) = 'split' THEN upi_amount
-			ELSE 0
-		END), 0) AS upi_amount`
+		COALESCE(SUM(tax_amount), 0) AS total_gst`
 
 	var total agg
 	if err := applySalesWindow(s.db).
@@ -1188,6 +1273,30 @@ func (s *OrderService) GetSalesSummary(restaurantID, period, orderType, fromStr,
 		}
 	}
 
+	gstByRate, err := s.salesGSTByRate(restaurantID, applySalesWindow(s.db).Select("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	tenders, err := s.salesTenderTotals(applySalesWindow(s.db).Select("id"))
+	if err != nil {
+		return nil, err
+	}
+	cashAmount, upiAmount := 0.0, 0.0
+	for _, t := range tenders {
+		switch t.Method {
+		case TenderCash:
+			cashAmount += t.Amount
+		case TenderUPI:
+			upiAmount += t.Amount
+		}
+	}
+
+	refunds, err := s.RefundTotalsForRange(restaurantID, window.From, window.ToEnd, orderType)
+	if err != nil {
+		return nil, err
+	}
+
 	avg := float64(0)
 	if total.TotalOrders > 0 {
 		avg = total.TotalRevenue / float64(total.TotalOrders)
@@ -1203,11 +1312,90 @@ func (s *OrderService) GetSalesSummary(restaurantID, period, orderType, fromStr,
 		CounterOrders:     counter.TotalOrders,
 		CounterRevenue:    counter.TotalRevenue,
 		TotalGST:          total.TotalGST,
-		CashAmount:        total.CashAmount,
-		UpiAmount:         total.UpiAmount,
+		GSTByRate:         gstByRate,
+		CashAmount:        cashAmount,
+		UpiAmount:         upiAmount,
+		Tenders:           tenders,
+		RefundCount:       refunds.Count,
+		RefundAmount:      refunds.Amount,
+		RefundGST:         refunds.TaxAmount,
+		NetRevenue:        total.TotalRevenue - refunds.Amount,
+		NetGST:            total.TotalGST - refunds.TaxAmount,
 	}, nil
 }
 
+// salesGSTByRate splits each order's stored tax_amount across the GST slabs of its lines,
+// and each slab into CGST/SGST or IGST by the order's place of supply. Shares follow each
+// slab's nominal tax, so checkout discounts prorate the same way they did on the bill.
+func (s *OrderService) salesGSTByRate(restaurantID string, orderIDs *gorm.DB) ([]GSTSlab, error) {
+	var restaurant models.Restaurant
+	if err := s.db.Select("id", "prices_include_gst", "state", "gst_number").Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
+		return nil, err
+	}
+
+	type rateLine struct {
+		OrderID       string
+		TaxAmount     float64
+		PlaceOfSupply string
+		Rate          float64
+		Gross         float64
+	}
+	var lines []rateLine
+	if err := s.db.Table("order_items").
+		Select("order_items.order_id, orders.tax_amount, orders.place_of_supply, COALESCE(order_items.tax_rate, 0) AS rate, COALESCE(SUM(order_items.total), 0) AS gross").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.order_id IN (?)", orderIDs).
+		Where("order_items.status <> ?", "cancelled").
+		Group("order_items.order_id, orders.tax_amount, orders.place_of_supply, COALESCE(order_items.tax_rate, 0)").
+		Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	nominal := func(l rateLine) float64 {
+		if restaurant.PricesIncludeGST {
+			return l.Gross * l.Rate / (100 + l.Rate)
+		}
+		return l.Gross * l.Rate / 100
+	}
+	orderWeight := make(map[string]float64)
+	for _, l := range lines {
+		orderWeight[l.OrderID] += nominal(l)
+	}
+
+	byRate := make(map[float64]*GSTSlab)
+	for _, l := range lines {
+		w := orderWeight[l.OrderID]
+		if l.Rate <= 0 || w <= 0 || l.TaxAmount <= 0 {
+			continue
+		}
+		slab, ok := byRate[l.Rate]
+		if !ok {
+			slab = &GSTSlab{Rate: l.Rate}
+			byRate[l.Rate] = slab
+		}
+		tax := l.TaxAmount * nominal(l) / w
+		slab.TaxAmount += tax
+		slab.TaxableValue += tax * 100 / l.Rate
+		if IsInterStateSupply(&restaurant, l.PlaceOfSupply) {
+			slab.IGST += tax
+		} else {
+			slab.CGST += tax / 2
+			slab.SGST += tax / 2
+		}
+	}
+
+	rates := make([]float64, 0, len(byRate))
+	for rate := range byRate {
+		rates = append(rates, rate)
+	}
+	sort.Float64s(rates)
+	out := make([]GSTSlab, 0, len(rates))
+	for _, rate := range rates {
+		out = append(out, *byRate[rate])
+	}
+	return out, nil
+}
+
 // SalesPeriodWindow is a resolved [From, ToEnd) window for sales queries.
 type SalesPeriodWindow struct {
 	Label          string
@@ -1237,17 +1425,19 @@ func applySalesOrderTypeFilter(query *gorm.DB, orderType string) *gorm.DB {
 	}
 }
 
-// ResolveSalesPeriodWindow maps UI period presets (and optional custom range) to timestamps.
+// ResolveSalesPeriodWindow maps UI period presets (and optional custom range) to business-day bounds in cal.
 // Max custom/preset span is 366 days. period: today | this_month | last_month | current_quarter | last_quarter | range
-func ResolveSalesPeriodWindow(period, fromStr, toStr string) (*SalesPeriodWindow, error) {
-	loc := RestaurantLocation()
-	now := time.Now().In(loc)
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
-	tomorrow := todayStart.Add(24 * time.Hour)
+func ResolveSalesPeriodWindow(period, fromStr, toStr string, cal BusinessCalendar) (*SalesPeriodWindow, error) {
+	todayStart := StartOfRestaurantDay(time.Now(), cal)
+	today := cal.BusinessDate(todayStart)
+	tomorrow := cal.DayStart(today.Year(), today.Month(), today.Day()+1)
+	dayStart := func(t time.Time) time.Time {
+		return cal.DayStart(t.Year(), t.Month(), t.Day())
+	}
 
 	quarterStart := func(t time.Time) time.Time {
 		m := ((int(t.Month())-1)/3)*3 + 1
-		return time.Date(t.Year(), time.Month(m), 1, 0, 0, 0, 0, loc)
+		return cal.DayStart(t.Year(), time.Month(m), 1)
 	}
 
 	var from, toEnd, prevFrom, prevToEnd time.Time
@@ -1257,37 +1447,37 @@ func ResolveSalesPeriodWindow(period, fromStr, toStr string) (*SalesPeriodWindow
 	case "today":
 		from = todayStart
 		toEnd = tomorrow
-		prevFrom = todayStart.AddDate(0, 0, -1)
+		prevFrom = cal.DayStart(today.Year(), today.Month(), today.Day()-1)
 		prevToEnd = todayStart
 		label = "today"
 	case "this_month", "month":
-		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		from = cal.DayStart(today.Year(), today.Month(), 1)
 		toEnd = tomorrow
-		prevFrom = from.AddDate(0, -1, 0)
+		prevFrom = dayStart(from.AddDate(0, -1, 0))
 		prevToEnd = from
 		label = "this_month"
 	case "last_month":
-		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
-		from = thisMonth.AddDate(0, -1, 0)
+		thisMonth := cal.DayStart(today.Year(), today.Month(), 1)
+		from = dayStart(thisMonth.AddDate(0, -1, 0))
 		toEnd = thisMonth
-		prevFrom = from.AddDate(0, -1, 0)
+		prevFrom = dayStart(from.AddDate(0, -1, 0))
 		prevToEnd = from
 		label = "last_month"
 	case "current_quarter":
-		from = quarterStart(now)
+		from = quarterStart(today)
 		toEnd = tomorrow
 		prevToEnd = from
 		prevFrom = quarterStart(from.AddDate(0, -1, 0))
 		label = "current_quarter"
 	case "last_quarter":
-		thisQ := quarterStart(now)
+		thisQ := quarterStart(today)
 		from = quarterStart(thisQ.AddDate(0, -1, 0))
 		toEnd = thisQ
 		prevToEnd = from
 		prevFrom = quarterStart(from.AddDate(0, -1, 0))
 		label = "last_quarter"
 	case "range":
-		parsedFrom, parsedToEnd, err := ParseHistoryDateRange(fromStr, toStr)
+		parsedFrom, parsedToEnd, err := ParseHistoryDateRange(fromStr, toStr, cal)
 		if err != nil {
 			return nil, err
 		}
@@ -1304,24 +1494,24 @@ func ResolveSalesPeriodWindow(period, fromStr, toStr string) (*SalesPeriodWindow
 		prevFrom = from.Add(-span)
 		label = "range"
 	case "week":
-		weekday := int(now.Weekday())
+		weekday := int(today.Weekday())
 		if weekday == 0 {
 			weekday = 7
 		}
-		from = todayStart.AddDate(0, 0, -(weekday - 1))
+		from = dayStart(today.AddDate(0, 0, -(weekday - 1)))
 		toEnd = tomorrow
-		prevFrom = from.AddDate(0, 0, -7)
+		prevFrom = dayStart(from.AddDate(0, 0, -7))
 		prevToEnd = from
 		label = "week"
 	case "last_week":
-		weekday := int(now.Weekday())
+		weekday := int(today.Weekday())
 		if weekday == 0 {
 			weekday = 7
 		}
-		thisWeek := todayStart.AddDate(0, 0, -(weekday - 1))
-		from = thisWeek.AddDate(0, 0, -7)
+		thisWeek := dayStart(today.AddDate(0, 0, -(weekday - 1)))
+		from = dayStart(thisWeek.AddDate(0, 0, -7))
 		toEnd = thisWeek
-		prevFrom = from.AddDate(0, 0, -7)
+		prevFrom = dayStart(from.AddDate(0, 0, -7))
 		prevToEnd = from
 		label = "last_week"
 	default:
@@ -1343,13 +1533,14 @@ func ResolveSalesPeriodWindow(period, fromStr, toStr string) (*SalesPeriodWindow
 
 // GetSalesAnalytics returns daily sales, previous-period comparison, and top-selling items.
 func (s *OrderService) GetSalesAnalytics(restaurantID, period, orderType, fromStr, toStr string) (*SalesAnalytics, error) {
-	window, err := ResolveSalesPeriodWindow(period, fromStr, toStr)
+	cal := LoadBusinessCalendar(s.db, restaurantID)
+	window, err := ResolveSalesPeriodWindow(period, fromStr, toStr, cal)
 	if err != nil {
 		return nil, err
 	}
 	orderType = normalizeSalesOrderType(orderType)
 
-	series, err := s.dailySalesSeries(restaurantID, window.From, window.ToEnd, orderType)
+	series, err := s.dailySalesSeries(restaurantID, window.From, window.ToEnd, orderType, cal)
 	if err != nil {
 		return nil, err
 	}
@@ -1382,7 +1573,7 @@ func (s *OrderService) GetSalesAnalytics(restaurantID, period, orderType, fromSt
 		return nil, err
 	}
 
-	toInclusive := window.ToEnd.Add(-24 * time.Hour)
+	toInclusive := window.ToEnd.AddDate(0, 0, -1)
 	return &SalesAnalytics{
 		Period:            window.Label,
 		From:              window.From.Format("2006-01-02"),
@@ -1447,8 +1638,8 @@ func (s *OrderService) SalesStatsForRange(restaurantID string, from, toEnd time.
 	return revenue, orders, avg, topItems, nil
 }
 
-func (s *OrderService) dailySalesSeries(restaurantID string, from, toEnd time.Time, orderType string) ([]SalesDayPoint, error) {
-	loc := RestaurantLocation()
+func (s *OrderService) dailySalesSeries(restaurantID string, from, toEnd time.Time, orderType string, cal BusinessCalendar) ([]SalesDayPoint, error) {
+	loc := cal.location()
 	tz := loc.String()
 
 	type dayRow struct {
@@ -1457,8 +1648,8 @@ func (s *OrderService) dailySalesSeries(restaurantID string, from, toEnd time.Ti
 		Revenue float64
 	}
 
-	// Convert activity timestamp into the restaurant local calendar date.
-	daySQL := fmt.Sprintf("((%s AT TIME ZONE '%s')::date)", historyActivityAtSQL, tz)
+	// Convert activity timestamp into the restaurant's business date (local time minus the day-start cutoff).
+	daySQL := fmt.Sprintf("(((%s AT TIME ZONE '%s') - INTERVAL '%d minutes')::date)", historyActivityAtSQL, tz, cal.DayStartMinutes)
 
 	var rows []dayRow
 	q := s.db.Model(&models.Order{}).
@@ -1487,7 +1678,7 @@ func (s *OrderService) dailySalesSeries(restaurantID string, from, toEnd time.Ti
 	span := toEnd.Sub(from)
 	series := make([]SalesDayPoint, 0)
 	for d := from; d.Before(toEnd); d = d.AddDate(0, 0, 1) {
-		key := d.Format("2006-01-02")
+		key := cal.BusinessDate(d).Format("2006-01-02")
 		row := byDate[key]
 		label := d.Format("Mon")
 		if span > 45*24*time.Hour {
@@ -1580,6 +1771,7 @@ func (s *OrderService) ListOrderHistory(restaurantID string, from, toEnd time.Ti
 	var orders []models.Order
 	err := query.Preload("Items").
 		Preload("Items.MenuItem").
+		Preload("Tenders").
 		Order("COALESCE(completed_at, created_at) DESC").
 		Limit(limit).
 		Offset(offset).
@@ -1780,8 +1972,8 @@ func (s *OrderService) AdjustOrderItemQuantity(
 		tx.Rollback()
 		return nil, nil, err
 	}
-	taxableGross, nonTaxableGross := orderItemsGrossSplit(allItems)
-	subTotal, taxAmount, total := CalculateRestaurantOrderTax(taxableGross, nonTaxableGross, order.DiscountAmount, SettingsFromRestaurant(&restaurant))
+	orderTax := CalculateRestaurantOrderTax(orderItemsGrossByRate(allItems), order.DiscountAmount, SettingsFromRestaurant(&restaurant))
+	subTotal, taxAmount, total := orderTax.SubTotal, orderTax.TaxAmount, orderTax.Total
 	if err := tx.Model(&order).Updates(map[string]interface{}{
 		"sub_total":  subTotal,
 		"tax_amount": taxAmount,
@@ -1920,7 +2112,7 @@ func allocateCounterTicket(tx *gorm.DB, restaurantID string, todayStart time.Tim
 
 // GetNextCounterTicket returns a preview of the next daily counter ticket (not reserved).
 func (s *OrderService) GetNextCounterTicket(restaurantID string) (int, error) {
-	todayStart := StartOfRestaurantDay(time.Now())
+	todayStart := StartOfRestaurantDay(time.Now(), LoadBusinessCalendar(s.db, restaurantID))
 	maxTicket, err := getMaxCounterTicketToday(s.db, restaurantID, todayStart)
 	if err != nil {
 		return 0, err
@@ -1934,7 +2126,7 @@ func (s *OrderService) ListCounterOrdersToday(restaurantID string, limit int) ([
 	if limit <= 0 || limit > 200 {
 		limit = 100
 	}
-	todayStart := StartOfRestaurantDay(time.Now())
+	todayStart := StartOfRestaurantDay(time.Now(), LoadBusinessCalendar(s.db, restaurantID))
 
 	var orders []models.Order
 	err := s.db.
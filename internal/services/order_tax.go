@@ -1,9 +1,29 @@
 package services
 
-import "restaurant-api/internal/models"
+import (
+	"errors"
+	"math"
+	"sort"
 
-// OrderGSTRate is the GST rate applied to restaurant orders (5%).
-const OrderGSTRate = 0.05
+	"restaurant-api/internal/models"
+)
+
+// DefaultGSTRate is the GST slab (percent) for menu items without an explicit rate.
+const DefaultGSTRate = 5.0
+
+// GSTSlabRates are the GST rates (percent) a menu item or variant may carry.
+// Exempt and MRP items use is_taxable=false rather than a zero slab.
+var GSTSlabRates = []float64{5, 12, 18, 28, 40}
+
+// NormalizeGSTRate validates a GST rate against the supported slabs.
+func NormalizeGSTRate(rate float64) (float64, error) {
+	for _, slab := range GSTSlabRates {
+		if math.Abs(rate-slab) < 0.001 {
+			return slab, nil
+		}
+	}
+	return 0, errors.New("gst_rate must be one of 5, 12, 18, 28 or 40 (use is_taxable=false for exempt items)")
+}
 
 // InitialOrderItemStatus returns the kitchen status for a new order line.
 // Readily available items (water, packaged goods) skip the kitchen queue and
@@ -32,88 +52,129 @@ func SettingsFromRestaurant(r *models.Restaurant) RestaurantTaxSettings {
 	}
 }
 
-// orderItemsGrossSplit splits active order line totals into taxable and non-taxable gross.
-func orderItemsGrossSplit(items []models.OrderItem) (taxableGross, nonTaxableGross float64) {
+// GSTSlab is the taxable value and GST charged at one rate on a bill.
+type GSTSlab struct {
+	Rate         float64 `json:"rate"`
+	TaxableValue float64 `json:"taxable_value"`
+	TaxAmount    float64 `json:"tax_amount"`
+	// CGST/SGST (intra-state) or IGST (inter-state) components; see SplitGSTSlabs.
+	CGST float64 `json:"cgst"`
+	SGST float64 `json:"sgst"`
+	IGST float64 `json:"igst"`
+}
+
+// OrderTax is the result of a bill tax calculation, with GST broken down by slab.
+type OrderTax struct {
+	SubTotal  float64
+	TaxAmount float64
+	Total     float64
+	Slabs     []GSTSlab
+}
+
+// MenuItemGSTRate is the GST rate for a menu item, or for one of its variants when the
+// variant overrides the item's slab. Non-taxable (MRP) items are always 0.
+func MenuItemGSTRate(menuItem *models.MenuItem, variant *models.MenuItemVariant) float64 {
+	if menuItem != nil && !menuItem.IsTaxable {
+		return 0
+	}
+	if variant != nil && variant.GSTRate != nil {
+		return *variant.GSTRate
+	}
+	if menuItem != nil && menuItem.GSTRate > 0 {
+		return menuItem.GSTRate
+	}
+	return DefaultGSTRate
+}
+
+// OrderItemGSTRate returns the rate snapshotted on the order line, falling back to the
+// menu item for lines written before rates were stored.
+func OrderItemGSTRate(item models.OrderItem) float64 {
+	if item.TaxRate != nil {
+		return *item.TaxRate
+	}
+	return MenuItemGSTRate(item.MenuItem, item.Variant)
+}
+
+// orderItemsGrossByRate sums active order line totals per GST rate.
+func orderItemsGrossByRate(items []models.OrderItem) map[float64]float64 {
+	out := make(map[float64]float64)
 	for _, item := range items {
 		if item.Status == "cancelled" {
 			continue
 		}
-		isTaxable := true
-		if item.MenuItem != nil {
-			isTaxable = item.MenuItem.IsTaxable
-		}
-		if isTaxable {
-			taxableGross += item.Total
-		} else {
-			nonTaxableGross += item.Total
-		}
+		out[OrderItemGSTRate(item)] += item.Total
 	}
-	return taxableGross, nonTaxableGross
+	return out
 }
 
-// CalculateRestaurantOrderTax derives sub_total, tax_amount, and total.
-// Non-taxable item gross is never charged GST. Composite scheme restaurants have zero tax.
-func CalculateRestaurantOrderTax(taxableGross, nonTaxableGross, discount float64, settings RestaurantTaxSettings) (subTotal float64, taxAmount float64, total float64) {
-	if taxableGross < 0 {
-		taxableGross = 0
-	}
-	if nonTaxableGross < 0 {
-		nonTaxableGross = 0
+// CalculateRestaurantOrderTax derives sub_total, tax_amount, total and the per-slab GST split
+// from line gross grouped by rate. Zero-rated gross is never charged GST. Composite scheme
+// restaurants have zero tax and no slabs.
+// Exclusive: menu prices are before GST — tax is added per slab; discount reduces final total after tax.
+// Inclusive: menu prices include GST — discount is prorated across slabs, then each slab is split
+// into taxable value + GST.
+func CalculateRestaurantOrderTax(grossByRate map[float64]float64, discount float64, settings RestaurantTaxSettings) OrderTax {
+	rates := make([]float64, 0, len(grossByRate))
+	fullGross := 0.0
+	for rate, gross := range grossByRate {
+		if gross <= 0 {
+			continue
+		}
+		rates = append(rates, rate)
+		fullGross += gross
 	}
+	sort.Float64s(rates)
+
 	if discount < 0 {
 		discount = 0
 	}
-
-	fullGross := taxableGross + nonTaxableGross
 	if discount > fullGross {
 		discount = fullGross
 	}
 
+	var out OrderTax
 	if settings.CompositeScheme {
-		total = fullGross - discount
-		if total < 0 {
-			total = 0
-		}
-		return total, 0, total
+		out.Total = math.Max(fullGross-discount, 0)
+		out.SubTotal = out.Total
+		return out
 	}
 
 	if settings.PricesIncludeGST {
-		ratio := 0.0
-		if fullGross > 0 {
-			ratio = taxableGross / fullGross
-		}
-		discountedTaxable := taxableGross - discount*ratio
-		discountedNonTaxable := nonTaxableGross - discount*(1-ratio)
-		if discountedTaxable < 0 {
-			discountedTaxable = 0
-		}
-		if discountedNonTaxable < 0 {
-			discountedNonTaxable = 0
+		for _, rate := range rates {
+			gross := grossByRate[rate]
+			discounted := math.Max(gross-discount*(gross/fullGross), 0)
+			taxableValue := discounted / (1 + rate/100)
+			out.SubTotal += taxableValue
+			if rate <= 0 {
+				continue
+			}
+			tax := discounted - taxableValue
+			out.TaxAmount += tax
+			out.Slabs = append(out.Slabs, GSTSlab{Rate: rate, TaxableValue: taxableValue, TaxAmount: tax})
 		}
-		taxableValue := discountedTaxable / (1 + OrderGSTRate)
-		taxAmount = discountedTaxable - taxableValue
-		subTotal = taxableValue + discountedNonTaxable
-		total = fullGross - discount
-		if total < 0 {
-			total = 0
-		}
-		return subTotal, taxAmount, total
+		out.Total = math.Max(fullGross-discount, 0)
+		return out
 	}
 
-	subTotal = taxableGross + nonTaxableGross
-	taxAmount = taxableGross * OrderGSTRate
-	total = subTotal + taxAmount - discount
-	if total < 0 {
-		total = 0
+	for _, rate := range rates {
+		gross := grossByRate[rate]
+		out.SubTotal += gross
+		if rate <= 0 {
+			continue
+		}
+		tax := gross * rate / 100
+		out.TaxAmount += tax
+		out.Slabs = append(out.Slabs, GSTSlab{Rate: rate, TaxableValue: gross, TaxAmount: tax})
 	}
-	return subTotal, taxAmount, total
+	out.Total = math.Max(out.SubTotal+out.TaxAmount-discount, 0)
+	return out
 }
 
-// CalculateOrderTax derives sub_total (excl. GST), tax_amount, and total from menu line gross.
-// Exclusive: menu prices are before GST — tax is added on top; discount reduces final total after tax.
-// Inclusive: menu prices include GST — bill shows taxable value + GST split; discount applies to inclusive gross.
+// CalculateOrderTax derives sub_total (excl. GST), tax_amount, and total from menu line gross
+// taxed entirely at the default slab.
 func CalculateOrderTax(grossAmount float64, discount float64, pricesIncludeGST bool) (subTotal float64, taxAmount float64, total float64) {
-	return CalculateRestaurantOrderTax(grossAmount, 0, discount, RestaurantTaxSettings{
+	tax := CalculateRestaurantOrderTax(map[float64]float64{DefaultGSTRate: grossAmount}, discount, RestaurantTaxSettings{
 		PricesIncludeGST: pricesIncludeGST,
 	})
+	return tax.SubTotal, tax.TaxAmount, tax.Total
 }
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+func TestCalculateRestaurantOrderTaxExclusiveSlabs(t *testing.T) {
+	got := CalculateRestaurantOrderTax(map[float64]float64{5: 200, 18: 100, 0: 20}, 10, RestaurantTaxSettings{})
+	if !approxEqual(got.SubTotal, 320) || !approxEqual(got.TaxAmount, 28) || !approxEqual(got.Total, 338) {
+		t.Fatalf("unexpected totals %+v", got)
+	}
+	if len(got.Slabs) != 2 {
+		t.Fatalf("expected 2 slabs, got %+v", got.Slabs)
+	}
+	if got.Slabs[0].Rate != 5 || !approxEqual(got.Slabs[0].TaxAmount, 10) || !approxEqual(got.Slabs[0].TaxableValue, 200) {
+		t.Fatalf("unexpected 5%% slab %+v", got.Slabs[0])
+	}
+	if got.Slabs[1].Rate != 18 || !approxEqual(got.Slabs[1].TaxAmount, 18) {
+		t.Fatalf("unexpected 18%% slab %+v", got.Slabs[1])
+	}
+}
+
+func TestCalculateRestaurantOrderTaxInclusiveProratesDiscount(t *testing.T) {
+	// 210 at 5% and 118 at 18%, 10% off the bill.
+	got := CalculateRestaurantOrderTax(map[float64]float64{5: 210, 18: 118}, 32.8, RestaurantTaxSettings{PricesIncludeGST: true})
+	if !approxEqual(got.Total, 295.2) {
+		t.Fatalf("total = %.2f", got.Total)
+	}
+	if len(got.Slabs) != 2 {
+		t.Fatalf("expected 2 slabs, got %+v", got.Slabs)
+	}
+	if !approxEqual(got.Slabs[0].TaxableValue, 180) || !approxEqual(got.Slabs[0].TaxAmount, 9) {
+		t.Fatalf("unexpected 5%% slab %+v", got.Slabs[0])
+	}
+	if !approxEqual(got.Slabs[1].TaxableValue, 90) || !approxEqual(got.Slabs[1].TaxAmount, 16.2) {
+		t.Fatalf("unexpected 18%% slab %+v", got.Slabs[1])
+	}
+	if !approxEqual(got.SubTotal+got.TaxAmount, got.Total) {
+		t.Fatalf("sub_total + tax should equal total: %+v", got)
+	}
+}
+
+func TestCalculateRestaurantOrderTaxCompositeHasNoSlabs(t *testing.T) {
+	got := CalculateRestaurantOrderTax(map[float64]float64{5: 100, 18: 100}, 50, RestaurantTaxSettings{CompositeScheme: true})
+	if got.TaxAmount != 0 || len(got.Slabs) != 0 || !approxEqual(got.Total, 150) {
+		t.Fatalf("unexpected composite result %+v", got)
+	}
+}
+
+func TestOrderItemGSTRate(t *testing.T) {
+	eighteen := 18.0
+	twelve := 12.0
+	item := &models.MenuItem{IsTaxable: true, GSTRate: 18}
+	if r := MenuItemGSTRate(item, nil); r != 18 {
+		t.Fatalf("item rate = %v", r)
+	}
+	if r := MenuItemGSTRate(item, &models.MenuItemVariant{GSTRate: &twelve}); r != 12 {
+		t.Fatalf("variant override = %v", r)
+	}
+	if r := MenuItemGSTRate(&models.MenuItem{IsTaxable: false, GSTRate: 18}, nil); r != 0 {
+		t.Fatalf("non-taxable rate = %v", r)
+	}
+	snap := models.OrderItem{TaxRate: &eighteen, MenuItem: &models.MenuItem{IsTaxable: true, GSTRate: 5}}
+	if r := OrderItemGSTRate(snap); r != 18 {
+		t.Fatalf("snapshotted rate = %v", r)
+	}
+	if _, err := NormalizeGSTRate(15); err == nil {
+		t.Fatal("expected 15% to be rejected")
+	}
+}
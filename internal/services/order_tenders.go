@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Tender methods accepted at checkout. "split" is not a tender: it is the order-level
+// payment_method recorded when more than one method settles the bill.
+const (
+	TenderCash   = "cash"
+	TenderUPI    = "upi"
+	TenderCard   = "card"
+	TenderWallet = "wallet"
+)
+
+var tenderMethods = map[string]bool{
+	TenderCash:   true,
+	TenderUPI:    true,
+	TenderCard:   true,
+	TenderWallet: true,
+}
+
+// tenderTolerance absorbs paise rounding between the tender sum and the order total.
+const tenderTolerance = 0.02
+
+// PaymentTender is one tender submitted at checkout.
+type PaymentTender struct {
+	Method    string  `json:"method"`
+	Provider  string  `json:"provider,omitempty"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference,omitempty"`
+}
+
+// IsTenderMethod reports whether method is a known tender (cash, upi, card, wallet).
+func IsTenderMethod(method string) bool {
+	return tenderMethods[strings.ToLower(strings.TrimSpace(method))]
+}
+
+// legacyPaymentTenders derives tenders from the pre-tender checkout fields, so clients
+// that still send payment_method + cash_amount/upi_amount keep working.
+func legacyPaymentTenders(payment OrderPaymentDetails, total float64) []PaymentTender {
+	method := strings.ToLower(strings.TrimSpace(payment.PaymentMethod))
+	if method == "split" {
+		var tenders []PaymentTender
+		if payment.CashAmount > 0 {
+			tenders = append(tenders, PaymentTender{Method: TenderCash, Amount: payment.CashAmount})
+		}
+		if payment.UpiAmount > 0 {
+			tenders = append(tenders, PaymentTender{Method: TenderUPI, Amount: payment.UpiAmount, Reference: payment.UpiTransactionID})
+		}
+		return tenders
+	}
+	if total <= 0 {
+		return nil
+	}
+	tender := PaymentTender{Method: method, Amount: total, Reference: payment.Reference, Provider: payment.Provider}
+	if method == TenderUPI && strings.TrimSpace(tender.Reference) == "" {
+		tender.Reference = payment.UpiTransactionID
+	}
+	return []PaymentTender{tender}
+}
+
+// ResolvePaymentTenders returns the normalized tenders settling an order of the given
+// total. Explicit tenders win; otherwise they are derived from the legacy fields. The
+// tender amounts must add up to the total, and card and wallet tenders need a reference
+// (terminal approval code or wallet transaction ID).
+func ResolvePaymentTenders(payment OrderPaymentDetails, total float64) ([]PaymentTender, error) {
+	source := payment.Tenders
+	legacy := len(source) == 0
+	if legacy {
+		method := strings.ToLower(strings.TrimSpace(payment.PaymentMethod))
+		if method == "split" {
+			if payment.CashAmount <= 0 || payment.UpiAmount <= 0 {
+				return nil, errors.New("split payment requires cash_amount and upi_amount greater than zero")
+			}
+		} else if !IsTenderMethod(method) {
+			return nil, fmt.Errorf("unsupported payment method %q", payment.PaymentMethod)
+		}
+		source = legacyPaymentTenders(payment, total)
+	}
+
+	tenders := make([]PaymentTender, 0, len(source))
+	sum := 0.0
+	for i, t := range source {
+		t.Method = strings.ToLower(strings.TrimSpace(t.Method))
+		t.Provider = strings.ToLower(strings.TrimSpace(t.Provider))
+		t.Reference = strings.TrimSpace(t.Reference)
+		t.Amount = roundPaise(t.Amount)
+		if !IsTenderMethod(t.Method) {
+			return nil, fmt.Errorf("tender %d: method must be cash, upi, card or wallet", i+1)
+		}
+		if t.Amount <= 0 {
+			return nil, fmt.Errorf("tender %d: amount must be greater than zero", i+1)
+		}
+		if len(t.Reference) > 120 {
+			return nil, fmt.Errorf("tender %d: reference is too long", i+1)
+		}
+		if (t.Method == TenderCard || t.Method == TenderWallet) && t.Reference == "" {
+			return nil, fmt.Errorf("tender %d: %s payments require a reference or approval code", i+1, t.Method)
+		}
+		sum += t.Amount
+		tenders = append(tenders, t)
+	}
+
+	if math.Abs(sum-total) > tenderTolerance {
+		if legacy && strings.EqualFold(payment.PaymentMethod, "split") {
+			return nil, fmt.Errorf("cash and upi amounts must equal order total (%.2f)", total)
+		}
+		return nil, fmt.Errorf("tender amounts (%.2f) must equal order total (%.2f)", sum, total)
+	}
+	return tenders, nil
+}
+
+// TenderPaymentColumns rolls tenders up into the order's payment columns: the method is
+// the single method used (or "split" for several), cash/UPI amounts are per-method sums
+// and the UPI transaction ID is the first UPI reference.
+func TenderPaymentColumns(tenders []PaymentTender) (method string, cashAmount, upiAmount float64, upiTransactionID string) {
+	for _, t := range tenders {
+		if method == "" {
+			method = t.Method
+		} else if method != t.Method {
+			method = "split"
+		}
+		switch t.Method {
+		case TenderCash:
+			cashAmount += t.Amount
+		case TenderUPI:
+			upiAmount += t.Amount
+			if upiTransactionID == "" {
+				upiTransactionID = t.Reference
+			}
+		}
+	}
+	return method, roundPaise(cashAmount), roundPaise(upiAmount), upiTransactionID
+}
+
+// replaceOrderTenders swaps the stored tenders for an order inside tx.
+func replaceOrderTenders(tx *gorm.DB, order *models.Order, tenders []PaymentTender) ([]models.OrderTender, error) {
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderTender{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.OrderTender, 0, len(tenders))
+	for _, t := range tenders {
+		rows = append(rows, models.OrderTender{
+			OrderID:      order.ID,
+			RestaurantID: order.RestaurantID,
+			Method:       t.Method,
+			Provider:     t.Provider,
+			Amount:       t.Amount,
+			Reference:    t.Reference,
+		})
+	}
+	if len(rows) > 0 {
+		if err := tx.Create(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// TenderLabel is the display name of a tender on bills and reports, e.g. "Card (visa)".
+func TenderLabel(method, provider string) string {
+	var label string
+	switch strings.ToLower(method) {
+	case TenderCash:
+		label = "Cash"
+	case TenderUPI:
+		label = "UPI"
+	case TenderCard:
+		label = "Card"
+	case TenderWallet:
+		label = "Wallet"
+	default:
+		label = strings.ToUpper(method)
+	}
+	if provider = strings.TrimSpace(provider); provider != "" {
+		label += " (" + provider + ")"
+	}
+	return label
+}
+
+// TenderTotal is money taken by one method/provider in a sales period.
+type TenderTotal struct {
+	Method   string  `json:"method"`
+	Provider string  `json:"provider,omitempty"`
+	Label    string  `json:"label"`
+	Count    int64   `json:"count"`
+	Amount   float64 `json:"amount"`
+}
+
+// salesTenderTotals sums order_tenders for the orders selected by orderIDs, largest first.
+func (s *OrderService) salesTenderTotals(orderIDs *gorm.DB) ([]TenderTotal, error) {
+	var rows []TenderTotal
+	if err := s.db.Model(&models.OrderTender{}).
+		Select("method, COALESCE(provider, '') AS provider, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("order_id IN (?)", orderIDs).
+		Group("method, COALESCE(provider, '')").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []TenderTotal{}
+	}
+	for i := range rows {
+		rows[i].Label = TenderLabel(rows[i].Method, rows[i].Provider)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Amount != rows[j].Amount {
+			return rows[i].Amount > rows[j].Amount
+		}
+		return rows[i].Label < rows[j].Label
+	})
+	return rows, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestResolvePaymentTenders_MixedTenders(t *testing.T) {
+	tenders, err := ResolvePaymentTenders(OrderPaymentDetails{Tenders: []PaymentTender{
+		{Method: "Card", Provider: "Visa", Amount: 600, Reference: " 123456 "},
+		{Method: "wallet", Provider: "paytm", Amount: 300, Reference: "PTM998"},
+		{Method: "cash", Amount: 100.01},
+	}}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenders) != 3 || tenders[0].Method != "card" || tenders[0].Provider != "visa" || tenders[0].Reference != "123456" {
+		t.Fatalf("got %+v", tenders)
+	}
+	method, cash, upi, upiRef := TenderPaymentColumns(tenders)
+	if method != "split" || cash != 100.01 || upi != 0 || upiRef != "" {
+		t.Fatalf("columns: %s %.2f %.2f %q", method, cash, upi, upiRef)
+	}
+}
+
+func TestResolvePaymentTenders_Rejects(t *testing.T) {
+	cases := map[string]OrderPaymentDetails{
+		"card without reference": {Tenders: []PaymentTender{{Method: "card", Amount: 500}}},
+		"short total":            {Tenders: []PaymentTender{{Method: "cash", Amount: 400}}},
+		"unknown method":         {Tenders: []PaymentTender{{Method: "cheque", Amount: 500, Reference: "1"}}},
+		"zero amount":            {Tenders: []PaymentTender{{Method: "cash", Amount: 500}, {Method: "upi", Amount: 0}}},
+		"legacy wallet":          {PaymentMethod: "wallet"},
+		"legacy split":           {PaymentMethod: "split", CashAmount: 500},
+	}
+	for name, payment := range cases {
+		if _, err := ResolvePaymentTenders(payment, 500); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestResolvePaymentTenders_LegacyFields(t *testing.T) {
+	tenders, err := ResolvePaymentTenders(OrderPaymentDetails{
+		PaymentMethod: "split", CashAmount: 200, UpiAmount: 300, UpiTransactionID: "UPI1",
+	}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenders) != 2 || tenders[1].Method != "upi" || tenders[1].Reference != "UPI1" {
+		t.Fatalf("split: %+v", tenders)
+	}
+
+	tenders, err = ResolvePaymentTenders(OrderPaymentDetails{PaymentMethod: "cash", AmountReceived: 500}, 420)
+	if err != nil || len(tenders) != 1 || tenders[0].Amount != 420 {
+		t.Fatalf("cash: %+v %v", tenders, err)
+	}
+
+	tenders, err = ResolvePaymentTenders(OrderPaymentDetails{PaymentMethod: "card", Reference: "A1B2"}, 250)
+	if err != nil || len(tenders) != 1 || tenders[0].Reference != "A1B2" {
+		t.Fatalf("card: %+v %v", tenders, err)
+	}
+	method, _, _, _ := TenderPaymentColumns(tenders)
+	if method != "card" {
+		t.Fatalf("method %q", method)
+	}
+}
+
+func TestPaymentLedgerLegs_UsesTenderRows(t *testing.T) {
+	order := &models.Order{
+		ID: "o1", RestaurantID: "r1", PaymentMethod: "split", Total: 500,
+		Tenders: []models.OrderTender{
+			{Method: "card", Amount: 350, Reference: "APP77"},
+			{Method: "wallet", Provider: "phonepe", Amount: 150, Reference: "PP1"},
+		},
+	}
+	legs := PaymentLedgerLegs(order, "u1")
+	if len(legs) != 2 || legs[0].PaymentMethod != "card" || legs[0].PaymentID != "APP77" || legs[1].Amount != 150 {
+		t.Fatalf("got %+v", legs)
+	}
+}
+
+func TestPrintBillTenders_ShowsReference(t *testing.T) {
+	out := printBillTenders([]models.OrderTender{{Method: "wallet", Provider: "paytm", Amount: 99.5, Reference: "PTM1"}}, 32)
+	if !strings.Contains(out, "Wallet (paytm)") || !strings.Contains(out, "99.50") || !strings.Contains(out, "Ref: PTM1") {
+		t.Fatalf("got %q", out)
+	}
+}
@@ -128,6 +128,7 @@ func (s *OrderService) GetOrderByTrackingToken(token string) (*models.Order, *mo
 	var order models.Order
 	err := s.db.Preload("Items.MenuItem").
 		Preload("AttendedBy").
+		Preload("Tenders").
 		Where("tracking_token = ?", token).
 		First(&order).Error
 	if err != nil {
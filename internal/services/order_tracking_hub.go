@@ -1,11 +1,16 @@
 package services
 
-import "sync"
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
 
 // OrderTrackingHub fans out readiness updates to customer SSE subscribers per tracking token.
 type OrderTrackingHub struct {
-	mu   sync.RWMutex
-	subs map[string]map[chan TrackingStatus]struct{}
+	mu     sync.RWMutex
+	subs   map[string]map[chan TrackingStatus]struct{}
+	broker HubBroker
 }
 
 func NewOrderTrackingHub() *OrderTrackingHub {
@@ -14,6 +19,21 @@ func NewOrderTrackingHub() *OrderTrackingHub {
 	}
 }
 
+// UseBroker publishes through broker so tracking pages attached to other instances get updates too.
+func (h *OrderTrackingHub) UseBroker(broker HubBroker) {
+	broker.Subscribe(hubTopicTracking, func(token string, payload []byte) {
+		var status TrackingStatus
+		if err := json.Unmarshal(payload, &status); err != nil {
+			log.Printf("⚠️  tracking hub message parse error: %v", err)
+			return
+		}
+		h.deliver(token, status)
+	})
+	h.mu.Lock()
+	h.broker = broker
+	h.mu.Unlock()
+}
+
 func (h *OrderTrackingHub) Subscribe(token string) chan TrackingStatus {
 	ch := make(chan TrackingStatus, 4)
 	h.mu.Lock()
@@ -38,6 +58,21 @@ func (h *OrderTrackingHub) Unsubscribe(token string, ch chan TrackingStatus) {
 }
 
 func (h *OrderTrackingHub) Publish(token string, status TrackingStatus) {
+	h.mu.RLock()
+	broker := h.broker
+	h.mu.RUnlock()
+	if broker != nil {
+		payload, err := json.Marshal(status)
+		if err == nil {
+			broker.Publish(hubTopicTracking, token, payload)
+			return
+		}
+		log.Printf("⚠️  tracking hub marshal error: %v", err)
+	}
+	h.deliver(token, status)
+}
+
+func (h *OrderTrackingHub) deliver(token string, status TrackingStatus) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for ch := range h.subs[token] {
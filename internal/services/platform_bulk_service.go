@@ -42,6 +42,7 @@ type BulkMenuVariantRow struct {
 	IsDefault     bool               `json:"is_default"`
 	IsAvailable   *bool              `json:"is_available,omitempty"`
 	ChannelPrices map[string]float64 `json:"channel_prices,omitempty"`
+	GSTRate       *float64           `json:"gst_rate,omitempty"`
 }
 
 type BulkMenuUploadRow struct {
@@ -52,6 +53,8 @@ type BulkMenuUploadRow struct {
 	IsAvailable        bool                 `json:"is_available"`
 	IsReadilyAvailable bool                 `json:"is_readily_available"`
 	IsTaxable          *bool                `json:"is_taxable"`
+	GSTRate            *float64             `json:"gst_rate"`
+	HSNCode            *string              `json:"hsn_code"`
 	AvailableChannels  []string             `json:"available_channels"`
 	ChannelPrices      map[string]float64   `json:"channel_prices"`
 	Variants           []BulkMenuVariantRow `json:"variants"`
@@ -147,6 +150,26 @@ func (s *PlatformOpsService) BulkUploadMenu(restaurantID string, req BulkMenuUpl
 		if row.IsTaxable != nil {
 			isTaxable = *row.IsTaxable
 		}
+		gstRate := DefaultGSTRate
+		if row.GSTRate != nil {
+			rate, rateErr := NormalizeGSTRate(*row.GSTRate)
+			if rateErr != nil {
+				result.Errors = append(result.Errors, BulkRowError{Row: rowNum, Field: "gst_rate", Message: rateErr.Error()})
+				result.Skipped++
+				continue
+			}
+			gstRate = rate
+		}
+		hsnCode := ""
+		if row.HSNCode != nil {
+			code, codeErr := NormalizeHSNCode(*row.HSNCode)
+			if codeErr != nil {
+				result.Errors = append(result.Errors, BulkRowError{Row: rowNum, Field: "hsn_code", Message: codeErr.Error()})
+				result.Skipped++
+				continue
+			}
+			hsnCode = code
+		}
 		variantInputs, verr := bulkVariantInputs(row.Variants, price)
 		if verr != nil {
 			result.Errors = append(result.Errors, BulkRowError{Row: rowNum, Field: "variants", Message: verr.Error()})
@@ -171,6 +194,8 @@ func (s *PlatformOpsService) BulkUploadMenu(restaurantID string, req BulkMenuUpl
 				IsAvailable:       row.IsAvailable,
 				ReadilyAvailable:  row.IsReadilyAvailable,
 				IsTaxable:         isTaxable,
+				GSTRate:           gstRate,
+				HSNCode:           hsnCode,
 				AvailableChannels: channels,
 				ChannelPrices:     channelPrices,
 			}
@@ -197,6 +222,12 @@ func (s *PlatformOpsService) BulkUploadMenu(restaurantID string, req BulkMenuUpl
 		if row.IsTaxable != nil {
 			existing.IsTaxable = *row.IsTaxable
 		}
+		if row.GSTRate != nil {
+			existing.GSTRate = gstRate
+		}
+		if row.HSNCode != nil {
+			existing.HSNCode = hsnCode
+		}
 		existing.AvailableChannels = channels
 		existing.ChannelPrices = channelPrices
 		if err := s.db.Save(&existing).Error; err != nil {
@@ -248,6 +279,7 @@ func bulkVariantInputs(rows []BulkMenuVariantRow, basePrice float64) ([]VariantI
 			IsDefault:     row.IsDefault,
 			IsAvailable:   row.IsAvailable,
 			SortOrder:     i,
+			GSTRate:       row.GSTRate,
 		})
 	}
 	hasDefault := false
@@ -1035,8 +1035,9 @@ func SubscriptionPriceWithGST(subtotal int) int {
 // monthOrderStats aggregates this calendar month's billed orders (dine-in and counter
 // combined) using the same filter as tenant sales reports; revenue includes GST.
 func (s *PlatformOpsService) monthOrderStats(restaurantID string) (int64, float64) {
-	now := time.Now().In(RestaurantLocation())
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	cal := LoadBusinessCalendar(s.db, restaurantID)
+	today := cal.BusinessDate(time.Now())
+	monthStart := cal.DayStart(today.Year(), today.Month(), 1)
 
 	var result struct {
 		MonthOrders  int64
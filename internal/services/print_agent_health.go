@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultPrintAgentOfflineAfter = 90 * time.Second
+
+// PrintAgentOfflineAfter is how long an agent may go without a heartbeat before it is
+// shown offline (PRINT_AGENT_OFFLINE_SECONDS overrides the 90s default; agents beat every 30s).
+func PrintAgentOfflineAfter() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("PRINT_AGENT_OFFLINE_SECONDS")); err == nil && v >= 30 {
+		return time.Duration(v) * time.Second
+	}
+	return defaultPrintAgentOfflineAfter
+}
+
+// PrintAgentPrinterStatus is one printer the agent probed during a heartbeat.
+type PrintAgentPrinterStatus struct {
+	Target    string `json:"target"`
+	StationID string `json:"station_id,omitempty"`
+	Host      string `json:"host"`
+	Port      int    `json:"port,omitempty"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PrintAgentHeartbeatInput is posted by the agent every heartbeat interval.
+type PrintAgentHeartbeatInput struct {
+	AgentID       string                    `json:"agent_id"`
+	Version       string                    `json:"version"`
+	UptimeSeconds int64                     `json:"uptime_seconds"`
+	Printers      []PrintAgentPrinterStatus `json:"printers"`
+	SpoolURL      string                    `json:"spool_url"`     // LAN spool address, "" when disabled
+	SpoolPending  int                       `json:"spool_pending"` // offline prints waiting to sync
+}
+
+// PrintAgentProbeTarget is a configured printer the agent should check on its next heartbeat.
+type PrintAgentProbeTarget struct {
+	Target    string `json:"target"`
+	StationID string `json:"station_id,omitempty"`
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+}
+
+// OnAgentHealthChanged registers a callback for a restaurant's agents going away (the last
+// online agent missed its heartbeats while KOT printing is on) or coming back.
+func (s *PrintService) OnAgentHealthChanged(fn func(restaurantID string, online bool, agents []models.PrintAgentStatus)) {
+	s.onAgents = fn
+}
+
+func (s *PrintService) agentHealthChanged(restaurantID string, online bool) {
+	if s == nil || s.onAgents == nil {
+		return
+	}
+	agents, err := s.ListAgents(restaurantID)
+	if err != nil {
+		log.Printf("print agents: list for %s failed: %v", restaurantID, err)
+		return
+	}
+	s.onAgents(restaurantID, online, agents)
+}
+
+// agentIsOnline reports whether an agent's last heartbeat is recent enough.
+func agentIsOnline(agent models.PrintAgentStatus, now time.Time, offlineAfter time.Duration) bool {
+	return agent.Online && !agent.LastSeenAt.IsZero() && now.Sub(agent.LastSeenAt) <= offlineAfter
+}
+
+// RecordHeartbeat stores the agent's last-seen state. When no agent of the restaurant was
+// online before, the health callback fires so dashboards can clear an offline banner.
+func (s *PrintService) RecordHeartbeat(restaurantID, remoteIP string, input PrintAgentHeartbeatInput) (*models.PrintAgentStatus, error) {
+	agentID := strings.TrimSpace(input.AgentID)
+	if agentID == "" {
+		agentID = remoteIP
+	}
+	if agentID == "" {
+		return nil, errors.New("agent_id is required")
+	}
+	if len(agentID) > 120 {
+		agentID = agentID[:120]
+	}
+	version := strings.TrimSpace(input.Version)
+	if len(version) > 40 {
+		version = version[:40]
+	}
+	spoolURL := strings.TrimSpace(input.SpoolURL)
+	if len(spoolURL) > 255 {
+		spoolURL = ""
+	}
+	printers := input.Printers
+	if len(printers) > 32 {
+		printers = printers[:32]
+	}
+	if printers == nil {
+		printers = []PrintAgentPrinterStatus{}
+	}
+	printersJSON, err := json.Marshal(printers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	offlineAfter := PrintAgentOfflineAfter()
+	wasOnline, err := s.anyAgentOnline(restaurantID, now, offlineAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	agent := models.PrintAgentStatus{
+		RestaurantID:  restaurantID,
+		AgentID:       agentID,
+		Version:       version,
+		UptimeSeconds: input.UptimeSeconds,
+		Printers:      printersJSON,
+		RemoteIP:      remoteIP,
+		SpoolURL:      spoolURL,
+		SpoolPending:  input.SpoolPending,
+		Online:        true,
+		LastSeenAt:    now,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "restaurant_id"}, {Name: "agent_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":         version,
+			"uptime_seconds":  input.UptimeSeconds,
+			"printers":        printersJSON,
+			"remote_ip":       remoteIP,
+			"spool_url":       spoolURL,
+			"spool_pending":   input.SpoolPending,
+			"online":          true,
+			"last_seen_at":    now,
+			"went_offline_at": nil,
+			"updated_at":      now,
+		}),
+	}).Create(&agent).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("restaurant_id = ? AND agent_id = ?", restaurantID, agentID).First(&agent).Error; err != nil {
+		return nil, err
+	}
+	if !wasOnline {
+		log.Printf("print agent %s online for restaurant %s (v%s)", agentID, restaurantID, version)
+		s.agentHealthChanged(restaurantID, true)
+	}
+	return &agent, nil
+}
+
+func (s *PrintService) anyAgentOnline(restaurantID string, now time.Time, offlineAfter time.Duration) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.PrintAgentStatus{}).
+		Where("restaurant_id = ? AND online = ? AND last_seen_at >= ?", restaurantID, true, now.Add(-offlineAfter)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListAgents returns the restaurant's known agents, most recently seen first. Online is
+// recomputed from the last heartbeat so a dead agent never shows online between sweeps.
+func (s *PrintService) ListAgents(restaurantID string) ([]models.PrintAgentStatus, error) {
+	var agents []models.PrintAgentStatus
+	if err := s.db.Where("restaurant_id = ?", restaurantID).
+		Order("last_seen_at DESC").
+		Limit(20).
+		Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	offlineAfter := PrintAgentOfflineAfter()
+	for i := range agents {
+		agents[i].Online = agentIsOnline(agents[i], now, offlineAfter)
+	}
+	return agents, nil
+}
+
+// AnyAgentOnline is true when at least one agent in the list is online.
+func AnyAgentOnline(agents []models.PrintAgentStatus) bool {
+	for _, agent := range agents {
+		if agent.Online {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeTargets lists the printers an agent should check: the bill and KOT printers from
+// settings plus every active kitchen station, one entry per host/port.
+func (s *PrintService) ProbeTargets(restaurantID string) ([]PrintAgentProbeTarget, error) {
+	settings, err := s.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	stations, err := stationsForRestaurant(s.db, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return printerProbeTargets(settings, stations), nil
+}
+
+func printerProbeTargets(settings *models.RestaurantPrintSettings, stations []models.KitchenStation) []PrintAgentProbeTarget {
+	targets := []PrintAgentProbeTarget{}
+	seen := map[string]bool{}
+	add := func(t PrintAgentProbeTarget) {
+		t.Host = strings.TrimSpace(t.Host)
+		if t.Host == "" {
+			return
+		}
+		t.Port = normalizePrinterPort(t.Port)
+		key := strings.ToLower(fmt.Sprintf("%s:%d", t.Host, t.Port))
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		targets = append(targets, t)
+	}
+	if settings != nil {
+		add(PrintAgentProbeTarget{Target: PrintTargetKOT, Name: "KOT", Host: settings.KotPrinterHost, Port: settings.KotPrinterPort})
+		add(PrintAgentProbeTarget{Target: PrintTargetBill, Name: "Bill", Host: settings.BillPrinterHost, Port: settings.BillPrinterPort})
+	}
+	for _, station := range stations {
+		if !station.IsActive {
+			continue
+		}
+		add(PrintAgentProbeTarget{Target: PrintTargetKOT, StationID: station.ID, Name: station.Name, Host: station.PrinterHost, Port: station.PrinterPort})
+	}
+	return targets
+}
+
+// MarkStaleAgentsOffline flips agents that missed their heartbeats to offline. For each
+// restaurant left with no online agent while KOT printing is enabled, the health callback
+// fires once. Returns the restaurants that lost their last agent.
+func (s *PrintService) MarkStaleAgentsOffline() ([]string, error) {
+	now := time.Now().UTC()
+	cutoff := now.Add(-PrintAgentOfflineAfter())
+
+	var stale []models.PrintAgentStatus
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("online = ? AND last_seen_at < ?", true, cutoff).
+			Limit(200).
+			Find(&stale).Error; err != nil {
+			return err
+		}
+		for _, agent := range stale {
+			if err := tx.Model(&agent).Updates(map[string]interface{}{
+				"online":          false,
+				"went_offline_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var lost []string
+	for _, agent := range stale {
+		if seen[agent.RestaurantID] {
+			continue
+		}
+		seen[agent.RestaurantID] = true
+		log.Printf("print agent %s offline for restaurant %s (last seen %s)", agent.AgentID, agent.RestaurantID, agent.LastSeenAt.Format(time.RFC3339))
+		online, err := s.anyAgentOnline(agent.RestaurantID, now, PrintAgentOfflineAfter())
+		if err != nil || online {
+			continue
+		}
+		var settings models.RestaurantPrintSettings
+		if err := s.db.Select("restaurant_id", "kot_printing_enabled").
+			Where("restaurant_id = ?", agent.RestaurantID).
+			First(&settings).Error; err != nil || !settings.KotPrintingEnabled {
+			continue
+		}
+		lost = append(lost, agent.RestaurantID)
+		s.agentHealthChanged(agent.RestaurantID, false)
+	}
+	return lost, nil
+}
+
+// StartAgentHealthSweeper periodically marks silent agents offline so the kitchen hears
+// about a dead print agent before KOTs pile up.
+func (s *PrintService) StartAgentHealthSweeper(ctx context.Context) {
+	ticker := time.NewTicker(PrintAgentOfflineAfter() / 3)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.MarkStaleAgentsOffline(); err != nil {
+					log.Printf("print agent health sweep failed: %v", err)
+				}
+			}
+		}
+	}()
+}
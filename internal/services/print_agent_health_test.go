@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"restaurant-api/internal/models"
+)
+
+func TestAgentIsOnline(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := 90 * time.Second
+	cases := []struct {
+		name  string
+		agent models.PrintAgentStatus
+		want  bool
+	}{
+		{"recent heartbeat", models.PrintAgentStatus{Online: true, LastSeenAt: now.Add(-30 * time.Second)}, true},
+		{"missed heartbeats", models.PrintAgentStatus{Online: true, LastSeenAt: now.Add(-2 * time.Minute)}, false},
+		{"swept offline", models.PrintAgentStatus{Online: false, LastSeenAt: now.Add(-10 * time.Second)}, false},
+		{"never seen", models.PrintAgentStatus{Online: true}, false},
+	}
+	for _, tc := range cases {
+		if got := agentIsOnline(tc.agent, now, window); got != tc.want {
+			t.Errorf("%s: got %v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPrinterProbeTargets_DedupesSharedPrinters(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{
+		KotPrinterHost:  "10.0.0.5",
+		KotPrinterPort:  9100,
+		BillPrinterHost: "10.0.0.5",
+		BillPrinterPort: 9100,
+	}
+	stations := []models.KitchenStation{
+		{ID: "bar", Name: "Bar", PrinterHost: "COM5", IsActive: true},
+		{ID: "grill", Name: "Grill", IsActive: true},
+		{ID: "old", Name: "Old", PrinterHost: "10.0.0.8", IsActive: false},
+	}
+	targets := printerProbeTargets(settings, stations)
+	if len(targets) != 2 {
+		t.Fatalf("expected KOT + bar, got %+v", targets)
+	}
+	if targets[0].Target != PrintTargetKOT || targets[0].Host != "10.0.0.5" {
+		t.Fatalf("main KOT printer: %+v", targets[0])
+	}
+	if targets[1].StationID != "bar" || targets[1].Port != 9100 {
+		t.Fatalf("bar station: %+v", targets[1])
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"restaurant-api/internal/models"
+)
+
+func TestBillTakesCash(t *testing.T) {
+	cases := []struct {
+		name  string
+		order models.Order
+		want  bool
+	}{
+		{"cash paid", models.Order{Status: "completed", PaymentMethod: "cash"}, true},
+		{"upi paid", models.Order{Status: "completed", PaymentMethod: "upi"}, false},
+		{"pre-payment bill", models.Order{Status: "confirmed", PaymentMethod: "cash"}, false},
+		{"legacy split with cash", models.Order{Status: "completed", PaymentMethod: "split", CashAmount: 200}, true},
+		{"split card + cash tenders", models.Order{Status: "completed", PaymentMethod: "split", Tenders: []models.OrderTender{
+			{Method: TenderCard, Amount: 300}, {Method: TenderCash, Amount: 50},
+		}}, true},
+		{"split card + upi tenders", models.Order{Status: "completed", PaymentMethod: "split", CashAmount: 0, Tenders: []models.OrderTender{
+			{Method: TenderCard, Amount: 300}, {Method: TenderUPI, Amount: 50},
+		}}, false},
+	}
+	for _, tc := range cases {
+		order := tc.order
+		if got := billTakesCash(&order); got != tc.want {
+			t.Errorf("%s: got %v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestKOTBuzzes(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{KotBuzzer: true}
+	if !kotBuzzes(settings, nil) {
+		t.Fatal("main KOT printer should buzz")
+	}
+	if kotBuzzes(settings, &models.KitchenStation{PrinterHost: "10.0.0.9"}) {
+		t.Fatal("station with its own quiet printer should not buzz")
+	}
+	if !kotBuzzes(settings, &models.KitchenStation{}) {
+		t.Fatal("station without a printer uses the main printer's buzzer")
+	}
+}
+
+func TestBillOpensDrawer_OnlyFirstPrint(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{BillOpenDrawer: true}
+	order := models.Order{Status: "completed", PaymentMethod: "cash"}
+	if !billOpensDrawer(settings, &order) {
+		t.Fatal("first bill for a cash settlement should open the drawer")
+	}
+	kicked := time.Now()
+	order.DrawerKickedAt = &kicked
+	if billOpensDrawer(settings, &order) {
+		t.Fatal("printing the bill again must not open the drawer")
+	}
+	if billOpensDrawer(&models.RestaurantPrintSettings{}, &models.Order{Status: "completed", PaymentMethod: "cash"}) {
+		t.Fatal("drawer kick is off in settings")
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxPrinterFailover = 5
+
+// printRoute is one printer in a job's failover chain.
+type printRoute struct {
+	Host   string
+	Port   int
+	Label  string // printed in the reroute note: "KOT printer", a station name or the host
+	Format printJobFormat
+}
+
+func kotPrintRoute(settings *models.RestaurantPrintSettings) printRoute {
+	host, port := resolvePrinter(settings, PrintTargetKOT)
+	return printRoute{Host: host, Port: port, Label: "KOT printer", Format: printJobFormat{
+		normalizePaperWidthMm(settings.KotPaperWidthMm), settings.KotPlainText, settings.KotRasterText,
+	}}
+}
+
+// billPrintRoute is the bill printer's own route; ok is false when bills share the KOT printer.
+func billPrintRoute(settings *models.RestaurantPrintSettings) (printRoute, bool) {
+	host := strings.TrimSpace(settings.BillPrinterHost)
+	if host == "" {
+		return printRoute{}, false
+	}
+	return printRoute{Host: host, Port: normalizePrinterPort(settings.BillPrinterPort), Label: "Bill printer", Format: printJobFormat{
+		normalizePaperWidthMm(settings.BillPaperWidthMm), settings.BillPlainText, settings.BillRasterText,
+	}}, true
+}
+
+// jobPrinterChain lists the printers a job may print on, in order: its configured printer
+// (station, bill or KOT, with the usual fallbacks) and then that printer's failover list.
+// Failover entries naming a target use that printer's own format; bare hosts inherit the
+// first printer's.
+func jobPrinterChain(settings *models.RestaurantPrintSettings, stations map[string]models.KitchenStation, job models.PrintJob) []printRoute {
+	var primary printRoute
+	var failover []models.PrinterEndpoint
+	station, hasStation := stations[job.StationID]
+	switch {
+	case job.StationID != "" && hasStation && strings.TrimSpace(station.PrinterHost) != "":
+		primary = printRoute{
+			Host:   strings.TrimSpace(station.PrinterHost),
+			Port:   normalizePrinterPort(station.PrinterPort),
+			Label:  station.Name,
+			Format: printJobFormat{normalizePaperWidthMm(station.PaperWidthMm), station.PlainText, station.RasterText},
+		}
+		failover = station.Failover
+	case job.Target == PrintTargetBill:
+		if route, ok := billPrintRoute(settings); ok {
+			primary, failover = route, settings.BillFailover
+		} else {
+			// Bills on the shared KOT printer keep the bill layout width but the KOT printer's abilities.
+			primary, failover = kotPrintRoute(settings), settings.KotFailover
+			primary.Format.PaperWidthMm = normalizePaperWidthMm(settings.BillPaperWidthMm)
+		}
+	default:
+		primary, failover = kotPrintRoute(settings), settings.KotFailover
+	}
+
+	// The configured printer always comes first, even without a host, so the job still
+	// fails with "printer host not configured" rather than jumping the queue to a failover.
+	chain := []printRoute{primary}
+	seen := map[string]bool{strings.ToLower(fmt.Sprintf("%s:%d", primary.Host, primary.Port)): true}
+	add := func(route printRoute) {
+		if route.Host == "" {
+			return
+		}
+		key := strings.ToLower(fmt.Sprintf("%s:%d", route.Host, route.Port))
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		chain = append(chain, route)
+	}
+	for _, ep := range failover {
+		switch ep.Target {
+		case PrintTargetKOT:
+			add(kotPrintRoute(settings))
+		case PrintTargetBill:
+			if route, ok := billPrintRoute(settings); ok {
+				add(route)
+			}
+		default:
+			host := strings.TrimSpace(ep.Host)
+			add(printRoute{Host: host, Port: normalizePrinterPort(ep.Port), Label: host, Format: primary.Format})
+		}
+	}
+	return chain
+}
+
+// jobPrintRoute is the printer the job is currently routed to. Its Host is "" when no
+// printer is configured.
+func jobPrintRoute(settings *models.RestaurantPrintSettings, stations map[string]models.KitchenStation, job models.PrintJob) printRoute {
+	chain := jobPrinterChain(settings, stations, job)
+	i := job.PrinterIndex
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(chain) {
+		// The failover list shrank since the reroute; stay on the last printer.
+		i = len(chain) - 1
+	}
+	return chain[i]
+}
+
+// normalizePrinterFailover validates a failover list from the settings or station API.
+func normalizePrinterFailover(list []models.PrinterEndpoint) ([]models.PrinterEndpoint, error) {
+	if len(list) > maxPrinterFailover {
+		return nil, fmt.Errorf("at most %d failover printers", maxPrinterFailover)
+	}
+	out := make([]models.PrinterEndpoint, 0, len(list))
+	for _, ep := range list {
+		switch ep.Target {
+		case PrintTargetKOT, PrintTargetBill:
+			out = append(out, models.PrinterEndpoint{Target: ep.Target})
+		case "":
+			host := strings.TrimSpace(ep.Host)
+			if host == "" || len(host) > 255 {
+				return nil, fmt.Errorf("failover printer needs a host (max 255 chars) or a target")
+			}
+			out = append(out, models.PrinterEndpoint{Host: host, Port: normalizePrinterPort(ep.Port)})
+		default:
+			return nil, fmt.Errorf("failover target must be %s or %s", PrintTargetKOT, PrintTargetBill)
+		}
+	}
+	return out, nil
+}
+
+// printerFailoverJSON encodes a validated list for a map update; gorm skips the json
+// serializer on map values.
+func printerFailoverJSON(list []models.PrinterEndpoint) string {
+	raw, _ := json.Marshal(list)
+	return string(raw)
+}
+
+// isPrinterUnreachable reports whether an agent's failure means it could not connect to the
+// printer at all, so nothing printed and the job is safe to send elsewhere. Agents from 1.9
+// say so explicitly; older ones are recognised by Go's dial error text.
+func isPrinterUnreachable(unreachable bool, errMsg string) bool {
+	return unreachable || strings.HasPrefix(strings.TrimSpace(errMsg), "dial tcp")
+}
+
+// printRerouteNote heads a rerouted slip so the kitchen knows why it printed here. The
+// label is a station name or host, so it is escaped and cut to fit the line.
+func printRerouteNote(from string, width int) string {
+	label := printTruncate(printSafeText(strings.TrimSpace(from)), width-printTextWidth("( unreachable)"))
+	return printCentered("REROUTED", width, printInvert, printBold) + "\n" +
+		printCenterLine("("+label+" unreachable)", width) + "\n"
+}
+
+// FailJob records an agent's print failure. When the agent could not reach the printer and
+// the job's failover chain has another printer, the job goes back in the queue routed to it
+// instead of failing. A report for a job no longer claimed by agentID is stale and changes
+// nothing. Returns whether the job was rerouted.
+func (s *PrintService) FailJob(restaurantID, jobID, agentID, errMsg string, unreachable bool) (bool, error) {
+	if !isPrinterUnreachable(unreachable, errMsg) {
+		return false, s.CompleteJob(restaurantID, jobID, agentID, true, errMsg)
+	}
+	settings, err := s.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return false, err
+	}
+	stale, rerouted := false, false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var job models.PrintJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", jobID, restaurantID).
+			First(&job).Error; err != nil {
+			return err
+		}
+		if job.Status != PrintStatusClaimed || job.ClaimedBy != agentID {
+			stale = true
+			return nil
+		}
+		stations, err := stationsByID(tx, restaurantID, []models.PrintJob{job})
+		if err != nil {
+			return err
+		}
+		chain := jobPrinterChain(settings, stations, job)
+		next := job.PrinterIndex + 1
+		if next >= len(chain) {
+			return nil
+		}
+		from := chain[next-1]
+		rerouted = true
+		log.Printf("print job %s: %s unreachable, rerouting to %s", job.ID, from.Label, chain[next].Label)
+		// A new printer gets a fresh attempt budget.
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":        PrintStatusPending,
+			"printer_index": next,
+			"rerouted_from": from.Label,
+			"claimed_by":    "",
+			"claimed_at":    nil,
+			"attempts":      0,
+			"error_message": fmt.Sprintf("%s unreachable (%s); rerouted to %s", from.Label, errMsg, chain[next].Label),
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	if stale {
+		log.Printf("print job %s: ignoring stale failure from agent %s", jobID, agentID)
+		return false, nil
+	}
+	if rerouted {
+		s.notifyJobsReady(restaurantID)
+		return true, nil
+	}
+	return false, s.CompleteJob(restaurantID, jobID, agentID, true, errMsg)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestJobPrinterChain(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{
+		KotPrinterHost: "10.0.0.5", KotPrinterPort: 9100, KotPaperWidthMm: 80,
+		BillPrinterHost: "10.0.0.6", BillPrinterPort: 9100, BillPaperWidthMm: 58, BillPlainText: true,
+		KotFailover: []models.PrinterEndpoint{
+			{Target: PrintTargetBill},
+			{Host: "10.0.0.7"},
+			{Target: PrintTargetKOT}, // the KOT printer itself; skipped
+		},
+	}
+	stations := map[string]models.KitchenStation{
+		"tandoor": {ID: "tandoor", Name: "Tandoor", PrinterHost: "10.0.0.9", PaperWidthMm: 58,
+			Failover: []models.PrinterEndpoint{{Target: PrintTargetKOT}}},
+	}
+
+	chain := jobPrinterChain(settings, stations, models.PrintJob{Target: PrintTargetKOT})
+	if len(chain) != 3 || chain[0].Host != "10.0.0.5" || chain[1].Host != "10.0.0.6" || chain[2].Host != "10.0.0.7" {
+		t.Fatalf("kot chain: %+v", chain)
+	}
+	if !chain[1].Format.PlainText || chain[1].Format.PaperWidthMm != 58 {
+		t.Fatalf("bill printer in the chain should keep its own format: %+v", chain[1].Format)
+	}
+	if chain[2].Format.PaperWidthMm != 80 {
+		t.Fatalf("bare host should inherit the KOT format: %+v", chain[2].Format)
+	}
+
+	job := models.PrintJob{Target: PrintTargetKOT, StationID: "tandoor", PrinterIndex: 1}
+	if host, _ := resolveJobPrinter(settings, stations, job); host != "10.0.0.5" {
+		t.Fatalf("rerouted station job should land on the KOT printer, got %s", host)
+	}
+	job.PrinterIndex = 7
+	if host, _ := resolveJobPrinter(settings, stations, job); host != "10.0.0.5" {
+		t.Fatalf("index past the chain should stay on the last printer, got %s", host)
+	}
+
+	// Bills on the shared KOT printer follow the KOT failover list.
+	settings.BillPrinterHost = ""
+	chain = jobPrinterChain(settings, stations, models.PrintJob{Target: PrintTargetBill})
+	if len(chain) != 2 || chain[0].Host != "10.0.0.5" || chain[0].Format.PaperWidthMm != 58 || chain[1].Host != "10.0.0.7" {
+		t.Fatalf("shared bill chain: %+v", chain)
+	}
+}
+
+func TestNormalizePrinterFailover(t *testing.T) {
+	list, err := normalizePrinterFailover([]models.PrinterEndpoint{{Target: PrintTargetBill, Host: "x"}, {Host: " 10.0.0.7 ", Port: -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Host != "" || list[1].Host != "10.0.0.7" || list[1].Port != 9100 {
+		t.Fatalf("normalized: %+v", list)
+	}
+	if _, err := normalizePrinterFailover([]models.PrinterEndpoint{{Target: "kds"}}); err == nil {
+		t.Fatal("expected error for unknown target")
+	}
+	if _, err := normalizePrinterFailover([]models.PrinterEndpoint{{}}); err == nil {
+		t.Fatal("expected error for empty entry")
+	}
+}
+
+func TestPrinterUnreachableAndNote(t *testing.T) {
+	if !isPrinterUnreachable(false, "dial tcp 10.0.0.5:9100: connect: connection refused") {
+		t.Fatal("dial errors from older agents should count as unreachable")
+	}
+	if isPrinterUnreachable(false, "write tcp 10.0.0.5:9100: broken pipe") {
+		t.Fatal("a write error may have printed part of the slip; must not reroute")
+	}
+	note := StripPrintMarkup(printRerouteNote("Tandoor", 32))
+	if !strings.Contains(note, "REROUTED") || !strings.Contains(note, "(Tandoor unreachable)") {
+		t.Fatalf("note: %q", note)
+	}
+	raw := printRerouteNote("<<<LOGO>>>http://10.0.0.1/x<<<END_LOGO>>> Tandoor Station Printer", 32)
+	if strings.Contains(raw, "<<<LOGO>>>") {
+		t.Fatalf("station label opened a logo block: %q", raw)
+	}
+	for _, line := range strings.Split(strings.TrimRight(StripPrintMarkup(raw), "\n"), "\n") {
+		if printTextWidth(line) > 32 {
+			t.Fatalf("note line %q is wider than the paper", line)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// reprintMarker heads every reprinted slip so the kitchen does not cook an order twice.
+const reprintMarker = "*** REPRINT ***"
+
+// PrintJobFilter narrows a print history listing. Empty fields are ignored.
+type PrintJobFilter struct {
+	OrderID string
+	Status  string
+	Target  string
+	JobType string
+	From    time.Time
+	ToEnd   time.Time
+}
+
+// ErrPrintJobFilter is returned for a history filter with a value ListJobs doesn't know.
+var ErrPrintJobFilter = errors.New("status must be pending, claimed, done or failed")
+
+var printJobStatuses = map[string]bool{
+	PrintStatusPending: true,
+	PrintStatusClaimed: true,
+	PrintStatusDone:    true,
+	PrintStatusFailed:  true,
+}
+
+// ListJobs returns print jobs newest first with the total matching count.
+func (s *PrintService) ListJobs(restaurantID string, filter PrintJobFilter, limit, offset int) ([]models.PrintJob, int64, error) {
+	if filter.Status != "" && !printJobStatuses[filter.Status] {
+		return nil, 0, ErrPrintJobFilter
+	}
+	query := s.db.Model(&models.PrintJob{}).Where("restaurant_id = ?", restaurantID)
+	if filter.OrderID != "" {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if filter.JobType != "" {
+		query = query.Where("job_type = ?", filter.JobType)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.ToEnd.IsZero() {
+		query = query.Where("created_at < ?", filter.ToEnd)
+	}
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var jobs []models.PrintJob
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, count, nil
+}
+
+// ReprintJob queues a copy of a job's slip, headed with a REPRINT marker. Reprinting a
+// reprint copies the original slip so the marker is never stacked.
+func (s *PrintService) ReprintJob(restaurantID, jobID string) (*models.PrintJob, error) {
+	var source models.PrintJob
+	if err := s.db.Where("id = ? AND restaurant_id = ?", jobID, restaurantID).First(&source).Error; err != nil {
+		return nil, err
+	}
+	settings, err := s.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	paperWidthMm := settings.BillPaperWidthMm
+	if source.Target == PrintTargetKOT {
+		paperWidthMm = settings.KotPaperWidthMm
+		if source.StationID != "" {
+			var station models.KitchenStation
+			if err := s.db.Select("paper_width_mm").Where("id = ? AND restaurant_id = ?", source.StationID, restaurantID).
+				First(&station).Error; err == nil {
+				paperWidthMm = station.PaperWidthMm
+			}
+		}
+	}
+
+	job := newReprintJob(source, thermalColsForPaper(paperWidthMm))
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	s.notifyJobsReady(restaurantID)
+	return &job, nil
+}
+
+// newReprintJob copies a job's slip under a REPRINT marker. A reprint never opens the
+// cash drawer or sounds the buzzer again.
+func newReprintJob(source models.PrintJob, width int) models.PrintJob {
+	originalID := source.ID
+	if source.ReprintOfID != "" {
+		originalID = source.ReprintOfID
+	}
+	return models.PrintJob{
+		RestaurantID: source.RestaurantID,
+		OrderID:      source.OrderID,
+		JobType:      source.JobType,
+		Target:       source.Target,
+		StationID:    source.StationID,
+		ReprintOfID:  originalID,
+		PayloadText:  markReprint(source.PayloadText, width),
+		Status:       PrintStatusPending,
+	}
+}
+
+// markReprint puts a centered REPRINT line above the slip, replacing any existing one.
+func markReprint(payload string, width int) string {
+	marker := printCenterLine(reprintMarker, width)
+	if first, rest, ok := strings.Cut(payload, "\n"); ok && strings.TrimSpace(first) == reprintMarker {
+		payload = rest
+	}
+	return marker + "\n" + payload
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestMarkReprint_AddsMarkerOnce(t *testing.T) {
+	slip := "Cafe\nKOT\n1 x Naan\n"
+	once := markReprint(slip, 32)
+	if !strings.HasPrefix(strings.TrimSpace(once), reprintMarker) || !strings.HasSuffix(once, slip) {
+		t.Fatalf("got %q", once)
+	}
+	twice := markReprint(once, 32)
+	if strings.Count(twice, reprintMarker) != 1 {
+		t.Fatalf("marker stacked: %q", twice)
+	}
+}
+
+func TestNewReprintJob_NeverKicksDrawer(t *testing.T) {
+	source := models.PrintJob{
+		ID: "job1", RestaurantID: "r1", OrderID: "o1", JobType: PrintJobTypeBill, Target: PrintTargetBill,
+		PayloadText: "BILL\n", OpenDrawer: true, Buzzer: true, Status: PrintStatusDone,
+	}
+	job := newReprintJob(source, 32)
+	if job.OpenDrawer || job.Buzzer {
+		t.Fatalf("reprint kept device flags: %+v", job)
+	}
+	if job.ReprintOfID != "job1" || job.OrderID != "o1" || job.Status != PrintStatusPending {
+		t.Fatalf("reprint job: %+v", job)
+	}
+	if again := newReprintJob(job, 32); again.ReprintOfID != "job1" || again.OpenDrawer {
+		t.Fatalf("reprint of a reprint: %+v", again)
+	}
+}
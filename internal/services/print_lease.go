@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPrintClaimLease  = 90 * time.Second
+	defaultPrintMaxAttempts = 3
+)
+
+var ErrPrintJobNotFailed = errors.New("only failed print jobs can be retried")
+
+// PrintClaimLease is how long an agent may hold a claimed job before it is handed out
+// again (PRINT_CLAIM_LEASE_SECONDS overrides the 90s default).
+func PrintClaimLease() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("PRINT_CLAIM_LEASE_SECONDS")); err == nil && v >= 15 {
+		return time.Duration(v) * time.Second
+	}
+	return defaultPrintClaimLease
+}
+
+// PrintMaxAttempts is how many claims a job gets before it is marked failed
+// (PRINT_MAX_ATTEMPTS overrides the default of 3).
+func PrintMaxAttempts() int {
+	if v, err := strconv.Atoi(os.Getenv("PRINT_MAX_ATTEMPTS")); err == nil && v >= 1 && v <= 20 {
+		return v
+	}
+	return defaultPrintMaxAttempts
+}
+
+// OnJobFailed registers a callback for jobs that end up failed (agent error, no printer,
+// or claim lease exhausted). It runs after the failure is committed.
+func (s *PrintService) OnJobFailed(fn func(job models.PrintJob)) {
+	s.onFailed = fn
+}
+
+func (s *PrintService) jobsFailed(jobs []models.PrintJob) {
+	if s == nil || s.onFailed == nil {
+		return
+	}
+	for _, job := range jobs {
+		s.onFailed(job)
+	}
+}
+
+// expiredClaimOutcome decides what happens to a job whose claim lease ran out:
+// back to pending while attempts remain, otherwise failed.
+func expiredClaimOutcome(job models.PrintJob, maxAttempts int, now time.Time) map[string]interface{} {
+	if job.Attempts >= maxAttempts {
+		return map[string]interface{}{
+			"status":        PrintStatusFailed,
+			"completed_at":  now,
+			"error_message": fmt.Sprintf("print agent %s did not confirm after %d attempt(s)", job.ClaimedBy, job.Attempts),
+		}
+	}
+	return map[string]interface{}{
+		"status":        PrintStatusPending,
+		"claimed_by":    "",
+		"claimed_at":    nil,
+		"error_message": fmt.Sprintf("claim by %s expired; requeued", job.ClaimedBy),
+	}
+}
+
+// RequeueExpiredClaims returns jobs whose claim lease expired to pending, or fails them once
+// they have used up their attempts. restaurantID "" sweeps every restaurant. Returns the
+// number requeued and the jobs that were failed.
+func (s *PrintService) RequeueExpiredClaims(restaurantID string) (int, []models.PrintJob, error) {
+	now := time.Now().UTC()
+	cutoff := now.Add(-PrintClaimLease())
+	maxAttempts := PrintMaxAttempts()
+
+	requeued := 0
+	var failed []models.PrintJob
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		q := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND claimed_at < ?", PrintStatusClaimed, cutoff)
+		if restaurantID != "" {
+			q = q.Where("restaurant_id = ?", restaurantID)
+		}
+		var jobs []models.PrintJob
+		if err := q.Order("claimed_at ASC").Limit(200).Find(&jobs).Error; err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			updates := expiredClaimOutcome(job, maxAttempts, now)
+			if err := tx.Model(&job).Updates(updates).Error; err != nil {
+				return err
+			}
+			if updates["status"] == PrintStatusFailed {
+				job.Status = PrintStatusFailed
+				job.ErrorMessage, _ = updates["error_message"].(string)
+				job.CompletedAt = &now
+				failed = append(failed, job)
+			} else {
+				requeued++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if requeued > 0 {
+		log.Printf("print lease: requeued %d expired claim(s)", requeued)
+	}
+	s.jobsFailed(failed)
+	return requeued, failed, nil
+}
+
+// StartClaimLeaseSweeper periodically requeues jobs held by agents that went away, so a
+// crashed agent's KOTs reach the kitchen (or raise a failure alert) without a new claim.
+func (s *PrintService) StartClaimLeaseSweeper(ctx context.Context) {
+	interval := PrintClaimLease() / 3
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				requeued, _, err := s.RequeueExpiredClaims("")
+				if err != nil {
+					log.Printf("print lease sweep failed: %v", err)
+					continue
+				}
+				if requeued > 0 {
+					s.wakeRestaurantsWithPending()
+				}
+			}
+		}
+	}()
+}
+
+// wakeRestaurantsWithPending nudges agents of every restaurant with pending jobs.
+func (s *PrintService) wakeRestaurantsWithPending() {
+	var ids []string
+	if err := s.db.Model(&models.PrintJob{}).
+		Where("status = ?", PrintStatusPending).
+		Distinct("restaurant_id").
+		Pluck("restaurant_id", &ids).Error; err != nil {
+		return
+	}
+	for _, id := range ids {
+		s.notifyJobsReady(id)
+	}
+}
+
+// RetryJob puts a failed job back in the queue with a fresh attempt budget.
+func (s *PrintService) RetryJob(restaurantID, jobID string) (*models.PrintJob, error) {
+	var job models.PrintJob
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", jobID, restaurantID).
+			First(&job).Error; err != nil {
+			return err
+		}
+		if job.Status != PrintStatusFailed {
+			return ErrPrintJobNotFailed
+		}
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":        PrintStatusPending,
+			"attempts":      0,
+			"claimed_by":    "",
+			"claimed_at":    nil,
+			"completed_at":  nil,
+			"error_message": "",
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return nil, err
+	}
+	s.notifyJobsReady(restaurantID)
+	return &job, nil
+}
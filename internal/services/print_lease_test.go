@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"restaurant-api/internal/models"
+)
+
+func TestExpiredClaimOutcome_RequeuesUntilMaxAttempts(t *testing.T) {
+	now := time.Now()
+	requeue := expiredClaimOutcome(models.PrintJob{Attempts: 2, ClaimedBy: "agent-1"}, 3, now)
+	if requeue["status"] != PrintStatusPending || requeue["claimed_at"] != nil || requeue["claimed_by"] != "" {
+		t.Fatalf("expected requeue, got %+v", requeue)
+	}
+
+	fail := expiredClaimOutcome(models.PrintJob{Attempts: 3, ClaimedBy: "agent-1"}, 3, now)
+	if fail["status"] != PrintStatusFailed || fail["completed_at"] != now {
+		t.Fatalf("expected failure, got %+v", fail)
+	}
+}
+
+func TestPrintClaimLeaseAndAttempts_EnvOverrides(t *testing.T) {
+	t.Setenv("PRINT_CLAIM_LEASE_SECONDS", "5")
+	t.Setenv("PRINT_MAX_ATTEMPTS", "0")
+	if PrintClaimLease() != defaultPrintClaimLease || PrintMaxAttempts() != defaultPrintMaxAttempts {
+		t.Fatal("out-of-range overrides should fall back to defaults")
+	}
+	t.Setenv("PRINT_CLAIM_LEASE_SECONDS", "120")
+	t.Setenv("PRINT_MAX_ATTEMPTS", "5")
+	if PrintClaimLease() != 2*time.Minute || PrintMaxAttempts() != 5 {
+		t.Fatalf("got %v / %d", PrintClaimLease(), PrintMaxAttempts())
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"restaurant-api/internal/models"
+)
+
+// Slip payloads may carry formatting markers in the same <<<...>>> form as the existing
+// QR block. The print agent turns them into ESC/POS commands; StripPrintMarkup reduces a
+// payload to plain text for old agents and printers marked plain-text only.
+//
+// Inline styles wrap text and may nest:
+//
+//	<<<B>>>bold<<</B>>>   <<<U>>>underline<<</U>>>   <<<INV>>>white on black<<</INV>>>
+//	<<<DH>>>double height<<</DH>>>   <<<DW>>>double width<<</DW>>>   <<<2X>>>both<<</2X>>>
+//
+// <<<CENTER>>> or <<<RIGHT>>> at the start of a line aligns that line only. Builders still
+// pad centered lines with spaces, so the plain form keeps its layout; the agent trims the
+// padding when it aligns with ESC a. Blocks sit on their own lines:
+//
+//	<<<BARCODE>>>data<<<END_BARCODE>>>   CODE128; plain form prints the data
+//	<<<LOGO>>>https://…/logo.png<<<END_LOGO>>>   raster image; dropped in plain form
+const (
+	printMarkupCenter     = "<<<CENTER>>>"
+	printMarkupRight      = "<<<RIGHT>>>"
+	printMarkupBarcode    = "<<<BARCODE>>>"
+	printMarkupBarcodeEnd = "<<<END_BARCODE>>>"
+	printMarkupLogo       = "<<<LOGO>>>"
+	printMarkupLogoEnd    = "<<<END_LOGO>>>"
+)
+
+var (
+	printStyleTagRe  = regexp.MustCompile(`<<</?(?:B|U|INV|DH|DW|2X)>>>|<<<(?:CENTER|RIGHT)>>>`)
+	printLogoRe      = regexp.MustCompile(`(?s)<<<LOGO>>>.*?<<<END_LOGO>>>\n?`)
+	printBarcodeRe   = regexp.MustCompile(`(?s)<<<BARCODE>>>(.*?)<<<END_BARCODE>>>`)
+	printMarkerRunRe = regexp.MustCompile(`<{3,}|>{3,}`)
+)
+
+// printSafeText defuses <<< and >>> in text that comes from users (item names, notes,
+// customer and table names) so it can't open a markup block: a guest note could
+// otherwise ask the agent to fetch a LOGO URL on the restaurant LAN, or print a QR code.
+func printSafeText(s string) string {
+	return printMarkerRunRe.ReplaceAllStringFunc(s, func(run string) string { return run[:2] })
+}
+
+func printBold(s string) string      { return "<<<B>>>" + s + "<<</B>>>" }
+func printUnderline(s string) string { return "<<<U>>>" + s + "<<</U>>>" }
+func printInvert(s string) string    { return "<<<INV>>>" + s + "<<</INV>>>" }
+func printTall(s string) string      { return "<<<DH>>>" + s + "<<</DH>>>" }
+func printLarge(s string) string     { return "<<<2X>>>" + s + "<<</2X>>>" }
+
+// printCentered centers a line with markup, keeping space padding for the plain form.
+// Styles wrap the text only, not the padding.
+func printCentered(text string, width int, styles ...func(string) string) string {
+	line := printCenterLine(text, width)
+	pad := len(line) - len(strings.TrimLeft(line, " "))
+	body := line[pad:]
+	for _, style := range styles {
+		body = style(body)
+	}
+	return printMarkupCenter + strings.Repeat(" ", pad) + body
+}
+
+// printBarcodeBlock emits a CODE128 barcode of data on its own lines.
+func printBarcodeBlock(data string) string {
+	return printMarkupBarcode + printSafeText(strings.TrimSpace(data)) + printMarkupBarcodeEnd + "\n"
+}
+
+// printLogoBlock emits a raster logo fetched by the agent from url.
+func printLogoBlock(url string) string {
+	return printMarkupLogo + printSafeText(strings.TrimSpace(url)) + printMarkupLogoEnd + "\n"
+}
+
+// StripPrintMarkup removes formatting markers, leaving the slip as plain text. QR blocks
+// are kept; every agent version prints them.
+func StripPrintMarkup(text string) string {
+	if !strings.Contains(text, "<<<") {
+		return text
+	}
+	text = printLogoRe.ReplaceAllString(text, "")
+	text = printBarcodeRe.ReplaceAllString(text, "$1")
+	return printStyleTagRe.ReplaceAllString(text, "")
+}
+
+// Raster text modes. Text-mode ESC/POS only prints the printer's code page, so slips in
+// Devanagari, Tamil or Kannada come out as garbage; with a raster mode the agent draws
+// those lines with its bundled fonts and sends them as GS v 0 images.
+const (
+	PrintRasterTextOff      = ""
+	PrintRasterTextNonASCII = "non_ascii" // only lines with non-ASCII text
+	PrintRasterTextAll      = "all"       // every text line
+)
+
+func normalizePrintRasterText(mode string) (string, error) {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case PrintRasterTextOff, "off":
+		return PrintRasterTextOff, nil
+	case PrintRasterTextNonASCII, PrintRasterTextAll:
+		return mode, nil
+	}
+	return "", fmt.Errorf("raster text mode must be off, non_ascii or all")
+}
+
+// printJobFormat is what the agent needs to know about the printer a job lands on.
+type printJobFormat struct {
+	PaperWidthMm int
+	PlainText    bool
+	RasterText   string
+}
+
+// resolveJobFormat returns the format of the printer a job lands on, following the same
+// station, bill-to-KOT and failover routing as resolveJobPrinter.
+func resolveJobFormat(settings *models.RestaurantPrintSettings, stations map[string]models.KitchenStation, job models.PrintJob) printJobFormat {
+	return jobPrintRoute(settings, stations, job).Format
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestStripPrintMarkup(t *testing.T) {
+	in := "<<<LOGO>>>https://cdn.example.com/logo.png<<<END_LOGO>>>\n" +
+		"<<<CENTER>>>   <<<DH>>><<<B>>>Spice Hut<<</B>>><<</DH>>>\n" +
+		"<<<2X>>>Table: 4<<</2X>>>\n" +
+		"<<<BARCODE>>>INV-0042<<<END_BARCODE>>>\n" +
+		"<<<BILLGENIE_QR>>>\nhttps://t.example.com/x\n<<<END_QR>>>\n"
+	want := "   Spice Hut\n" +
+		"Table: 4\n" +
+		"INV-0042\n" +
+		"<<<BILLGENIE_QR>>>\nhttps://t.example.com/x\n<<<END_QR>>>\n"
+	if got := StripPrintMarkup(in); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestPrintCentered_StylesTextNotPadding(t *testing.T) {
+	got := printCentered("BILL", 12, printBold)
+	if got != "<<<CENTER>>>    <<<B>>>BILL<<</B>>>" {
+		t.Fatalf("got %q", got)
+	}
+	if StripPrintMarkup(got) != printCenterLine("BILL", 12) {
+		t.Fatalf("plain form should match printCenterLine, got %q", StripPrintMarkup(got))
+	}
+}
+
+func TestBuildBillPayload_Markup(t *testing.T) {
+	order := &models.Order{OrderNumber: 7, InvoiceNumber: "INV-7", Total: 120, Status: "completed"}
+	items := []models.OrderItem{{MenuID: "m1", Quantity: 1, UnitRate: 120, Total: 120, MenuItem: &models.MenuItem{Name: "Thali"}}}
+	text := buildBillPayload(models.Restaurant{Name: "Spice Hut"}, order, items, 58, "https://cdn.example.com/logo.png")
+	for _, want := range []string{
+		"<<<LOGO>>>https://cdn.example.com/logo.png<<<END_LOGO>>>",
+		"<<<BARCODE>>>INV-7<<<END_BARCODE>>>",
+		"<<<DH>>><<<B>>>TOTAL",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(StripPrintMarkup(text), "<<<B>>>") {
+		t.Fatal("plain bill still has markup")
+	}
+}
+
+func TestSlipPayloads_EscapeUserText(t *testing.T) {
+	inject := "<<<LOGO>>>http://192.168.1.1/reset<<<END_LOGO>>><<<<BILLGENIE_QR>>>\nx\n<<<END_QR>>>>"
+	order := &models.Order{OrderNumber: 9, InvoiceNumber: "INV-9", TableNumber: inject, CustomerName: inject, Total: 50, Status: "completed"}
+	items := []models.OrderItem{{MenuID: "m1", Quantity: 1, UnitRate: 50, Total: 50, Notes: inject, MenuItem: &models.MenuItem{Name: inject}}}
+	restaurant := models.Restaurant{Name: inject}
+
+	for name, text := range map[string]string{
+		"kot":  buildKOTPayload(restaurant, order, items, false, "", 80),
+		"bill": buildBillPayload(restaurant, order, items, 80, ""),
+	} {
+		for _, tag := range []string{"<<<LOGO>>>", "<<<END_LOGO>>>", "<<<BILLGENIE_QR>>>", "<<<END_QR>>>"} {
+			if strings.Contains(text, tag) {
+				t.Errorf("%s: user text opened %s:\n%s", name, tag, text)
+			}
+		}
+		if !strings.Contains(text, "<<LOGO>>http://192.168.1.1/reset") {
+			t.Errorf("%s: escaped text should still print:\n%s", name, text)
+		}
+	}
+	if got := printSafeText("a <<< b >>>> c << d"); got != "a << b >> c << d" {
+		t.Fatalf("printSafeText = %q", got)
+	}
+}
+
+func TestResolveJobFormat(t *testing.T) {
+	settings := &models.RestaurantPrintSettings{KotPaperWidthMm: 80, BillPaperWidthMm: 58, KotPlainText: true, KotRasterText: PrintRasterTextNonASCII}
+	stations := map[string]models.KitchenStation{"bar": {ID: "bar", PrinterHost: "10.0.0.9", PaperWidthMm: 58, RasterText: PrintRasterTextAll}}
+	if f := resolveJobFormat(settings, stations, models.PrintJob{Target: PrintTargetKOT, StationID: "bar"}); f.PaperWidthMm != 58 || f.PlainText || f.RasterText != PrintRasterTextAll {
+		t.Fatalf("bar station: %+v", f)
+	}
+	if f := resolveJobFormat(settings, stations, models.PrintJob{Target: PrintTargetKOT}); f.PaperWidthMm != 80 || !f.PlainText || f.RasterText != PrintRasterTextNonASCII {
+		t.Fatalf("main KOT: %+v", f)
+	}
+	if f := resolveJobFormat(settings, stations, models.PrintJob{Target: PrintTargetBill}); !f.PlainText || f.RasterText != PrintRasterTextNonASCII {
+		t.Fatal("bill on the shared KOT printer should follow the KOT printer's plain-text and raster flags")
+	}
+}
+
+func TestPrintLayoutCountsColumnsNotBytes(t *testing.T) {
+	// प न ी र: the vowel sign ी takes a column; the virama in ट ् ट does not.
+	if w := printTextWidth("पनीर टिक्का"); w != 10 {
+		t.Fatalf("width = %d, want 10", w)
+	}
+	line := printPadLine("पनीर टिक्का", "240.00", 32)
+	if printTextWidth(line) != 32 || !strings.HasSuffix(line, " 240.00") {
+		t.Fatalf("pad line %q is %d columns", line, printTextWidth(line))
+	}
+	for _, l := range wrapPrintWords("மசாலா தோசை ಮಸಾಲೆ ದೋಸೆ", 8) {
+		if printTextWidth(l) > 8 {
+			t.Fatalf("wrapped line %q wider than 8 columns", l)
+		}
+	}
+	if got := printTruncate("क्षत्रिय", 2); got != "क्ष" {
+		t.Fatalf("truncate kept %q; combining marks must stay with their base", got)
+	}
+	if c := printCenterLine("नमस्ते", 12); !strings.HasPrefix(c, "    न") {
+		t.Fatalf("center line %q", c)
+	}
+}
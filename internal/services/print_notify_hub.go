@@ -8,8 +8,9 @@ var sharedPrintNotifyHub = NewPrintNotifyHub()
 
 // PrintNotifyHub wakes print-agent SSE subscribers when jobs are enqueued for a restaurant.
 type PrintNotifyHub struct {
-	mu   sync.RWMutex
-	subs map[string]map[chan struct{}]struct{}
+	mu     sync.RWMutex
+	subs   map[string]map[chan struct{}]struct{}
+	broker HubBroker
 }
 
 func NewPrintNotifyHub() *PrintNotifyHub {
@@ -23,6 +24,17 @@ func SharedPrintNotifyHub() *PrintNotifyHub {
 	return sharedPrintNotifyHub
 }
 
+// UseBroker sends wake-ups through broker so an agent streaming from any instance hears
+// about jobs enqueued on another.
+func (h *PrintNotifyHub) UseBroker(broker HubBroker) {
+	broker.Subscribe(hubTopicPrintNotify, func(restaurantID string, _ []byte) {
+		h.wake(restaurantID)
+	})
+	h.mu.Lock()
+	h.broker = broker
+	h.mu.Unlock()
+}
+
 func (h *PrintNotifyHub) Subscribe(restaurantID string) chan struct{} {
 	ch := make(chan struct{}, 1)
 	h.mu.Lock()
@@ -51,6 +63,17 @@ func (h *PrintNotifyHub) Notify(restaurantID string) {
 	if h == nil || restaurantID == "" {
 		return
 	}
+	h.mu.RLock()
+	broker := h.broker
+	h.mu.RUnlock()
+	if broker != nil {
+		broker.Publish(hubTopicPrintNotify, restaurantID, nil)
+		return
+	}
+	h.wake(restaurantID)
+}
+
+func (h *PrintNotifyHub) wake(restaurantID string) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for ch := range h.subs[restaurantID] {
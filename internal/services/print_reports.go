@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+
+	"restaurant-api/internal/models"
+)
+
+// PrintJobTypeReport is a non-order slip (drawer close, day-end report) sent to the bill printer.
+const PrintJobTypeReport = "report"
+
+// enqueueReport queues a pre-rendered report slip on the bill printer. Returns queued=false
+// when no printer host resolves.
+func (s *PrintService) enqueueReport(restaurantID, text string) (bool, error) {
+	settings, err := s.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return false, err
+	}
+	if host, _ := resolvePrinter(settings, PrintTargetBill); host == "" {
+		return false, nil
+	}
+	job := models.PrintJob{
+		RestaurantID: restaurantID,
+		JobType:      PrintJobTypeReport,
+		Target:       PrintTargetBill,
+		PayloadText:  text,
+		Status:       PrintStatusPending,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return false, err
+	}
+	s.notifyJobsReady(restaurantID)
+	return true, nil
+}
+
+// EnqueueCashSessionSlip prints a drawer summary (running totals while open, frozen after close).
+func (s *PrintService) EnqueueCashSessionSlip(session *models.CashDrawerSession) (bool, error) {
+	settings, err := s.GetOrCreateSettings(session.RestaurantID)
+	if err != nil {
+		return false, err
+	}
+	var restaurant models.Restaurant
+	_ = s.db.Select("id", "name", "timezone", "business_day_start").
+		Where("id = ?", session.RestaurantID).First(&restaurant).Error
+
+	text := CashSessionSlip(strings.TrimSpace(restaurant.Name), session,
+		BusinessCalendarForRestaurant(&restaurant), thermalColsForPaper(settings.BillPaperWidthMm))
+	return s.enqueueReport(session.RestaurantID, text)
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"restaurant-api/internal/models"
 
@@ -26,14 +28,21 @@ const (
 )
 
 type PrintService struct {
-	db     *gorm.DB
-	notify *PrintNotifyHub
+	db       *gorm.DB
+	notify   *PrintNotifyHub
+	onFailed func(job models.PrintJob)
+	onAgents func(restaurantID string, online bool, agents []models.PrintAgentStatus)
 }
 
 func NewPrintService(db *gorm.DB) *PrintService {
 	return &PrintService{db: db, notify: SharedPrintNotifyHub()}
 }
 
+// GetDB returns the underlying database handle.
+func (s *PrintService) GetDB() *gorm.DB {
+	return s.db
+}
+
 // NotifyHub returns the SSE wake hub used by print agents.
 func (s *PrintService) NotifyHub() *PrintNotifyHub {
 	return s.notify
@@ -101,6 +110,15 @@ type UpdatePrintSettingsInput struct {
 	KotPaperWidthMm         *int    `json:"kot_paper_width_mm"`
 	TopFeedLines            *int    `json:"top_feed_lines"`
 	BottomFeedLines         *int    `json:"bottom_feed_lines"`
+	BillOpenDrawer          *bool   `json:"bill_open_drawer"`
+	KotBuzzer               *bool   `json:"kot_buzzer"`
+	KotPlainText            *bool   `json:"kot_plain_text"`
+	BillPlainText           *bool   `json:"bill_plain_text"`
+	BillLogoURL             *string `json:"bill_logo_url"`
+	KotRasterText           *string `json:"kot_raster_text"`
+	BillRasterText          *string `json:"bill_raster_text"`
+	KotFailover             *[]models.PrinterEndpoint `json:"kot_failover"`
+	BillFailover            *[]models.PrinterEndpoint `json:"bill_failover"`
 }
 
 func clampFeedLines(n int) int {
@@ -120,6 +138,8 @@ func normalizePaperWidthMm(n int) int {
 	return 58
 }
 
+// thermalColsForPaper is the slip width in font A columns (12 dots each). Layout counts
+// columns with printTextWidth, so Indic and CJK text lines up when the agent rasterizes it.
 func thermalColsForPaper(paperWidthMm int) int {
 	if normalizePaperWidthMm(paperWidthMm) == 80 {
 		return 48
@@ -186,6 +206,53 @@ func (s *PrintService) UpdateSettings(restaurantID string, input UpdatePrintSett
 	if input.BottomFeedLines != nil {
 		updates["bottom_feed_lines"] = clampFeedLines(*input.BottomFeedLines)
 	}
+	if input.BillOpenDrawer != nil {
+		updates["bill_open_drawer"] = *input.BillOpenDrawer
+	}
+	if input.KotBuzzer != nil {
+		updates["kot_buzzer"] = *input.KotBuzzer
+	}
+	if input.KotPlainText != nil {
+		updates["kot_plain_text"] = *input.KotPlainText
+	}
+	if input.BillPlainText != nil {
+		updates["bill_plain_text"] = *input.BillPlainText
+	}
+	if input.BillLogoURL != nil {
+		logoURL := strings.TrimSpace(*input.BillLogoURL)
+		if logoURL != "" && (!strings.HasPrefix(logoURL, "https://") || len(logoURL) > 500) {
+			return nil, fmt.Errorf("bill_logo_url must be an https URL (max 500 chars)")
+		}
+		updates["bill_logo_url"] = logoURL
+	}
+	if input.KotRasterText != nil {
+		mode, err := normalizePrintRasterText(*input.KotRasterText)
+		if err != nil {
+			return nil, fmt.Errorf("kot_raster_text: %w", err)
+		}
+		updates["kot_raster_text"] = mode
+	}
+	if input.BillRasterText != nil {
+		mode, err := normalizePrintRasterText(*input.BillRasterText)
+		if err != nil {
+			return nil, fmt.Errorf("bill_raster_text: %w", err)
+		}
+		updates["bill_raster_text"] = mode
+	}
+	if input.KotFailover != nil {
+		list, err := normalizePrinterFailover(*input.KotFailover)
+		if err != nil {
+			return nil, fmt.Errorf("kot_failover: %w", err)
+		}
+		updates["kot_failover"] = printerFailoverJSON(list)
+	}
+	if input.BillFailover != nil {
+		list, err := normalizePrinterFailover(*input.BillFailover)
+		if err != nil {
+			return nil, fmt.Errorf("bill_failover: %w", err)
+		}
+		updates["bill_failover"] = printerFailoverJSON(list)
+	}
 	if len(updates) == 0 {
 		return settings, nil
 	}
@@ -270,7 +337,12 @@ func (s *PrintService) enqueueKOT(order *models.Order, preferLatestBatch bool) e
 	if !settings.KotPrintingEnabled {
 		return nil
 	}
-	if strings.TrimSpace(settings.KotPrinterHost) == "" {
+	stations, err := stationsForRestaurant(s.db, order.RestaurantID)
+	if err != nil {
+		return err
+	}
+	mainHost := strings.TrimSpace(settings.KotPrinterHost)
+	if mainHost == "" && len(stations) == 0 {
 		log.Printf(
 			"print enqueue KOT skipped for order %s: kot printing enabled but kot_printer_host is empty (browser KOT may still print)",
 			order.ID,
@@ -292,19 +364,39 @@ func (s *PrintService) enqueueKOT(order *models.Order, preferLatestBatch bool) e
 	var restaurant models.Restaurant
 	_ = s.db.Select("name", "category_display_blocklist").Where("id = ?", order.RestaurantID).First(&restaurant).Error
 
-	text := buildKOTPayload(restaurant, order, items, isAddOn)
-	job := models.PrintJob{
-		RestaurantID: order.RestaurantID,
-		OrderID:      order.ID,
-		JobType:      PrintJobTypeKOT,
-		Target:       PrintTargetKOT,
-		PayloadText:  text,
-		Status:       PrintStatusPending,
+	// One slip per station, each listing only that station's lines.
+	queued := 0
+	for _, group := range RouteKOTItems(items, stations) {
+		stationID, stationName, paperWidthMm := "", "", settings.KotPaperWidthMm
+		if group.Station != nil {
+			stationID = group.Station.ID
+			stationName = group.Station.Name
+			paperWidthMm = group.Station.PaperWidthMm
+		}
+		if host, _ := resolveStationPrinter(settings, group.Station); host == "" {
+			log.Printf("print enqueue KOT skipped %d item(s) for order %s: no printer host for station %q",
+				len(group.Items), order.ID, stationName)
+			continue
+		}
+		text := buildKOTPayload(restaurant, order, group.Items, isAddOn, stationName, paperWidthMm)
+		job := models.PrintJob{
+			RestaurantID: order.RestaurantID,
+			OrderID:      order.ID,
+			JobType:      PrintJobTypeKOT,
+			Target:       PrintTargetKOT,
+			StationID:    stationID,
+			PayloadText:  text,
+			Buzzer:       kotBuzzes(settings, group.Station),
+			Status:       PrintStatusPending,
+		}
+		if err := s.db.Create(&job).Error; err != nil {
+			return err
+		}
+		queued++
 	}
-	if err := s.db.Create(&job).Error; err != nil {
-		return err
+	if queued > 0 {
+		s.notifyJobsReady(order.RestaurantID)
 	}
-	s.notifyJobsReady(order.RestaurantID)
 	return nil
 }
 
@@ -389,7 +481,7 @@ func (s *PrintService) enqueueBill(order *models.Order) (bool, error) {
 	order = full
 
 	var restaurant models.Restaurant
-	_ = s.db.Select("name", "address", "contact_number", "phone", "gst_number", "category_display_blocklist").
+	_ = s.db.Select("name", "address", "contact_number", "phone", "gst_number", "category_display_blocklist", "composite_scheme", "prices_include_gst", "state").
 		Where("id = ?", order.RestaurantID).First(&restaurant).Error
 
 	active := make([]models.OrderItem, 0, len(order.Items))
@@ -402,7 +494,7 @@ func (s *PrintService) enqueueBill(order *models.Order) (bool, error) {
 		return false, nil
 	}
 
-	text := buildBillPayload(restaurant, order, active, settings.BillPaperWidthMm)
+	text := buildBillPayload(restaurant, order, active, settings.BillPaperWidthMm, settings.BillLogoURL)
 	job := models.PrintJob{
 		RestaurantID: order.RestaurantID,
 		OrderID:      order.ID,
@@ -411,13 +503,64 @@ func (s *PrintService) enqueueBill(order *models.Order) (bool, error) {
 		PayloadText:  text,
 		Status:       PrintStatusPending,
 	}
-	if err := s.db.Create(&job).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if billOpensDrawer(settings, order) {
+			// Claim the kick on the order so a second Print bill on the same order can't
+			// open the drawer again.
+			res := tx.Model(&models.Order{}).Where("id = ? AND drawer_kicked_at IS NULL", order.ID).
+				Update("drawer_kicked_at", time.Now())
+			if res.Error != nil {
+				return res.Error
+			}
+			job.OpenDrawer = res.RowsAffected == 1
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
 		return false, err
 	}
 	s.notifyJobsReady(order.RestaurantID)
 	return true, nil
 }
 
+// billOpensDrawer reports whether this bill should kick the cash drawer: only the first
+// bill printed for a cash settlement does, never a later print of the same order.
+func billOpensDrawer(settings *models.RestaurantPrintSettings, order *models.Order) bool {
+	return settings.BillOpenDrawer && billTakesCash(order) && order.DrawerKickedAt == nil
+}
+
+// billTakesCash reports whether a bill settles cash into the drawer: a completed order paid
+// in cash, or split with a cash leg. Pre-payment bills and card/UPI-only bills don't open it.
+func billTakesCash(order *models.Order) bool {
+	if order == nil || order.Status != "completed" {
+		return false
+	}
+	if len(order.Tenders) > 0 {
+		for _, t := range order.Tenders {
+			if t.Method == TenderCash && t.Amount > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	switch order.PaymentMethod {
+	case TenderCash:
+		return true
+	case "split":
+		return order.CashAmount > 0
+	}
+	return false
+}
+
+// kotBuzzes reports whether the printer a KOT group lands on should beep: the station's
+// own setting when it has a printer, otherwise the main KOT printer's.
+func kotBuzzes(settings *models.RestaurantPrintSettings, station *models.KitchenStation) bool {
+	if station != nil && strings.TrimSpace(station.PrinterHost) != "" {
+		return station.Buzzer
+	}
+	return settings != nil && settings.KotBuzzer
+}
+
 // EnqueueTestPrint queues a short slip so staff can verify Wi‑Fi/LAN agent printing.
 // Target is "kot" or "bill". Returns queued=false when no printer host resolves.
 func (s *PrintService) EnqueueTestPrint(restaurantID, target string) (bool, error) {
@@ -465,25 +608,67 @@ func (s *PrintService) EnqueueTestPrint(restaurantID, target string) (bool, erro
 	return true, nil
 }
 
+// EnqueueStationTestPrint queues a test slip on a kitchen station's printer.
+func (s *PrintService) EnqueueStationTestPrint(restaurantID string, station *models.KitchenStation) (bool, error) {
+	settings, err := s.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return false, err
+	}
+	host, _ := resolveStationPrinter(settings, station)
+	if host == "" {
+		return false, nil
+	}
+
+	var restaurant models.Restaurant
+	_ = s.db.Select("name").Where("id = ?", restaurantID).First(&restaurant).Error
+
+	text := buildTestPrintPayload(restaurant.Name, "kot "+station.Name, host, station.PaperWidthMm)
+	job := models.PrintJob{
+		RestaurantID: restaurantID,
+		JobType:      PrintJobTypeKOT,
+		Target:       PrintTargetKOT,
+		StationID:    station.ID,
+		PayloadText:  text,
+		Status:       PrintStatusPending,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return false, err
+	}
+	s.notifyJobsReady(restaurantID)
+	return true, nil
+}
+
+// printerConnectionLabel names how the agent reaches a printer host, for the test slip.
+func printerConnectionLabel(host string) string {
+	h := strings.ToLower(strings.TrimSpace(host))
+	switch {
+	case strings.HasPrefix(h, "usb:"), strings.HasPrefix(h, "/dev/usb/lp"), strings.HasPrefix(h, "/dev/lp"):
+		return "USB"
+	case strings.HasPrefix(h, "ipp://"), strings.HasPrefix(h, "ipps://"):
+		return "CUPS / IPP"
+	}
+	return "Wi-Fi / LAN"
+}
+
 func buildTestPrintPayload(restaurantName, target, host string, paperWidthMm int) string {
 	width := thermalColsForPaper(paperWidthMm)
 	divider := strings.Repeat("-", width)
-	label := "KOT"
-	if strings.EqualFold(target, "bill") {
-		label = "BILL"
+	label := strings.ToUpper(strings.TrimSpace(target))
+	if label == "" {
+		label = "KOT"
 	}
 	var b strings.Builder
 	if name := strings.TrimSpace(restaurantName); name != "" {
-		b.WriteString(printCenterLine(name, width))
+		b.WriteString(printCenterLine(printSafeText(name), width))
 		b.WriteByte('\n')
 	}
 	b.WriteString(printCenterLine("TEST PRINT", width))
 	b.WriteByte('\n')
-	b.WriteString(printCenterLine(label+" Wi-Fi / LAN", width))
+	b.WriteString(printCenterLine(label+" "+printerConnectionLabel(host), width))
 	b.WriteByte('\n')
 	b.WriteString(divider)
 	b.WriteByte('\n')
-	b.WriteString(fmt.Sprintf("Host: %s\n", host))
+	b.WriteString(fmt.Sprintf("Host: %s\n", printSafeText(host)))
 	b.WriteString(time.Now().Format("02 Jan 2006 03:04 PM"))
 	b.WriteByte('\n')
 	b.WriteString(divider)
@@ -501,7 +686,7 @@ func buildTestPrintPayload(restaurantName, target, host string, paperWidthMm int
 
 func (s *PrintService) loadOrderForPrint(orderID string) (*models.Order, error) {
 	var order models.Order
-	err := s.db.Preload("Items.MenuItem").Where("id = ?", orderID).First(&order).Error
+	err := s.db.Preload("Items.MenuItem").Preload("Tenders").Where("id = ?", orderID).First(&order).Error
 	if err != nil {
 		return nil, err
 	}
@@ -512,6 +697,7 @@ func (s *PrintService) loadOrderForPrint(orderID string) (*models.Order, error)
 func (s *PrintService) LoadOrderForEnqueue(restaurantID, orderID string) (*models.Order, error) {
 	var order models.Order
 	err := s.db.Preload("Items.MenuItem").
+		Preload("Tenders").
 		Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
 		First(&order).Error
 	if err != nil {
@@ -529,10 +715,16 @@ type AgentJobView struct {
 	PrinterPort     int    `json:"printer_port"`
 	TopFeedLines    int    `json:"top_feed_lines"`
 	BottomFeedLines int    `json:"bottom_feed_lines"`
+	OpenDrawer      bool   `json:"open_drawer"`
+	Buzzer          bool   `json:"buzzer"`
+	PaperWidthMm    int    `json:"paper_width_mm"`
+	RasterMode      string `json:"raster_mode,omitempty"`
 	CreatedAt       string `json:"created_at"`
 }
 
-func (s *PrintService) ClaimPendingJobs(restaurantID, agentID string, limit int) ([]AgentJobView, error) {
+// ClaimPendingJobs hands queued jobs to an agent. markup is true when the agent understands
+// slip formatting markers; otherwise (older agents) payloads are sent as plain text.
+func (s *PrintService) ClaimPendingJobs(restaurantID, agentID string, limit int, markup bool) ([]AgentJobView, error) {
 	if limit <= 0 || limit > 20 {
 		limit = 5
 	}
@@ -540,8 +732,13 @@ func (s *PrintService) ClaimPendingJobs(restaurantID, agentID string, limit int)
 	if err != nil {
 		return nil, err
 	}
+	// Jobs stranded by a dead agent go back in the queue before handing out new work.
+	if _, _, err := s.RequeueExpiredClaims(restaurantID); err != nil {
+		log.Printf("print lease requeue failed for restaurant %s: %v", restaurantID, err)
+	}
 
 	var claimed []AgentJobView
+	var failed []models.PrintJob
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		var jobs []models.PrintJob
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
@@ -551,16 +748,22 @@ func (s *PrintService) ClaimPendingJobs(restaurantID, agentID string, limit int)
 			Find(&jobs).Error; err != nil {
 			return err
 		}
+		stations, err := stationsByID(tx, restaurantID, jobs)
+		if err != nil {
+			return err
+		}
 		now := time.Now().UTC()
 		for _, job := range jobs {
-			host, port := resolvePrinter(settings, job.Target)
+			host, port := resolveJobPrinter(settings, stations, job)
 			if host == "" {
-				_ = tx.Model(&job).Updates(map[string]interface{}{
+				if err := tx.Model(&job).Updates(map[string]interface{}{
 					"status":        PrintStatusFailed,
 					"error_message": "printer host not configured",
 					"completed_at":  now,
 					"attempts":      job.Attempts + 1,
-				}).Error
+				}).Error; err == nil {
+					failed = append(failed, job)
+				}
 				continue
 			}
 			updates := map[string]interface{}{
@@ -572,20 +775,35 @@ func (s *PrintService) ClaimPendingJobs(restaurantID, agentID string, limit int)
 			if err := tx.Model(&job).Updates(updates).Error; err != nil {
 				return err
 			}
+			format := resolveJobFormat(settings, stations, job)
+			payload := job.PayloadText
+			if job.PrinterIndex > 0 && job.ReroutedFrom != "" {
+				payload = printRerouteNote(job.ReroutedFrom, thermalColsForPaper(format.PaperWidthMm)) + payload
+			}
+			if format.PlainText || !markup {
+				payload = StripPrintMarkup(payload)
+			}
 			claimed = append(claimed, AgentJobView{
 				ID:              job.ID,
 				JobType:         job.JobType,
 				Target:          job.Target,
-				PayloadText:     job.PayloadText,
+				PayloadText:     payload,
 				PrinterHost:     host,
 				PrinterPort:     port,
 				TopFeedLines:    settings.TopFeedLines,
 				BottomFeedLines: settings.BottomFeedLines,
+				OpenDrawer:      job.OpenDrawer,
+				Buzzer:          job.Buzzer,
+				PaperWidthMm:    format.PaperWidthMm,
+				RasterMode:      format.RasterText,
 				CreatedAt:       job.CreatedAt.UTC().Format(time.RFC3339),
 			})
 		}
 		return nil
 	})
+	if err == nil {
+		s.jobsFailed(failed)
+	}
 	return claimed, err
 }
 
@@ -610,7 +828,52 @@ func resolvePrinter(settings *models.RestaurantPrintSettings, target string) (st
 	return host, port
 }
 
-func (s *PrintService) CompleteJob(restaurantID, jobID string, failed bool, errMsg string) error {
+// resolveStationPrinter returns a station's printer, falling back to the main KOT printer
+// when the station is nil or has no host of its own.
+func resolveStationPrinter(settings *models.RestaurantPrintSettings, station *models.KitchenStation) (string, int) {
+	if station != nil {
+		if host := strings.TrimSpace(station.PrinterHost); host != "" {
+			return host, normalizePrinterPort(station.PrinterPort)
+		}
+	}
+	return resolvePrinter(settings, PrintTargetKOT)
+}
+
+// resolveJobPrinter picks the printer for a claimed job: the job's kitchen station when it
+// has one, otherwise the restaurant-level printer for the job's target, moved along the
+// failover chain when the job has been rerouted.
+func resolveJobPrinter(settings *models.RestaurantPrintSettings, stations map[string]models.KitchenStation, job models.PrintJob) (string, int) {
+	route := jobPrintRoute(settings, stations, job)
+	return route.Host, route.Port
+}
+
+// stationsByID loads the kitchen stations referenced by jobs. Deleted stations are simply
+// missing, so their queued slips fall back to the main KOT printer.
+func stationsByID(db *gorm.DB, restaurantID string, jobs []models.PrintJob) (map[string]models.KitchenStation, error) {
+	ids := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		if job.StationID != "" {
+			ids = append(ids, job.StationID)
+		}
+	}
+	out := make(map[string]models.KitchenStation, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var stations []models.KitchenStation
+	if err := db.Where("restaurant_id = ? AND id IN ?", restaurantID, ids).Find(&stations).Error; err != nil {
+		return nil, err
+	}
+	for _, station := range stations {
+		out[station.ID] = station
+	}
+	return out, nil
+}
+
+// CompleteJob records the agent's outcome for a job it claimed. A report for a job that is
+// no longer claimed by agentID (its lease expired and it was requeued, rerouted or claimed
+// again) is stale and changes nothing.
+func (s *PrintService) CompleteJob(restaurantID, jobID, agentID string, failed bool, errMsg string) error {
 	var job models.PrintJob
 	if err := s.db.Where("id = ? AND restaurant_id = ?", jobID, restaurantID).First(&job).Error; err != nil {
 		return err
@@ -620,11 +883,27 @@ func (s *PrintService) CompleteJob(restaurantID, jobID string, failed bool, errM
 	if failed {
 		status = PrintStatusFailed
 	}
-	return s.db.Model(&job).Updates(map[string]interface{}{
-		"status":        status,
-		"completed_at":  now,
-		"error_message": errMsg,
-	}).Error
+	res := s.db.Model(&models.PrintJob{}).
+		Where("id = ? AND status = ? AND claimed_by = ?", job.ID, PrintStatusClaimed, agentID).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"completed_at":  now,
+			"error_message": errMsg,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		log.Printf("print job %s: ignoring stale report from agent %s (job is %s, claimed by %q)", job.ID, agentID, job.Status, job.ClaimedBy)
+		return nil
+	}
+	if failed {
+		job.Status = status
+		job.ErrorMessage = errMsg
+		job.CompletedAt = &now
+		s.jobsFailed([]models.PrintJob{job})
+	}
+	return nil
 }
 
 // printItemNameAndCategory returns the menu item name and its category for slips.
@@ -641,21 +920,29 @@ func printItemNameAndCategory(it models.OrderItem) (name, category string) {
 
 func printItemDisplayName(it models.OrderItem, extraBlocklist []string) string {
 	name, category := printItemNameAndCategory(it)
-	return FormatItemDisplayName(name, category, it.VariantLabel, extraBlocklist)
+	return printSafeText(FormatItemDisplayName(name, category, it.VariantLabel, extraBlocklist))
 }
 
-func buildKOTPayload(restaurant models.Restaurant, order *models.Order, items []models.OrderItem, isAddOn bool) string {
+// buildKOTPayload renders a kitchen slip. station names the kitchen station the slip is
+// routed to ("" for the main KOT printer).
+func buildKOTPayload(restaurant models.Restaurant, order *models.Order, items []models.OrderItem, isAddOn bool, station string, paperWidthMm int) string {
 	var b strings.Builder
-	divider := "--------------------------------"
+	width := thermalColsForPaper(paperWidthMm)
+	divider := strings.Repeat("-", width)
 	if restaurant.Name != "" {
-		b.WriteString(restaurant.Name)
+		b.WriteString(printSafeText(restaurant.Name))
 		b.WriteByte('\n')
 	}
+	title := "KOT"
+	if station = strings.TrimSpace(station); station != "" {
+		title += " - " + strings.ToUpper(printSafeText(station))
+	}
+	title = printTall(printBold(title))
 	if isAddOn {
-		b.WriteString("KOT (ADD-ON)\n")
-	} else {
-		b.WriteString("KOT\n")
+		title += " " + printInvert("(ADD-ON)")
 	}
+	b.WriteString(title)
+	b.WriteByte('\n')
 	b.WriteString(divider)
 	b.WriteByte('\n')
 	num := order.TicketNumber
@@ -663,16 +950,19 @@ func buildKOTPayload(restaurant models.Restaurant, order *models.Order, items []
 		num = order.OrderNumber
 	}
 	if num > 0 {
-		b.WriteString(fmt.Sprintf("Order #%d\n", num))
+		b.WriteString(printBold(fmt.Sprintf("Order #%d", num)))
+		b.WriteByte('\n')
 	}
 	if order.OrderType == "counter" {
 		mode := order.ServiceMode
 		if mode == "" {
 			mode = "eat_here"
 		}
-		b.WriteString(fmt.Sprintf("Counter - %s\n", mode))
+		b.WriteString(kotHeadline(fmt.Sprintf("Counter - %s", mode), width))
+		b.WriteByte('\n')
 	} else if order.TableNumber != "" {
-		b.WriteString(fmt.Sprintf("Table: %s\n", order.TableNumber))
+		b.WriteString(kotHeadline(fmt.Sprintf("Table: %s", printSafeText(order.TableNumber)), width))
+		b.WriteByte('\n')
 	}
 	b.WriteString(time.Now().Format("02 Jan 3:04 PM"))
 	b.WriteByte('\n')
@@ -681,9 +971,10 @@ func buildKOTPayload(restaurant models.Restaurant, order *models.Order, items []
 	blocklist := ParseCategoryDisplayBlocklist(restaurant.CategoryDisplayBlocklist)
 	for _, it := range items {
 		name := printItemDisplayName(it, blocklist)
-		b.WriteString(fmt.Sprintf("%d x %s\n", it.Quantity, name))
+		b.WriteString(printBold(fmt.Sprintf("%d x %s", it.Quantity, name)))
+		b.WriteByte('\n')
 		if notes := strings.TrimSpace(it.Notes); notes != "" {
-			b.WriteString(fmt.Sprintf("   * %s\n", notes))
+			b.WriteString(fmt.Sprintf("   * %s\n", printSafeText(notes)))
 		}
 	}
 	b.WriteString(divider)
@@ -691,33 +982,83 @@ func buildKOTPayload(restaurant models.Restaurant, order *models.Order, items []
 	return b.String()
 }
 
-func buildBillPayload(restaurant models.Restaurant, order *models.Order, items []models.OrderItem, paperWidthMm int) string {
+// kotHeadline prints the table or counter line double size so it reads across the pass,
+// or double height only when it would not fit at double width.
+func kotHeadline(text string, width int) string {
+	if printTextWidth(text) <= width/2 {
+		return printLarge(text)
+	}
+	return printTall(printBold(text))
+}
+
+// printBillTaxSummary renders the HSN/SAC-wise GST table for the thermal bill.
+// Columns: code, rate, taxable value, then CGST+SGST (or IGST) as a single tax figure
+// so the table fits 32-column paper.
+func printBillTaxSummary(rows []GSTTaxSummaryRow, interState bool, width int) string {
+	amountW := (width - 14) / 2
+	if amountW < 7 {
+		amountW = 7
+	}
+	taxHead := "CGST+SGST"
+	if interState {
+		taxHead = "IGST"
+	}
+	if len(taxHead) > amountW {
+		taxHead = "Tax"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-8s%6s%*s%*s\n", "HSN/SAC", "Rate", amountW, "Taxable", amountW, taxHead))
+	for _, row := range rows {
+		b.WriteString(fmt.Sprintf("%-8s%6s%*.2f%*.2f\n",
+			row.HSNCode, formatGSTRate(row.Rate)+"%", amountW, row.TaxableValue, amountW, row.TaxAmount))
+	}
+	return b.String()
+}
+
+// printBillTenders lists each payment on the thermal bill, with its reference underneath.
+func printBillTenders(tenders []models.OrderTender, width int) string {
+	var b strings.Builder
+	for _, tender := range tenders {
+		b.WriteString(printPadLine(TenderLabel(tender.Method, tender.Provider), fmt.Sprintf("%.2f", tender.Amount), width))
+		b.WriteByte('\n')
+		if ref := strings.TrimSpace(tender.Reference); ref != "" {
+			b.WriteString("  Ref: " + printSafeText(ref) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// buildBillPayload renders a customer bill. logoURL, when set, prints as a raster logo on top.
+func buildBillPayload(restaurant models.Restaurant, order *models.Order, items []models.OrderItem, paperWidthMm int, logoURL string) string {
 	width := thermalColsForPaper(paperWidthMm)
 	var b strings.Builder
 	divider := strings.Repeat("-", width)
+	if logoURL = strings.TrimSpace(logoURL); logoURL != "" {
+		b.WriteString(printLogoBlock(logoURL))
+	}
 	if restaurant.Name != "" {
-		b.WriteString(printCenterLine(restaurant.Name, width))
+		b.WriteString(printCentered(printSafeText(restaurant.Name), width, printBold, printTall))
 		b.WriteByte('\n')
 	}
 	if restaurant.Address != "" {
-		for _, line := range wrapPrintWords(restaurant.Address, width) {
+		for _, line := range wrapPrintWords(printSafeText(restaurant.Address), width) {
 			b.WriteString(printCenterLine(line, width))
 			b.WriteByte('\n')
 		}
 	}
-	contact := restaurant.ContactNumber
+	contact := printSafeText(restaurant.ContactNumber)
 	if contact == "" {
-		contact = restaurant.Phone
+		contact = printSafeText(restaurant.Phone)
 	}
 	if contact != "" {
 		b.WriteString(printCenterLine("Ph: "+contact, width))
 		b.WriteByte('\n')
 	}
 	if gst := strings.TrimSpace(restaurant.GstNumber); gst != "" {
-		b.WriteString(printCenterLine("GSTIN: "+gst, width))
+		b.WriteString(printCenterLine("GSTIN: "+printSafeText(gst), width))
 		b.WriteByte('\n')
 	}
-	b.WriteString(printCenterLine("BILL", width))
+	b.WriteString(printCentered("BILL", width, printBold))
 	b.WriteByte('\n')
 	b.WriteString(divider)
 	b.WriteByte('\n')
@@ -725,21 +1066,24 @@ func buildBillPayload(restaurant models.Restaurant, order *models.Order, items [
 	if num == 0 {
 		num = order.OrderNumber
 	}
+	if inv := strings.TrimSpace(order.InvoiceNumber); inv != "" {
+		b.WriteString(fmt.Sprintf("Invoice: %s\n", printSafeText(inv)))
+	}
 	if num > 0 {
 		b.WriteString(fmt.Sprintf("Order: #%d\n", num))
 	}
 	if order.TableNumber != "" && order.OrderType != "counter" {
-		b.WriteString(fmt.Sprintf("Table: %s\n", order.TableNumber))
+		b.WriteString(fmt.Sprintf("Table: %s\n", printSafeText(order.TableNumber)))
 	}
 	if order.CustomerName != "" &&
 		order.CustomerName != "Guest" &&
 		order.CustomerName != "Takeaway" &&
 		order.CustomerName != "Counter" &&
 		order.CustomerName != "Self Service" {
-		b.WriteString(fmt.Sprintf("Customer: %s\n", order.CustomerName))
+		b.WriteString(fmt.Sprintf("Customer: %s\n", printSafeText(order.CustomerName)))
 	}
 	if phone := strings.TrimSpace(order.CustomerPhone); phone != "" {
-		b.WriteString(fmt.Sprintf("Phone: %s\n", phone))
+		b.WriteString(fmt.Sprintf("Phone: %s\n", printSafeText(phone)))
 	}
 	b.WriteString(time.Now().Format("02 Jan 2006 03:04 PM"))
 	b.WriteByte('\n')
@@ -750,7 +1094,8 @@ func buildBillPayload(restaurant models.Restaurant, order *models.Order, items [
 	if nameWidth < 8 {
 		nameWidth = 8
 	}
-	b.WriteString(fmt.Sprintf("%-*s%3s %7s %7s\n", nameWidth, "Item", "Qty", "Rate", "Price"))
+	b.WriteString(printUnderline(fmt.Sprintf("%-*s%3s %7s %7s", nameWidth, "Item", "Qty", "Rate", "Price")))
+	b.WriteByte('\n')
 	blocklist := ParseCategoryDisplayBlocklist(restaurant.CategoryDisplayBlocklist)
 	for _, it := range items {
 		name := printItemDisplayName(it, blocklist)
@@ -763,12 +1108,8 @@ func buildBillPayload(restaurant models.Restaurant, order *models.Order, items [
 			rate = lineTotal / float64(it.Quantity)
 		}
 		nameLines := wrapPrintWords(name, nameWidth)
-		first := nameLines[0]
-		if len(first) < nameWidth {
-			first = first + strings.Repeat(" ", nameWidth-len(first))
-		} else if len(first) > nameWidth {
-			first = first[:nameWidth]
-		}
+		first := printTruncate(nameLines[0], nameWidth)
+		first += strings.Repeat(" ", nameWidth-printTextWidth(first))
 		b.WriteString(fmt.Sprintf("%s%3d %7.2f %7.2f\n", first, it.Quantity, rate, lineTotal))
 		for i := 1; i < len(nameLines); i++ {
 			b.WriteString(nameLines[i])
@@ -781,29 +1122,52 @@ func buildBillPayload(restaurant models.Restaurant, order *models.Order, items [
 		b.WriteString(printPadLine("Subtotal", fmt.Sprintf("%.2f", order.SubTotal), width))
 		b.WriteByte('\n')
 	}
+	var slabs []GSTSlab
+	interState := IsInterStateSupply(&restaurant, order.PlaceOfSupply)
 	if order.TaxAmount > 0 {
-		b.WriteString(printPadLine("GST", fmt.Sprintf("%.2f", order.TaxAmount), width))
-		b.WriteByte('\n')
+		orderTax := CalculateRestaurantOrderTax(orderItemsGrossByRate(items), order.DiscountAmount, SettingsFromRestaurant(&restaurant))
+		slabs = SplitGSTSlabs(orderTax.Slabs, interState)
+		if len(slabs) == 0 {
+			b.WriteString(printPadLine("GST", fmt.Sprintf("%.2f", order.TaxAmount), width))
+			b.WriteByte('\n')
+		}
+		for _, line := range GSTComponentLines(slabs) {
+			b.WriteString(printPadLine(line.Label, fmt.Sprintf("%.2f", line.Amount), width))
+			b.WriteByte('\n')
+		}
 	}
 	if order.DiscountAmount > 0 {
 		b.WriteString(printPadLine("Discount", fmt.Sprintf("-%.2f", order.DiscountAmount), width))
 		b.WriteByte('\n')
 	}
-	b.WriteString(printPadLine("TOTAL", fmt.Sprintf("Rs.%.2f", order.Total), width))
+	b.WriteString(printTall(printBold(printPadLine("TOTAL", fmt.Sprintf("Rs.%.2f", order.Total), width))))
 	b.WriteByte('\n')
-	if order.PaymentMethod != "" {
-		b.WriteString(fmt.Sprintf("Payment: %s\n", strings.ToUpper(order.PaymentMethod)))
+	if len(order.Tenders) > 0 {
+		b.WriteString(printBillTenders(order.Tenders, width))
+	} else if order.PaymentMethod != "" {
+		b.WriteString(fmt.Sprintf("Payment: %s\n", strings.ToUpper(printSafeText(order.PaymentMethod))))
+	}
+	if summary := BuildGSTTaxSummary(items, slabs); len(summary) > 0 {
+		b.WriteString(divider)
+		b.WriteByte('\n')
+		b.WriteString(printBillTaxSummary(summary, interState, width))
+		if code := GSTStateCode(order.PlaceOfSupply); code != "" {
+			b.WriteString("Place of supply: " + PlaceOfSupplyLabel(code) + "\n")
+		}
 	}
 	b.WriteString(divider)
 	b.WriteByte('\n')
-	b.WriteString(printCenterLine("Thank you!", width))
+	if inv := strings.TrimSpace(order.InvoiceNumber); inv != "" {
+		b.WriteString(printBarcodeBlock(inv))
+	}
+	b.WriteString(printCentered("Thank you!", width))
 	b.WriteByte('\n')
 	if order.OrderType == "counter" {
 		if token := strings.TrimSpace(order.TrackingToken); token != "" {
 			trackingURL := BuildTrackingURL(token)
 			b.WriteString(divider)
 			b.WriteByte('\n')
-			b.WriteString(printCenterLine("Scan to track order", width))
+			b.WriteString(printCentered("Scan to track order", width))
 			b.WriteByte('\n')
 			b.WriteString("<<<BILLGENIE_QR>>>\n")
 			b.WriteString(trackingURL)
@@ -818,6 +1182,73 @@ func buildBillPayload(restaurant models.Restaurant, order *models.Order, items [
 	return b.String()
 }
 
+// printRuneWidth is the number of columns a rune takes on the slip. Combining marks such
+// as Devanagari viramas and above/below vowel signs sit on their base letter and take
+// none; wide East Asian characters take two.
+func printRuneWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1f64f, r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// printTextWidth is the number of slip columns text takes (not its length in bytes).
+func printTextWidth(text string) int {
+	n := 0
+	for _, r := range text {
+		n += printRuneWidth(r)
+	}
+	return n
+}
+
+// printTruncate cuts text to at most width columns, keeping combining marks with their base.
+func printTruncate(text string, width int) string {
+	n := 0
+	for i, r := range text {
+		w := printRuneWidth(r)
+		if n+w > width {
+			return text[:i]
+		}
+		n += w
+	}
+	return text
+}
+
+// printTail keeps the last width columns of text.
+func printTail(text string, width int) string {
+	runes := []rune(text)
+	n := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		n += printRuneWidth(runes[i])
+		if n > width {
+			return strings.TrimLeftFunc(string(runes[i+1:]), func(r rune) bool { return printRuneWidth(r) == 0 })
+		}
+	}
+	return text
+}
+
+// splitPrintWord breaks a word wider than width into width-column pieces.
+func splitPrintWord(word string, width int) []string {
+	var parts []string
+	for printTextWidth(word) > width {
+		head := printTruncate(word, width)
+		if head == "" {
+			// A single rune wider than the line; print it on its own.
+			_, size := utf8.DecodeRuneInString(word)
+			head = word[:size]
+		}
+		parts = append(parts, head)
+		word = word[len(head):]
+	}
+	return append(parts, word)
+}
+
 func wrapPrintWords(text string, width int) []string {
 	fields := strings.Fields(strings.TrimSpace(text))
 	if len(fields) == 0 {
@@ -829,32 +1260,16 @@ func wrapPrintWords(text string, width int) []string {
 	var lines []string
 	current := ""
 	for _, word := range fields {
-		if current == "" {
-			if len(word) <= width {
-				current = word
-			} else {
-				for len(word) > width {
-					lines = append(lines, word[:width])
-					word = word[width:]
-				}
-				current = word
-			}
+		if current != "" && printTextWidth(current)+1+printTextWidth(word) <= width {
+			current = current + " " + word
 			continue
 		}
-		if len(current)+1+len(word) <= width {
-			current = current + " " + word
-		} else {
+		if current != "" {
 			lines = append(lines, current)
-			if len(word) <= width {
-				current = word
-			} else {
-				for len(word) > width {
-					lines = append(lines, word[:width])
-					word = word[width:]
-				}
-				current = word
-			}
 		}
+		parts := splitPrintWord(word, width)
+		lines = append(lines, parts[:len(parts)-1]...)
+		current = parts[len(parts)-1]
 	}
 	if current != "" {
 		lines = append(lines, current)
@@ -866,23 +1281,20 @@ func printPadLine(left, right string, width int) string {
 	if width <= 0 {
 		width = 32
 	}
-	r := right
-	if len(r) > width {
-		r = r[len(r)-width:]
-	}
-	maxLeft := width - len(r) - 1
+	r := printTail(right, width)
+	maxLeft := width - printTextWidth(r) - 1
 	if maxLeft < 0 {
 		maxLeft = 0
 	}
 	l := left
-	if len(l) > maxLeft {
+	if printTextWidth(l) > maxLeft {
 		if maxLeft <= 1 {
 			l = ""
 		} else {
-			l = l[:maxLeft-1] + "."
+			l = printTruncate(l, maxLeft-1) + "."
 		}
 	}
-	spaces := width - len(l) - len(r)
+	spaces := width - printTextWidth(l) - printTextWidth(r)
 	if spaces < 1 {
 		spaces = 1
 	}
@@ -894,10 +1306,8 @@ func printCenterLine(text string, width int) string {
 	if width <= 0 {
 		width = 32
 	}
-	if len(t) > width {
-		t = t[:width]
-	}
-	pad := width - len(t)
+	t = printTruncate(t, width)
+	pad := width - printTextWidth(t)
 	if pad <= 0 {
 		return t
 	}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxSpoolSyncBatch = 100
+
+// SpooledPrintJob is a slip the agent printed from its LAN spool while the API was
+// unreachable, replayed from the agent's on-disk journal once it reconnects.
+type SpooledPrintJob struct {
+	SpoolID     string    `json:"spool_id"`
+	JobType     string    `json:"job_type"`
+	Target      string    `json:"target"`
+	OrderID     string    `json:"order_id"`
+	StationID   string    `json:"station_id"`
+	PayloadText string    `json:"payload_text"`
+	Status      string    `json:"status"` // done | failed
+	Error       string    `json:"error"`
+	PrintedAt   time.Time `json:"printed_at"`
+}
+
+// spooledJobRecord turns a journal entry into a completed PrintJob row.
+func spooledJobRecord(restaurantID, agentID string, in SpooledPrintJob, now time.Time) (models.PrintJob, error) {
+	spoolID := strings.TrimSpace(in.SpoolID)
+	if spoolID == "" || len(spoolID) > 64 {
+		return models.PrintJob{}, errors.New("spool_id is required (max 64 chars)")
+	}
+	if strings.TrimSpace(in.PayloadText) == "" {
+		return models.PrintJob{}, errors.New("payload_text is required")
+	}
+	jobType := strings.ToLower(strings.TrimSpace(in.JobType))
+	target := strings.TrimSpace(in.Target)
+	switch jobType {
+	case PrintJobTypeBill:
+		if target == "" {
+			target = PrintTargetBill
+		}
+	case PrintJobTypeKOT, "":
+		jobType = PrintJobTypeKOT
+		if target == "" {
+			target = PrintTargetKOT
+		}
+	default:
+		return models.PrintJob{}, errors.New("job_type must be kot or bill")
+	}
+	if target != PrintTargetKOT && target != PrintTargetBill {
+		return models.PrintJob{}, errors.New("target must be kot_printer or bill_printer")
+	}
+	status := PrintStatusDone
+	if in.Status == PrintStatusFailed {
+		status = PrintStatusFailed
+	}
+	printedAt := in.PrintedAt.UTC()
+	if printedAt.IsZero() || printedAt.After(now) {
+		printedAt = now
+	}
+	return models.PrintJob{
+		RestaurantID: restaurantID,
+		OrderID:      strings.TrimSpace(in.OrderID),
+		JobType:      jobType,
+		Target:       target,
+		StationID:    strings.TrimSpace(in.StationID),
+		SpoolID:      spoolID,
+		PayloadText:  in.PayloadText,
+		Status:       status,
+		ClaimedBy:    agentID,
+		ClaimedAt:    &printedAt,
+		CompletedAt:  &printedAt,
+		ErrorMessage: strings.TrimSpace(in.Error),
+		Attempts:     1,
+		CreatedAt:    printedAt,
+	}, nil
+}
+
+// SpoolSyncRejection is a journal entry the API would not store, with the reason.
+type SpoolSyncRejection struct {
+	SpoolID string `json:"spool_id"`
+	Error   string `json:"error"`
+}
+
+// SyncSpooledJobs records offline prints as completed jobs so they show up in print
+// history. Rows are upserted on (restaurant_id, spool_id), so an entry already synced, or
+// sent by two overlapping syncs, is acknowledged again without a new row and the agent can
+// safely resend after a dropped response. Returns the spool ids stored and the entries
+// rejected as invalid, each with the reason; the agent logs and drops both.
+func (s *PrintService) SyncSpooledJobs(restaurantID, agentID string, jobs []SpooledPrintJob) (synced []string, rejected []SpoolSyncRejection, err error) {
+	if len(jobs) > maxSpoolSyncBatch {
+		jobs = jobs[:maxSpoolSyncBatch]
+	}
+	now := time.Now().UTC()
+	synced = make([]string, 0, len(jobs))
+	rejected = []SpoolSyncRejection{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, in := range jobs {
+			job, err := spooledJobRecord(restaurantID, agentID, in, now)
+			if err != nil {
+				rejected = append(rejected, SpoolSyncRejection{SpoolID: strings.TrimSpace(in.SpoolID), Error: err.Error()})
+				continue
+			}
+			if job.OrderID != "" {
+				var count int64
+				if err := tx.Model(&models.Order{}).
+					Where("id = ? AND restaurant_id = ?", job.OrderID, restaurantID).
+					Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					job.OrderID = ""
+				}
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:     []clause.Column{{Name: "restaurant_id"}, {Name: "spool_id"}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "spool_id <> ''"}}},
+				DoNothing:   true,
+			}).Create(&job).Error; err != nil {
+				return err
+			}
+			synced = append(synced, job.SpoolID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return synced, rejected, nil
+}
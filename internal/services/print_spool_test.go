@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpooledJobRecord(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	printed := now.Add(-40 * time.Minute)
+
+	job, err := spooledJobRecord("r1", "counter-pc", SpooledPrintJob{
+		SpoolID:     "sp-1",
+		JobType:     "bill",
+		PayloadText: "BILL",
+		Status:      "done",
+		PrintedAt:   printed,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Target != PrintTargetBill || job.Status != PrintStatusDone || job.ClaimedBy != "counter-pc" {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if !job.CreatedAt.Equal(printed) || job.CompletedAt == nil || !job.CompletedAt.Equal(printed) {
+		t.Fatalf("printed time not kept: %+v", job)
+	}
+
+	job, err = spooledJobRecord("r1", "counter-pc", SpooledPrintJob{
+		SpoolID:     "sp-2",
+		PayloadText: "KOT",
+		Status:      "failed",
+		Error:       "connection refused",
+		PrintedAt:   now.Add(time.Hour),
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.JobType != PrintJobTypeKOT || job.Target != PrintTargetKOT || job.Status != PrintStatusFailed {
+		t.Fatalf("kot default / failed status: %+v", job)
+	}
+	if !job.CreatedAt.Equal(now) {
+		t.Fatalf("future printed_at should clamp to now, got %s", job.CreatedAt)
+	}
+
+	if _, err := spooledJobRecord("r1", "a", SpooledPrintJob{PayloadText: "x"}, now); err == nil {
+		t.Fatal("expected error without spool_id")
+	}
+	if _, err := spooledJobRecord("r1", "a", SpooledPrintJob{SpoolID: "x", JobType: "report", PayloadText: "x"}, now); err == nil {
+		t.Fatal("expected error for unsupported job type")
+	}
+}
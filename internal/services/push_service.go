@@ -19,6 +19,8 @@ const (
 	PushAlertAssistance    = "assistance"
 	PushAlertItemsReady    = "items_ready"
 	PushAlertItemCancelled = "item_cancelled"
+	PushAlertPrintFailed   = "print_failed"
+	PushAlertGuestOrder    = "guest_order"
 )
 
 // PushService delivers Expo push notifications to staff devices.
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefundLineRequest credits some units of one order line.
+type RefundLineRequest struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	// Restock puts the units back into stock. Defaults to true only for readily available
+	// (packaged) items — prepared food that went out cannot be resold.
+	Restock *bool `json:"restock,omitempty"`
+}
+
+// RefundRequest is a full or per-line refund against a paid order.
+type RefundRequest struct {
+	Reason       string              `json:"reason"`
+	RefundMethod string              `json:"refund_method"` // cash | upi | card | original
+	Full         bool                `json:"full"`
+	Lines        []RefundLineRequest `json:"lines"`
+}
+
+// RefundResult is the credit note issued plus the order after the refund.
+type RefundResult struct {
+	CreditNote          *models.CreditNote
+	Order               *models.Order
+	RestoredIngredients []models.Ingredient
+}
+
+var validRefundMethods = map[string]bool{"cash": true, "upi": true, "card": true, "original": true}
+
+// IsOrderPaid reports whether an order has been settled and can be refunded. Counter orders
+// are paid up front and may still be in the kitchen.
+func IsOrderPaid(order *models.Order) bool {
+	if order.Status == "cancelled" {
+		return false
+	}
+	return order.Status == "completed" || strings.TrimSpace(order.PaymentMethod) != ""
+}
+
+// CreditNoteLineAmounts computes the credited sub_total/tax/total for qty units of an order line.
+// The order-level discount is shared across lines by gross, so refunding every line credits
+// exactly what was billed.
+func CreditNoteLineAmounts(item models.OrderItem, qty int, orderGross, orderDiscount float64, settings RestaurantTaxSettings) OrderTax {
+	if qty <= 0 || item.Quantity <= 0 {
+		return OrderTax{}
+	}
+	gross := item.Total * float64(qty) / float64(item.Quantity)
+	discountShare := 0.0
+	if orderGross > 0 && orderDiscount > 0 {
+		discountShare = orderDiscount * gross / orderGross
+	}
+	return CalculateRestaurantOrderTax(map[float64]float64{OrderItemGSTRate(item): gross}, discountShare, settings)
+}
+
+func roundPaise(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// RefundOrder issues a credit note against a paid order, marks the refunded quantities, restocks
+// where requested and records a refund transaction.
+func (s *OrderService) RefundOrder(restaurantID, orderID, userID string, req RefundRequest) (*RefundResult, error) {
+	method := strings.ToLower(strings.TrimSpace(req.RefundMethod))
+	if method == "" {
+		method = "original"
+	}
+	if !validRefundMethods[method] {
+		return nil, errors.New("refund_method must be cash, upi, card or original")
+	}
+	if !req.Full && len(req.Lines) == 0 {
+		return nil, errors.New("select at least one item to refund, or request a full refund")
+	}
+
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND restaurant_id = ?", orderID, restaurantID).
+		First(&order).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	if !IsOrderPaid(&order) {
+		tx.Rollback()
+		return nil, errors.New("only paid orders can be refunded; cancel unpaid orders instead")
+	}
+	remaining := roundPaise(order.Total - order.RefundedAmount)
+	if remaining <= 0 {
+		tx.Rollback()
+		return nil, errors.New("order is already fully refunded")
+	}
+
+	var restaurant models.Restaurant
+	if err := tx.Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	settings := SettingsFromRestaurant(&restaurant)
+
+	var items []models.OrderItem
+	if err := tx.Preload("MenuItem").Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	orderGross := 0.0
+	byID := make(map[string]*models.OrderItem, len(items))
+	for i := range items {
+		if items[i].Status == "cancelled" {
+			continue
+		}
+		orderGross += items[i].Total
+		byID[items[i].ID] = &items[i]
+	}
+
+	type refundLine struct {
+		item    *models.OrderItem
+		qty     int
+		restock bool
+	}
+	var lines []refundLine
+	if req.Full {
+		restockOverride := make(map[string]*bool, len(req.Lines))
+		for _, l := range req.Lines {
+			restockOverride[l.OrderItemID] = l.Restock
+		}
+		for i := range items {
+			item := &items[i]
+			if item.Status == "cancelled" || item.Quantity-item.RefundedQuantity <= 0 {
+				continue
+			}
+			lines = append(lines, refundLine{item: item, qty: item.Quantity - item.RefundedQuantity, restock: defaultRestock(item, restockOverride[item.ID])})
+		}
+	} else {
+		seen := make(map[string]bool, len(req.Lines))
+		for _, l := range req.Lines {
+			item, ok := byID[l.OrderItemID]
+			if !ok {
+				tx.Rollback()
+				return nil, fmt.Errorf("order item %s not found on this order", l.OrderItemID)
+			}
+			if seen[l.OrderItemID] {
+				tx.Rollback()
+				return nil, fmt.Errorf("order item %s listed more than once", l.OrderItemID)
+			}
+			seen[l.OrderItemID] = true
+			left := item.Quantity - item.RefundedQuantity
+			if l.Quantity < 1 || l.Quantity > left {
+				tx.Rollback()
+				return nil, fmt.Errorf("refund quantity for item %s must be between 1 and %d", l.OrderItemID, left)
+			}
+			lines = append(lines, refundLine{item: item, qty: l.Quantity, restock: defaultRestock(item, l.Restock)})
+		}
+	}
+	if len(lines) == 0 {
+		tx.Rollback()
+		return nil, errors.New("nothing left to refund on this order")
+	}
+
+	now := time.Now()
+	number, financialYear, seq, err := AssignCreditNoteNumber(tx, restaurantID, now)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	note := models.CreditNote{
+		RestaurantID:     restaurantID,
+		OrderID:          orderID,
+		InvoiceNumber:    order.InvoiceNumber,
+		CreditNoteNumber: number,
+		FinancialYear:    financialYear,
+		Seq:              seq,
+		Reason:           strings.TrimSpace(req.Reason),
+		RefundMethod:     method,
+		CreatedByUserID:  userID,
+	}
+	var restockQty []MenuItemQuantity
+	for _, l := range lines {
+		amounts := CreditNoteLineAmounts(*l.item, l.qty, orderGross, order.DiscountAmount, settings)
+		name := ""
+		if l.item.MenuItem != nil {
+			name = l.item.MenuItem.Name
+		}
+		if l.item.VariantLabel != "" {
+			name = strings.TrimSpace(name + " (" + l.item.VariantLabel + ")")
+		}
+		note.Lines = append(note.Lines, models.CreditNoteLine{
+			OrderItemID: l.item.ID,
+			MenuID:      l.item.MenuID,
+			Name:        name,
+			Quantity:    l.qty,
+			UnitRate:    l.item.UnitRate,
+			TaxRate:     OrderItemGSTRate(*l.item),
+			HSNCode:     OrderItemHSNCode(*l.item),
+			SubTotal:    roundPaise(amounts.SubTotal),
+			TaxAmount:   roundPaise(amounts.TaxAmount),
+			Total:       roundPaise(amounts.Total),
+			Restocked:   l.restock,
+		})
+		note.SubTotal += amounts.SubTotal
+		note.TaxAmount += amounts.TaxAmount
+		note.Total += amounts.Total
+
+		if err := tx.Model(&models.OrderItem{}).Where("id = ?", l.item.ID).
+			Update("refunded_quantity", gorm.Expr("refunded_quantity + ?", l.qty)).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if l.restock {
+			if err := tx.Model(&models.Inventory{}).
+				Where("restaurant_id = ? AND menu_item_id = ?", restaurantID, l.item.MenuID).
+				Update("quantity", gorm.Expr("quantity + ?", l.qty)).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			variantID := ""
+			if l.item.VariantID != nil {
+				variantID = *l.item.VariantID
+			}
+			restockQty = append(restockQty, MenuItemQuantity{MenuItemID: l.item.MenuID, Quantity: l.qty, RecipeScale: 1, VariantID: variantID})
+		}
+	}
+	note.SubTotal = roundPaise(note.SubTotal)
+	note.TaxAmount = roundPaise(note.TaxAmount)
+	note.Total = roundPaise(note.Total)
+
+	fullyRefunded := true
+	for i := range items {
+		if items[i].Status == "cancelled" {
+			continue
+		}
+		credited := items[i].RefundedQuantity
+		for _, l := range lines {
+			if l.item.ID == items[i].ID {
+				credited += l.qty
+			}
+		}
+		if credited < items[i].Quantity {
+			fullyRefunded = false
+			break
+		}
+	}
+	// The last credit note absorbs paise rounding so the order nets to exactly zero.
+	if fullyRefunded || note.Total > remaining {
+		note.Total = remaining
+	}
+	note.FullRefund = fullyRefunded
+
+	if err := tx.Create(&note).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&order).Updates(map[string]interface{}{
+		"refunded_amount": gorm.Expr("refunded_amount + ?", note.Total),
+		"updated_at":      now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	txnMethod := method
+	if method == "original" {
+		txnMethod = order.PaymentMethod
+	}
+	if err := RecordTransaction(tx, models.Transaction{
+		RestaurantID:    restaurantID,
+		OrderID:         orderID,
+		Amount:          note.Total,
+		TransactionType: TransactionTypeRefund,
+		PaymentMethod:   txnMethod,
+		UserID:          userID,
+		ReferenceID:     note.ID,
+		Notes:           note.CreditNoteNumber,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var restoredIngredients []models.Ingredient
+	if len(restockQty) > 0 {
+		restoredIngredients, err = RestoreIngredientsForMenuItems(tx, restaurantID, restockQty)
+		if err != nil {
+			log.Printf("❌ [RefundOrder] Ingredient stock restore failed: %v", err)
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Credit note %s issued for order #%d", note.CreditNoteNumber, order.OrderNumber)
+
+	reloaded, err := s.reloadOrderWithItems(orderID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return &RefundResult{CreditNote: &note, Order: reloaded, RestoredIngredients: restoredIngredients}, nil
+}
+
+func defaultRestock(item *models.OrderItem, override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return item.MenuItem != nil && item.MenuItem.ReadilyAvailable
+}
+
+// ListCreditNotes returns the credit notes issued against an order, oldest first.
+func (s *OrderService) ListCreditNotes(restaurantID, orderID string) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+	err := s.db.Preload("Lines").
+		Where("restaurant_id = ? AND order_id = ?", restaurantID, orderID).
+		Order("created_at ASC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// RefundTotals sums credit notes issued in a window.
+type RefundTotals struct {
+	Count     int64   `json:"count"`
+	Amount    float64 `json:"amount"`
+	TaxAmount float64 `json:"tax_amount"`
+}
+
+// RefundTotalsForRange sums credit notes issued in [from, toEnd), optionally limited to an order type.
+func (s *OrderService) RefundTotalsForRange(restaurantID string, from, toEnd time.Time, orderType string) (RefundTotals, error) {
+	var out RefundTotals
+	query := s.db.Table("credit_notes").
+		Select("COUNT(*) AS count, COALESCE(SUM(credit_notes.total), 0) AS amount, COALESCE(SUM(credit_notes.tax_amount), 0) AS tax_amount").
+		Where("credit_notes.restaurant_id = ? AND credit_notes.created_at >= ? AND credit_notes.created_at < ?", restaurantID, from, toEnd)
+	if ot := normalizeSalesOrderType(orderType); ot != "all" {
+		orders := applySalesOrderTypeFilter(s.db.Model(&models.Order{}).Select("id").Where("restaurant_id = ?", restaurantID), ot)
+		query = query.Where("credit_notes.order_id IN (?)", orders)
+	}
+	err := query.Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestCreditNoteLineAmounts_AllLinesMatchOrderTotal(t *testing.T) {
+	rate5, rate18 := 5.0, 18.0
+	items := []models.OrderItem{
+		{ID: "a", Quantity: 2, UnitRate: 100, Total: 200, TaxRate: &rate5},
+		{ID: "b", Quantity: 1, UnitRate: 300, Total: 300, TaxRate: &rate18},
+	}
+	settings := RestaurantTaxSettings{}
+	discount := 50.0
+	order := CalculateRestaurantOrderTax(orderItemsGrossByRate(items), discount, settings)
+
+	var total, tax float64
+	for _, item := range items {
+		line := CreditNoteLineAmounts(item, item.Quantity, 500, discount, settings)
+		total += line.Total
+		tax += line.TaxAmount
+	}
+	if !approxEqual(total, order.Total) {
+		t.Fatalf("credited total %.2f, billed %.2f", total, order.Total)
+	}
+	if !approxEqual(tax, order.TaxAmount) {
+		t.Fatalf("credited tax %.2f, billed %.2f", tax, order.TaxAmount)
+	}
+}
+
+func TestCreditNoteLineAmounts_PartialQuantityInclusive(t *testing.T) {
+	rate := 5.0
+	item := models.OrderItem{Quantity: 4, UnitRate: 105, Total: 420, TaxRate: &rate}
+	line := CreditNoteLineAmounts(item, 1, 420, 0, RestaurantTaxSettings{PricesIncludeGST: true})
+	if !approxEqual(line.Total, 105) || !approxEqual(line.SubTotal, 100) || !approxEqual(line.TaxAmount, 5) {
+		t.Fatalf("got %+v", line)
+	}
+}
+
+func TestIsOrderPaid(t *testing.T) {
+	cases := []struct {
+		order models.Order
+		want  bool
+	}{
+		{models.Order{Status: "completed"}, true},
+		{models.Order{Status: "preparing", OrderType: "counter", PaymentMethod: "cash"}, true},
+		{models.Order{Status: "pending"}, false},
+		{models.Order{Status: "cancelled", PaymentMethod: "cash"}, false},
+	}
+	for _, tc := range cases {
+		if got := IsOrderPaid(&tc.order); got != tc.want {
+			t.Errorf("IsOrderPaid(%+v) = %v, want %v", tc.order, got, tc.want)
+		}
+	}
+}
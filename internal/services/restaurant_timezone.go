@@ -2,56 +2,190 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
 )
 
 const defaultRestaurantTimezone = "Asia/Kolkata"
 
-// RestaurantLocation returns the timezone used for business-day boundaries (order history, counter tickets).
-func RestaurantLocation() *time.Location {
-	tz := os.Getenv("APP_TIMEZONE")
+// DefaultRestaurantTimezone returns the IANA zone name new and legacy restaurants fall back to
+// (APP_TIMEZONE when set and valid, otherwise Asia/Kolkata).
+func DefaultRestaurantTimezone() string {
+	tz := strings.TrimSpace(os.Getenv("APP_TIMEZONE"))
 	if tz == "" {
-		tz = defaultRestaurantTimezone
+		return defaultRestaurantTimezone
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return defaultRestaurantTimezone
 	}
-	loc, err := time.LoadLocation(tz)
+	return tz
+}
+
+// RestaurantLocation returns the process-wide default timezone. Prefer LocationForRestaurant
+// or LoadBusinessCalendar for anything scoped to a tenant.
+func RestaurantLocation() *time.Location {
+	loc, err := time.LoadLocation(DefaultRestaurantTimezone())
 	if err != nil {
 		return time.UTC
 	}
 	return loc
 }
 
-// StartOfRestaurantDay returns midnight at the start of the calendar day for t in the restaurant timezone.
-func StartOfRestaurantDay(t time.Time) time.Time {
-	loc := RestaurantLocation()
-	inLoc := t.In(loc)
-	y, m, d := inLoc.Date()
-	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+// NormalizeRestaurantTimezone validates an IANA zone name (e.g. "Asia/Dubai") and returns it trimmed.
+func NormalizeRestaurantTimezone(tz string) (string, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return "", errors.New("timezone is required")
+	}
+	// time.LoadLocation accepts "" and "Local", neither of which is meaningful per tenant.
+	if tz == "Local" {
+		return "", errors.New("timezone must be an IANA zone name such as Asia/Kolkata")
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", errors.New("timezone must be an IANA zone name such as Asia/Kolkata")
+	}
+	return tz, nil
+}
+
+// LocationForRestaurant returns the restaurant's business-day timezone, falling back to the default.
+func LocationForRestaurant(r *models.Restaurant) *time.Location {
+	if r == nil || strings.TrimSpace(r.Timezone) == "" {
+		return RestaurantLocation()
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(r.Timezone))
+	if err != nil {
+		return RestaurantLocation()
+	}
+	return loc
+}
+
+// maxBusinessDayStartMinutes keeps the cutoff in the morning so a business day is always
+// labelled with the calendar date its service started on.
+const maxBusinessDayStartMinutes = 12*60 - 1
+
+// parseBusinessDayStart parses "HH:MM" into minutes after midnight.
+func parseBusinessDayStart(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, errors.New("business_day_start must be HH:MM")
+	}
+	h, errH := strconv.Atoi(parts[0])
+	m, errM := strconv.Atoi(parts[1])
+	if errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, errors.New("business_day_start must be HH:MM")
+	}
+	minutes := h*60 + m
+	if minutes > maxBusinessDayStartMinutes {
+		return 0, errors.New("business_day_start must be between 00:00 and 11:59")
+	}
+	return minutes, nil
+}
+
+// NormalizeBusinessDayStart validates a "day starts at" value and returns it zero-padded (e.g. "3:00" → "03:00").
+func NormalizeBusinessDayStart(value string) (string, error) {
+	minutes, err := parseBusinessDayStart(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60), nil
+}
+
+// BusinessCalendar is a restaurant's timezone plus the local time its trading day starts.
+// A bar with a 03:00 cutoff books a 01:00 order on the previous business day.
+type BusinessCalendar struct {
+	Location *time.Location
+	// DayStartMinutes is minutes after local midnight when the business day rolls over.
+	DayStartMinutes int
+}
+
+// BusinessCalendarForRestaurant builds the calendar from the restaurant's timezone and cutoff.
+func BusinessCalendarForRestaurant(r *models.Restaurant) BusinessCalendar {
+	cal := BusinessCalendar{Location: LocationForRestaurant(r)}
+	if r != nil {
+		if minutes, err := parseBusinessDayStart(r.BusinessDayStart); err == nil {
+			cal.DayStartMinutes = minutes
+		}
+	}
+	return cal
+}
+
+// DefaultBusinessCalendar is the process-wide timezone with a midnight cutoff.
+func DefaultBusinessCalendar() BusinessCalendar {
+	return BusinessCalendar{Location: RestaurantLocation()}
+}
+
+// LoadBusinessCalendar reads only the timezone and cutoff columns for a restaurant.
+func LoadBusinessCalendar(db *gorm.DB, restaurantID string) BusinessCalendar {
+	var restaurant models.Restaurant
+	if err := db.Select("id", "timezone", "business_day_start").
+		Where("id = ?", restaurantID).
+		First(&restaurant).Error; err != nil {
+		return DefaultBusinessCalendar()
+	}
+	return BusinessCalendarForRestaurant(&restaurant)
+}
+
+func (c BusinessCalendar) location() *time.Location {
+	if c.Location == nil {
+		return RestaurantLocation()
+	}
+	return c.Location
 }
 
-// ParseHistoryDateRange parses inclusive YYYY-MM-DD bounds into [from, toEnd) in the restaurant timezone.
-func ParseHistoryDateRange(fromStr, toStr string) (from, toEnd time.Time, err error) {
-	loc := RestaurantLocation()
+// DayStart returns the instant the business day labelled y-m-d begins.
+func (c BusinessCalendar) DayStart(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, c.DayStartMinutes/60, c.DayStartMinutes%60, 0, 0, c.location())
+}
+
+// BusinessDate returns the business day t falls in, as local midnight of that date.
+func (c BusinessCalendar) BusinessDate(t time.Time) time.Time {
+	start := StartOfRestaurantDay(t, c)
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, c.location())
+}
 
+// StartOfRestaurantDay returns when the business day containing t started.
+func StartOfRestaurantDay(t time.Time, cal BusinessCalendar) time.Time {
+	inLoc := t.In(cal.location())
+	y, m, d := inLoc.Date()
+	start := cal.DayStart(y, m, d)
+	if inLoc.Before(start) {
+		start = cal.DayStart(y, m, d-1)
+	}
+	return start
+}
+
+// ParseHistoryDateRange parses inclusive YYYY-MM-DD business dates into [from, toEnd).
+func ParseHistoryDateRange(fromStr, toStr string, cal BusinessCalendar) (from, toEnd time.Time, err error) {
+	loc := cal.location()
 	if fromStr == "" {
-		now := time.Now().In(loc)
-		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		from = StartOfRestaurantDay(time.Now(), cal)
 	} else {
-		from, err = time.ParseInLocation("2006-01-02", fromStr, loc)
-		if err != nil {
+		day, parseErr := time.ParseInLocation("2006-01-02", fromStr, loc)
+		if parseErr != nil {
 			return time.Time{}, time.Time{}, errors.New("invalid from date; use YYYY-MM-DD")
 		}
+		from = cal.DayStart(day.Year(), day.Month(), day.Day())
 	}
 
 	if toStr == "" {
-		toEnd = from.Add(24 * time.Hour)
+		toEnd = cal.DayStart(from.Year(), from.Month(), from.Day()+1)
 	} else {
-		var toDay time.Time
-		toDay, err = time.ParseInLocation("2006-01-02", toStr, loc)
-		if err != nil {
+		toDay, parseErr := time.ParseInLocation("2006-01-02", toStr, loc)
+		if parseErr != nil {
 			return time.Time{}, time.Time{}, errors.New("invalid to date; use YYYY-MM-DD")
 		}
-		toEnd = toDay.Add(24 * time.Hour)
+		toEnd = cal.DayStart(toDay.Year(), toDay.Month(), toDay.Day()+1)
 	}
 
 	if !toEnd.After(from) {
@@ -61,5 +195,13 @@ func ParseHistoryDateRange(fromStr, toStr string) (from, toEnd time.Time, err er
 	return from, toEnd, nil
 }
 
+// HistoryRangeDates turns a [from, toEnd) range from ParseHistoryDateRange back into the
+// inclusive YYYY-MM-DD business dates it covers. toEnd is the start of the day after the
+// last one, so the last date is the business day of the instant before it; stepping back
+// a fixed 24 hours would be off on DST changes.
+func (c BusinessCalendar) HistoryRangeDates(from, toEnd time.Time) (fromDate, toDate string) {
+	return c.BusinessDate(from).Format("2006-01-02"), c.BusinessDate(toEnd.Add(-time.Nanosecond)).Format("2006-01-02")
+}
+
 // historyActivityAtSQL is the timestamp used for order-history date filters (payment/completion, not kitchen bumps).
 const historyActivityAtSQL = "COALESCE(completed_at, created_at)"
@@ -3,17 +3,18 @@ package services
 import (
 	"testing"
 	"time"
+
+	"restaurant-api/internal/models"
 )
 
 func TestParseHistoryDateRangeYesterdayIST(t *testing.T) {
-	t.Setenv("APP_TIMEZONE", "Asia/Kolkata")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
 
-	from, toEnd, err := ParseHistoryDateRange("2026-06-02", "2026-06-02")
+	from, toEnd, err := ParseHistoryDateRange("2026-06-02", "2026-06-02", BusinessCalendar{Location: loc})
 	if err != nil {
 		t.Fatalf("ParseHistoryDateRange: %v", err)
 	}
 
-	loc, _ := time.LoadLocation("Asia/Kolkata")
 	wantFrom := time.Date(2026, 6, 2, 0, 0, 0, 0, loc)
 	wantToEnd := time.Date(2026, 6, 3, 0, 0, 0, 0, loc)
 
@@ -31,20 +32,136 @@ func TestParseHistoryDateRangeYesterdayIST(t *testing.T) {
 }
 
 func TestParseHistoryDateRangeIncludesLateEveningIST(t *testing.T) {
-	t.Setenv("APP_TIMEZONE", "Asia/Kolkata")
+	loc, _ := time.LoadLocation("Asia/Kolkata")
 
-	from, toEnd, err := ParseHistoryDateRange("2026-06-02", "2026-06-02")
+	from, toEnd, err := ParseHistoryDateRange("2026-06-02", "2026-06-02", BusinessCalendar{Location: loc})
 	if err != nil {
 		t.Fatalf("ParseHistoryDateRange: %v", err)
 	}
 
-	loc, _ := time.LoadLocation("Asia/Kolkata")
 	lateJune2 := time.Date(2026, 6, 2, 23, 30, 0, 0, loc)
 	if !inHistoryRange(lateJune2, from, toEnd) {
 		t.Fatalf("late June 2 order should be inside June 2 range")
 	}
 }
 
+func TestStartOfRestaurantDayUsesRestaurantZone(t *testing.T) {
+	t.Setenv("APP_TIMEZONE", "Asia/Kolkata")
+
+	// 21:00 UTC on June 2 is already June 3 in both Dubai (01:00) and Singapore (05:00).
+	at := time.Date(2026, 6, 2, 21, 0, 0, 0, time.UTC)
+
+	dubaiCal := BusinessCalendarForRestaurant(&models.Restaurant{Timezone: "Asia/Dubai"})
+	singaporeCal := BusinessCalendarForRestaurant(&models.Restaurant{Timezone: "Asia/Singapore"})
+	dubai, singapore := dubaiCal.Location, singaporeCal.Location
+
+	if got, want := StartOfRestaurantDay(at, dubaiCal), time.Date(2026, 6, 3, 0, 0, 0, 0, dubai); !got.Equal(want) {
+		t.Fatalf("Dubai day start = %v, want %v", got, want)
+	}
+	if got, want := StartOfRestaurantDay(at, singaporeCal), time.Date(2026, 6, 3, 0, 0, 0, 0, singapore); !got.Equal(want) {
+		t.Fatalf("Singapore day start = %v, want %v", got, want)
+	}
+
+	early := time.Date(2026, 6, 2, 19, 0, 0, 0, time.UTC)
+	if got, want := StartOfRestaurantDay(early, dubaiCal), time.Date(2026, 6, 2, 0, 0, 0, 0, dubai); !got.Equal(want) {
+		t.Fatalf("Dubai day start before local midnight = %v, want %v", got, want)
+	}
+	if got, want := StartOfRestaurantDay(early, singaporeCal), time.Date(2026, 6, 3, 0, 0, 0, 0, singapore); !got.Equal(want) {
+		t.Fatalf("Singapore day start after local midnight = %v, want %v", got, want)
+	}
+}
+
+func TestLocationForRestaurantFallsBackToDefault(t *testing.T) {
+	t.Setenv("APP_TIMEZONE", "Asia/Kolkata")
+
+	if got := LocationForRestaurant(&models.Restaurant{}).String(); got != "Asia/Kolkata" {
+		t.Fatalf("empty timezone = %q, want Asia/Kolkata", got)
+	}
+	if got := LocationForRestaurant(&models.Restaurant{Timezone: "Mars/Base"}).String(); got != "Asia/Kolkata" {
+		t.Fatalf("invalid timezone = %q, want Asia/Kolkata", got)
+	}
+}
+
+func TestNormalizeRestaurantTimezone(t *testing.T) {
+	if tz, err := NormalizeRestaurantTimezone(" Asia/Dubai "); err != nil || tz != "Asia/Dubai" {
+		t.Fatalf("NormalizeRestaurantTimezone = %q, %v", tz, err)
+	}
+	for _, bad := range []string{"", "Local", "Mars/Base"} {
+		if _, err := NormalizeRestaurantTimezone(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestStartOfRestaurantDayWithLateCutoff(t *testing.T) {
+	cal := BusinessCalendarForRestaurant(&models.Restaurant{Timezone: "Asia/Kolkata", BusinessDayStart: "03:00"})
+	loc := cal.Location
+
+	oneAM := time.Date(2026, 6, 3, 1, 0, 0, 0, loc)
+	want := time.Date(2026, 6, 2, 3, 0, 0, 0, loc)
+	if got := StartOfRestaurantDay(oneAM, cal); !got.Equal(want) {
+		t.Fatalf("1 AM day start = %v, want %v", got, want)
+	}
+	if got := cal.BusinessDate(oneAM).Format("2006-01-02"); got != "2026-06-02" {
+		t.Fatalf("1 AM business date = %s, want 2026-06-02", got)
+	}
+
+	fourAM := time.Date(2026, 6, 3, 4, 0, 0, 0, loc)
+	want = time.Date(2026, 6, 3, 3, 0, 0, 0, loc)
+	if got := StartOfRestaurantDay(fourAM, cal); !got.Equal(want) {
+		t.Fatalf("4 AM day start = %v, want %v", got, want)
+	}
+}
+
+func TestParseHistoryDateRangeWithLateCutoff(t *testing.T) {
+	cal := BusinessCalendarForRestaurant(&models.Restaurant{Timezone: "Asia/Kolkata", BusinessDayStart: "03:00"})
+	loc := cal.Location
+
+	from, toEnd, err := ParseHistoryDateRange("2026-06-02", "2026-06-02", cal)
+	if err != nil {
+		t.Fatalf("ParseHistoryDateRange: %v", err)
+	}
+	if !inHistoryRange(time.Date(2026, 6, 3, 1, 30, 0, 0, loc), from, toEnd) {
+		t.Fatalf("1:30 AM June 3 should belong to the June 2 business day")
+	}
+	if inHistoryRange(time.Date(2026, 6, 2, 2, 30, 0, 0, loc), from, toEnd) {
+		t.Fatalf("2:30 AM June 2 should belong to the June 1 business day")
+	}
+}
+
+func TestHistoryRangeDatesAcrossDST(t *testing.T) {
+	for _, tz := range []string{"America/New_York", "Asia/Kolkata"} {
+		for _, start := range []string{"00:00", "03:00"} {
+			cal := BusinessCalendarForRestaurant(&models.Restaurant{Timezone: tz, BusinessDayStart: start})
+			// New York springs forward on 8 March and falls back on 1 November 2026.
+			for _, r := range [][2]string{{"2026-03-07", "2026-03-08"}, {"2026-03-08", "2026-03-08"}, {"2026-11-01", "2026-11-01"}, {"2026-06-02", "2026-06-04"}} {
+				from, toEnd, err := ParseHistoryDateRange(r[0], r[1], cal)
+				if err != nil {
+					t.Fatalf("%s %s %v: %v", tz, start, r, err)
+				}
+				if gotFrom, gotTo := cal.HistoryRangeDates(from, toEnd); gotFrom != r[0] || gotTo != r[1] {
+					t.Errorf("%s day start %s: %v came back as %s..%s", tz, start, r, gotFrom, gotTo)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalizeBusinessDayStart(t *testing.T) {
+	cases := map[string]string{"": "00:00", "3:00": "03:00", "02:30": "02:30", "11:59": "11:59"}
+	for in, want := range cases {
+		got, err := NormalizeBusinessDayStart(in)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeBusinessDayStart(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"12:00", "25:00", "3", "ab:cd", "03:60"} {
+		if _, err := NormalizeBusinessDayStart(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
 func inHistoryRange(at, from, toEnd time.Time) bool {
 	return !at.Before(from) && at.Before(toEnd)
 }
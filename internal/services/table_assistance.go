@@ -127,12 +127,14 @@ type AssistanceBillItem struct {
 
 // AssistanceMenuVariant is a lean dine-in menu portion for the customer page.
 type AssistanceMenuVariant struct {
+	ID    string  `json:"id"`
 	Label string  `json:"label"`
 	Price float64 `json:"price"`
 }
 
-// AssistanceMenuItem is a lean dine-in menu row (browse only).
+// AssistanceMenuItem is a lean dine-in menu row; the IDs let guests order it.
 type AssistanceMenuItem struct {
+	ID          string                  `json:"id"`
 	Name        string                  `json:"name"`
 	Category    string                  `json:"category,omitempty"`
 	Description string                  `json:"description,omitempty"`
@@ -167,6 +169,10 @@ type AssistanceStatus struct {
 	PricesIncludeGST bool    `json:"prices_include_gst"`
 	CompositeScheme  bool    `json:"composite_scheme"`
 	ShowTax          bool    `json:"show_tax"`
+	TaxBreakdown     []GSTSlab `json:"tax_breakdown,omitempty"`
+	// GuestOrdering shows the cart on the menu; GuestOrders are this table's recent carts.
+	GuestOrdering bool                   `json:"guest_ordering"`
+	GuestOrders   []AssistanceGuestOrder `json:"guest_orders,omitempty"`
 }
 
 // ResolveTableByAssistanceToken finds a table by its permanent QR token.
@@ -221,7 +227,9 @@ func BuildAssistanceStatusForTable(db *gorm.DB, orderService *OrderService, tabl
 	var restaurant models.Restaurant
 	if err := db.Where("id = ?", table.RestaurantID).First(&restaurant).Error; err == nil {
 		status.RestaurantName = restaurant.Name
+		status.GuestOrdering = restaurant.GuestOrdering
 	}
+	status.GuestOrders = recentTableGuestOrders(db, table.ID)
 
 	var order *models.Order
 	if table.CurrentOrderID != nil && strings.TrimSpace(*table.CurrentOrderID) != "" && orderService != nil {
@@ -254,6 +262,7 @@ func BuildAssistanceStatusForTable(db *gorm.DB, orderService *OrderService, tabl
 	// Checkout / bill review phase — hide menu, show bill.
 	status.Phase = "checkout"
 	status.MenuVisible = false
+	status.GuestOrdering = false
 	status.BillAvailable = true
 	status.BillURL = BuildBillURL(order.BillToken)
 	status.BillDownloadURL = status.BillURL + "/download"
@@ -271,6 +280,7 @@ func BuildAssistanceStatusForTable(db *gorm.DB, orderService *OrderService, tabl
 	status.PricesIncludeGST = summary.PricesIncludeGST
 	status.CompositeScheme = summary.CompositeScheme
 	status.ShowTax = summary.TaxAmount > 0 && !summary.CompositeScheme
+	status.TaxBreakdown = summary.TaxBreakdown
 
 	groupedItems := make(map[string]int)
 	blocklist := []string(nil)
@@ -368,7 +378,7 @@ func dineInVariantPrice(v *models.MenuItemVariant, item *models.MenuItem) float6
 	return v.Price
 }
 
-// LoadAssistanceMenu returns browse-only dine-in menu items for the table's restaurant.
+// LoadAssistanceMenu returns the dine-in menu items for the table's restaurant.
 // Item names use FormatItemDisplayName (same smart naming as bills/KOTs).
 func LoadAssistanceMenu(db *gorm.DB, restaurantID string) ([]AssistanceMenuItem, error) {
 	var restaurant models.Restaurant
@@ -391,6 +401,7 @@ func LoadAssistanceMenu(db *gorm.DB, restaurantID string) ([]AssistanceMenuItem,
 			continue
 		}
 		row := AssistanceMenuItem{
+			ID:          item.ID,
 			Name:        FormatItemDisplayName(item.Name, item.Category, "", blocklist),
 			Category:    item.Category,
 			Description: strings.TrimSpace(item.Description),
@@ -400,6 +411,7 @@ func LoadAssistanceMenu(db *gorm.DB, restaurantID string) ([]AssistanceMenuItem,
 		for j := range item.Variants {
 			v := &item.Variants[j]
 			row.Variants = append(row.Variants, AssistanceMenuVariant{
+				ID:    v.ID,
 				Label: v.Label,
 				Price: dineInVariantPrice(v, item),
 			})
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Ledger transaction types. Rows are append-only; corrections are new rows.
+const (
+	TransactionTypePayment         = "payment"
+	TransactionTypeRefund          = "refund"
+	TransactionTypeExpense         = "expense"
+	TransactionTypeExpenseReversal = "expense_reversal"
+	TransactionTypeRestock         = "restock"
+	TransactionTypePayout          = "payout"
+)
+
+var transactionTypes = map[string]bool{
+	TransactionTypePayment:         true,
+	TransactionTypeRefund:          true,
+	TransactionTypeExpense:         true,
+	TransactionTypeExpenseReversal: true,
+	TransactionTypeRestock:         true,
+	TransactionTypePayout:          true,
+}
+
+// RecordTransaction appends a completed ledger row. Pass the caller's transaction so the
+// ledger commits or rolls back together with the money movement it describes.
+func RecordTransaction(tx *gorm.DB, txn models.Transaction) error {
+	if txn.RestaurantID == "" {
+		return errors.New("ledger transaction requires restaurant_id")
+	}
+	if !transactionTypes[txn.TransactionType] {
+		return fmt.Errorf("unknown ledger transaction type %q", txn.TransactionType)
+	}
+	txn.ID = ""
+	if txn.Status == "" {
+		txn.Status = "completed"
+	}
+	if txn.CashSessionID == nil && txn.UserID != "" && strings.EqualFold(txn.PaymentMethod, "cash") {
+		sessionID, err := openCashSessionID(tx, txn.RestaurantID, txn.UserID)
+		if err != nil {
+			return err
+		}
+		if sessionID != "" {
+			txn.CashSessionID = &sessionID
+		}
+	}
+	return tx.Create(&txn).Error
+}
+
+// PaymentLedgerLegs splits a settled order into one ledger row per tender, each carrying
+// its own reference. Orders without tender rows fall back to the legacy columns: split
+// payments produce a cash leg and a UPI leg with the UPI reference on the UPI leg only.
+func PaymentLedgerLegs(order *models.Order, userID string) []models.Transaction {
+	base := models.Transaction{
+		RestaurantID:    order.RestaurantID,
+		OrderID:         order.ID,
+		TransactionType: TransactionTypePayment,
+		UserID:          userID,
+		Notes:           order.InvoiceNumber,
+	}
+	if len(order.Tenders) > 0 {
+		legs := make([]models.Transaction, 0, len(order.Tenders))
+		for _, tender := range order.Tenders {
+			leg := base
+			leg.PaymentMethod = tender.Method
+			leg.Amount = tender.Amount
+			leg.PaymentID = tender.Reference
+			legs = append(legs, leg)
+		}
+		return legs
+	}
+	method := strings.ToLower(strings.TrimSpace(order.PaymentMethod))
+	if method == "split" {
+		var legs []models.Transaction
+		if order.CashAmount > 0 {
+			leg := base
+			leg.PaymentMethod = "cash"
+			leg.Amount = order.CashAmount
+			legs = append(legs, leg)
+		}
+		if order.UpiAmount > 0 {
+			leg := base
+			leg.PaymentMethod = "upi"
+			leg.Amount = order.UpiAmount
+			leg.PaymentID = order.UpiTransactionID
+			legs = append(legs, leg)
+		}
+		return legs
+	}
+	base.PaymentMethod = method
+	base.Amount = order.Total
+	if method == "upi" {
+		base.PaymentID = order.UpiTransactionID
+	}
+	return []models.Transaction{base}
+}
+
+// TransactionFilter narrows a ledger listing. Empty fields are ignored.
+type TransactionFilter struct {
+	Type          string
+	PaymentMethod string
+	OrderID       string
+	UserID        string
+	CashSessionID string
+	From          time.Time
+	ToEnd         time.Time
+}
+
+// TransactionLedgerService reads the append-only money ledger.
+type TransactionLedgerService struct {
+	db *gorm.DB
+}
+
+func NewTransactionLedgerService(db *gorm.DB) *TransactionLedgerService {
+	return &TransactionLedgerService{db: db}
+}
+
+// TransactionTotals sums a filtered ledger: money in (payments), money out (everything else).
+type TransactionTotals struct {
+	In  float64 `json:"in"`
+	Out float64 `json:"out"`
+	Net float64 `json:"net"`
+}
+
+// List returns ledger rows newest first, the total matching count and in/out totals.
+func (s *TransactionLedgerService) List(restaurantID string, filter TransactionFilter, limit, offset int) ([]models.Transaction, int64, TransactionTotals, error) {
+	var totals TransactionTotals
+	if filter.Type != "" && !transactionTypes[filter.Type] {
+		return nil, 0, totals, fmt.Errorf("unknown transaction type %q", filter.Type)
+	}
+
+	query := s.db.Model(&models.Transaction{}).Where("restaurant_id = ?", restaurantID)
+	if filter.Type != "" {
+		query = query.Where("transaction_type = ?", filter.Type)
+	}
+	if filter.PaymentMethod != "" {
+		query = query.Where("LOWER(payment_method) = ?", strings.ToLower(filter.PaymentMethod))
+	}
+	if filter.OrderID != "" {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.CashSessionID != "" {
+		query = query.Where("cash_session_id = ?", filter.CashSessionID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.ToEnd.IsZero() {
+		query = query.Where("created_at < ?", filter.ToEnd)
+	}
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, totals, err
+	}
+
+	if err := query.Session(&gorm.Session{}).Select(`
+		COALESCE(SUM(CASE WHEN transaction_type = 'payment' THEN amount
+			WHEN transaction_type = 'expense_reversal' THEN amount ELSE 0 END), 0) AS "in",
+		COALESCE(SUM(CASE WHEN transaction_type IN ('refund', 'expense', 'restock', 'payout') THEN amount ELSE 0 END), 0) AS "out"`).
+		Scan(&totals).Error; err != nil {
+		return nil, 0, totals, err
+	}
+	totals.Net = totals.In - totals.Out
+
+	var rows []models.Transaction
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&rows).Error; err != nil {
+		return nil, 0, totals, err
+	}
+	return rows, count, totals, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"restaurant-api/internal/models"
+)
+
+func TestPaymentLedgerLegs_SplitWritesOneRowPerTender(t *testing.T) {
+	order := &models.Order{
+		ID: "o1", RestaurantID: "r1", PaymentMethod: "split", Total: 500,
+		CashAmount: 200, UpiAmount: 300, UpiTransactionID: "UPI123", InvoiceNumber: "INV/25-26/000001",
+	}
+	legs := PaymentLedgerLegs(order, "u1")
+	if len(legs) != 2 {
+		t.Fatalf("expected 2 legs, got %d", len(legs))
+	}
+	if legs[0].PaymentMethod != "cash" || legs[0].Amount != 200 || legs[0].PaymentID != "" {
+		t.Fatalf("cash leg: %+v", legs[0])
+	}
+	if legs[1].PaymentMethod != "upi" || legs[1].Amount != 300 || legs[1].PaymentID != "UPI123" {
+		t.Fatalf("upi leg: %+v", legs[1])
+	}
+	for _, leg := range legs {
+		if leg.TransactionType != TransactionTypePayment || leg.UserID != "u1" || leg.OrderID != "o1" {
+			t.Fatalf("leg missing context: %+v", leg)
+		}
+	}
+}
+
+func TestPaymentLedgerLegs_SingleTenderUsesOrderTotal(t *testing.T) {
+	order := &models.Order{ID: "o1", RestaurantID: "r1", PaymentMethod: "cash", Total: 420, AmountReceived: 500}
+	legs := PaymentLedgerLegs(order, "")
+	if len(legs) != 1 || legs[0].Amount != 420 || legs[0].PaymentMethod != "cash" {
+		t.Fatalf("got %+v", legs)
+	}
+}
+
+func TestRecordTransaction_RejectsUnknownType(t *testing.T) {
+	if err := RecordTransaction(nil, models.Transaction{RestaurantID: "r1", TransactionType: "sale"}); err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"restaurant-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrZReportNotFound = errors.New("z-report not found")
+
+// ErrZReportDayOpen is returned for a business day that has not ended yet: orders can still
+// land in it, so a frozen report would miss them.
+var ErrZReportDayOpen = errors.New("business day is still open; the Z-report can be generated once it ends")
+
+// ZReportCount is a number of events and their value.
+type ZReportCount struct {
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// ZReportCancellations covers whole cancelled orders and lines voided on paid orders.
+type ZReportCancellations struct {
+	Orders     int64   `json:"orders"`
+	OrderValue float64 `json:"order_value"`
+	Items      int64   `json:"items"`
+	ItemValue  float64 `json:"item_value"`
+}
+
+// ZReportTable is dine-in activity for one table.
+type ZReportTable struct {
+	TableNumber string  `json:"table_number"`
+	Orders      int64   `json:"orders"`
+	Covers      int64   `json:"covers"`
+	Revenue     float64 `json:"revenue"`
+}
+
+// ZReportSnapshot is everything frozen into a Z-report.
+type ZReportSnapshot struct {
+	ReportNumber   int                  `json:"report_number"`
+	BusinessDate   string               `json:"business_date"`
+	RestaurantName string               `json:"restaurant_name"`
+	GSTNumber      string               `json:"gst_number,omitempty"`
+	PeriodStart    time.Time            `json:"period_start"`
+	PeriodEnd      time.Time            `json:"period_end"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+	Sales          SalesSummary         `json:"sales"`
+	Discounts      ZReportCount         `json:"discounts"`
+	Cancellations  ZReportCancellations `json:"cancellations"`
+	Refunds        RefundTotals         `json:"refunds"`
+	NetRevenue     float64              `json:"net_revenue"`
+	TopItems       []TopSellingItem     `json:"top_items"`
+	Tables         []ZReportTable       `json:"tables"`
+	TotalCovers    int64                `json:"total_covers"`
+}
+
+// ZReportService builds, freezes, prints and emails end-of-day reports.
+type ZReportService struct {
+	db     *gorm.DB
+	orders *OrderService
+	print  *PrintService
+}
+
+func NewZReportService(db *gorm.DB) *ZReportService {
+	return &ZReportService{db: db, orders: NewOrderService(db), print: NewPrintService(db)}
+}
+
+// Generate freezes the Z-report for a business date (YYYY-MM-DD, default the last business
+// day that has ended). A date is frozen once: later calls return the stored report with
+// created=false. A day still in progress is refused with ErrZReportDayOpen.
+func (s *ZReportService) Generate(restaurantID, businessDate, userID string) (report *models.ZReport, created bool, err error) {
+	var restaurant models.Restaurant
+	if err := s.db.Select("id", "name", "gst_number", "timezone", "business_day_start").
+		Where("id = ?", restaurantID).First(&restaurant).Error; err != nil {
+		return nil, false, err
+	}
+	cal := BusinessCalendarForRestaurant(&restaurant)
+	businessDate = strings.TrimSpace(businessDate)
+	now := time.Now()
+	if businessDate == "" {
+		businessDate = cal.BusinessDate(now).AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	from, toEnd, err := ParseHistoryDateRange(businessDate, businessDate, cal)
+	if err != nil {
+		return nil, false, err
+	}
+	if toEnd.After(now) {
+		return nil, false, ErrZReportDayOpen
+	}
+
+	if existing, err := s.findByDate(restaurantID, businessDate); err == nil {
+		return existing, false, nil
+	} else if !errors.Is(err, ErrZReportNotFound) {
+		return nil, false, err
+	}
+
+	snapshot, err := s.buildSnapshot(&restaurant, businessDate, from, toEnd)
+	if err != nil {
+		return nil, false, err
+	}
+	settings, err := s.print.GetOrCreateSettings(restaurantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var out models.ZReport
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the restaurant row so report numbers stay gap-free under concurrent closes.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", restaurantID).First(&models.Restaurant{}).Error; err != nil {
+			return err
+		}
+		var dup int64
+		if err := tx.Model(&models.ZReport{}).
+			Where("restaurant_id = ? AND business_date = ?", restaurantID, businessDate).
+			Count(&dup).Error; err != nil {
+			return err
+		}
+		if dup > 0 {
+			return errZReportRace
+		}
+		var last int
+		if err := tx.Model(&models.ZReport{}).Where("restaurant_id = ?", restaurantID).
+			Select("COALESCE(MAX(report_number), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		snapshot.ReportNumber = last + 1
+		raw, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		out = models.ZReport{
+			RestaurantID:      restaurantID,
+			BusinessDate:      businessDate,
+			ReportNumber:      snapshot.ReportNumber,
+			PeriodStart:       from,
+			PeriodEnd:         toEnd,
+			Snapshot:          raw,
+			SlipText:          ZReportSlip(snapshot, cal, thermalColsForPaper(settings.BillPaperWidthMm)),
+			GeneratedByUserID: userID,
+		}
+		return tx.Create(&out).Error
+	})
+	if errors.Is(err, errZReportRace) {
+		existing, findErr := s.findByDate(restaurantID, businessDate)
+		return existing, false, findErr
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &out, true, nil
+}
+
+var errZReportRace = errors.New("z-report created concurrently")
+
+func (s *ZReportService) findByDate(restaurantID, businessDate string) (*models.ZReport, error) {
+	var report models.ZReport
+	if err := s.db.Where("restaurant_id = ? AND business_date = ?", restaurantID, businessDate).
+		First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrZReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Get loads a stored report.
+func (s *ZReportService) Get(restaurantID, reportID string) (*models.ZReport, error) {
+	var report models.ZReport
+	if err := s.db.Where("id = ? AND restaurant_id = ?", reportID, restaurantID).First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrZReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// List returns stored reports, newest business date first. Slip text is omitted.
+func (s *ZReportService) List(restaurantID string, limit, offset int) ([]models.ZReport, int64, error) {
+	query := s.db.Model(&models.ZReport{}).Where("restaurant_id = ?", restaurantID)
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var reports []models.ZReport
+	err := query.Session(&gorm.Session{}).
+		Omit("slip_text").
+		Order("business_date DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reports).Error
+	return reports, count, err
+}
+
+// Print queues the stored slip text on the bill printer.
+func (s *ZReportService) Print(report *models.ZReport) (bool, error) {
+	queued, err := s.print.enqueueReport(report.RestaurantID, report.SlipText)
+	if err != nil || !queued {
+		return queued, err
+	}
+	return true, s.db.Model(&models.ZReport{}).Where("id = ?", report.ID).
+		UpdateColumn("print_count", gorm.Expr("print_count + 1")).Error
+}
+
+// Email sends the stored slip to `to`, or to the restaurant's address when empty.
+func (s *ZReportService) Email(report *models.ZReport, to string) (string, error) {
+	var restaurant models.Restaurant
+	if err := s.db.Select("id", "name", "email").Where("id = ?", report.RestaurantID).First(&restaurant).Error; err != nil {
+		return "", err
+	}
+	to = strings.TrimSpace(to)
+	if to == "" {
+		to = strings.TrimSpace(restaurant.Email)
+	}
+	if to == "" {
+		return "", errors.New("restaurant has no email address; pass one explicitly")
+	}
+	if _, err := smtpEnvelopeAddress(to); err != nil {
+		return "", errors.New("invalid email address")
+	}
+	if err := sendComposedEmail(to, buildZReportEmail(restaurant.Name, report)); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err := s.db.Model(&models.ZReport{}).Where("id = ?", report.ID).UpdateColumns(map[string]interface{}{
+		"last_emailed_to": to,
+		"last_emailed_at": now,
+	}).Error
+	return to, err
+}
+
+func buildZReportEmail(restaurantName string, report *models.ZReport) composedEmail {
+	name := strings.TrimSpace(restaurantName)
+	if name == "" {
+		name = "your restaurant"
+	}
+	subject := fmt.Sprintf("Z-report #%d for %s (%s)", report.ReportNumber, name, report.BusinessDate)
+	text := fmt.Sprintf("End-of-day report for %s, business date %s.\n\n%s\n%s",
+		name, report.BusinessDate, report.SlipText, transactionalEmailFooterText())
+	bodyHTML := fmt.Sprintf(`<p style="margin:0 0 12px;font-size:15px;line-height:1.6;color:#111827;">End-of-day report for <strong>%s</strong>, business date %s.</p>
+<pre style="margin:0;padding:12px;background:#f9fafb;border:1px solid #e5e7eb;border-radius:8px;font-size:13px;line-height:1.4;color:#111827;">%s</pre>`,
+		html.EscapeString(name), html.EscapeString(report.BusinessDate), html.EscapeString(report.SlipText))
+	return composedEmail{Subject: subject, Text: text, HTML: wrapTransactionalHTML(subject, bodyHTML)}
+}
+
+func (s *ZReportService) buildSnapshot(restaurant *models.Restaurant, businessDate string, from, toEnd time.Time) (ZReportSnapshot, error) {
+	restaurantID := restaurant.ID
+	snap := ZReportSnapshot{
+		BusinessDate:   businessDate,
+		RestaurantName: restaurant.Name,
+		GSTNumber:      restaurant.GstNumber,
+		PeriodStart:    from,
+		PeriodEnd:      toEnd,
+		GeneratedAt:    time.Now(),
+	}
+
+	sales, err := s.orders.GetSalesSummary(restaurantID, "range", "all", businessDate, businessDate)
+	if err != nil {
+		return snap, err
+	}
+	snap.Sales = *sales
+	snap.Refunds = ZReportRefunds(sales)
+	snap.NetRevenue = sales.NetRevenue
+
+	paid := func() *gorm.DB {
+		return s.db.Model(&models.Order{}).
+			Where("restaurant_id = ?", restaurantID).
+			Where("(status = ? OR (order_type = ? AND payment_method <> ''))", "completed", "counter").
+			Where(historyActivityAtSQL+" >= ? AND "+historyActivityAtSQL+" < ?", from, toEnd)
+	}
+
+	if err := paid().Where("discount_amount > 0").
+		Select("COUNT(*) AS count, COALESCE(SUM(discount_amount), 0) AS amount").
+		Scan(&snap.Discounts).Error; err != nil {
+		return snap, err
+	}
+
+	var cancelledOrders ZReportCount
+	if err := s.db.Model(&models.Order{}).
+		Where("restaurant_id = ? AND status = ? AND updated_at >= ? AND updated_at < ?", restaurantID, "cancelled", from, toEnd).
+		Select("COUNT(*) AS count, COALESCE(SUM(total), 0) AS amount").
+		Scan(&cancelledOrders).Error; err != nil {
+		return snap, err
+	}
+	snap.Cancellations.Orders = cancelledOrders.Count
+	snap.Cancellations.OrderValue = cancelledOrders.Amount
+
+	var cancelledItems ZReportCount
+	if err := s.db.Table("order_items AS oi").
+		Joins("JOIN orders AS o ON o.id = oi.order_id").
+		Where("o.restaurant_id = ? AND o.status <> ? AND oi.status = ?", restaurantID, "cancelled", "cancelled").
+		Where("COALESCE(o.completed_at, o.created_at) >= ? AND COALESCE(o.completed_at, o.created_at) < ?", from, toEnd).
+		Select("COALESCE(SUM(oi.quantity), 0) AS count, COALESCE(SUM(oi.total), 0) AS amount").
+		Scan(&cancelledItems).Error; err != nil {
+		return snap, err
+	}
+	snap.Cancellations.Items = cancelledItems.Count
+	snap.Cancellations.ItemValue = cancelledItems.Amount
+
+	if snap.TopItems, err = s.orders.topSellingItems(restaurantID, from, toEnd, 10, "all"); err != nil {
+		return snap, err
+	}
+
+	if err := applySalesOrderTypeFilter(paid(), "dine_in").
+		Select("table_number, COUNT(*) AS orders, COALESCE(SUM(covers), 0) AS covers, COALESCE(SUM(total), 0) AS revenue").
+		Group("table_number").
+		Order("table_number ASC").
+		Scan(&snap.Tables).Error; err != nil {
+		return snap, err
+	}
+	for _, t := range snap.Tables {
+		snap.TotalCovers += t.Covers
+	}
+	return snap, nil
+}
+
+// ZReportRefunds lifts the refund figures out of a sales summary.
+func ZReportRefunds(sales *SalesSummary) RefundTotals {
+	return RefundTotals{Count: sales.RefundCount, Amount: sales.RefundAmount, TaxAmount: sales.RefundGST}
+}
+
+// ZReportSlip renders the thermal slip for a snapshot. It is rendered once at freeze time.
+func ZReportSlip(snap ZReportSnapshot, cal BusinessCalendar, width int) string {
+	if width <= 0 {
+		width = 32
+	}
+	loc := cal.location()
+	divider := strings.Repeat("-", width)
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	section := func(title string) {
+		line(divider)
+		line(title)
+	}
+
+	if name := strings.TrimSpace(snap.RestaurantName); name != "" {
+		line(printCenterLine(printSafeText(name), width))
+	}
+	if snap.GSTNumber != "" {
+		line(printCenterLine("GSTIN: "+printSafeText(snap.GSTNumber), width))
+	}
+	line(printCenterLine(fmt.Sprintf("Z-REPORT #%d", snap.ReportNumber), width))
+	line(printCenterLine("Business date "+snap.BusinessDate, width))
+	line(divider)
+	line(printPadLine("From", snap.PeriodStart.In(loc).Format("02 Jan 03:04 PM"), width))
+	line(printPadLine("To", snap.PeriodEnd.In(loc).Format("02 Jan 03:04 PM"), width))
+
+	sales := snap.Sales
+	section("SALES")
+	line(printPadLine("Orders", fmt.Sprintf("%d", sales.TotalOrders), width))
+	line(printPadLine("Gross sales", money(sales.TotalRevenue), width))
+	line(printPadLine("  Dine-in", fmt.Sprintf("%d / %s", sales.DineInOrders, money(sales.DineInRevenue)), width))
+	line(printPadLine("  Counter", fmt.Sprintf("%d / %s", sales.CounterOrders, money(sales.CounterRevenue)), width))
+	line(printPadLine("Avg order", money(sales.AverageOrderValue), width))
+
+	section("PAYMENTS")
+	if len(sales.Tenders) > 0 {
+		for _, tender := range sales.Tenders {
+			line(printPadLine(fmt.Sprintf("%s (%d)", tender.Label, tender.Count), money(tender.Amount), width))
+		}
+	} else {
+		line(printPadLine("Cash", money(sales.CashAmount), width))
+		line(printPadLine("UPI", money(sales.UpiAmount), width))
+	}
+
+	section("GST")
+	for _, slab := range sales.GSTByRate {
+		line(printPadLine(fmt.Sprintf("%s%% on %s", formatGSTRate(slab.Rate), money(slab.TaxableValue)), money(slab.TaxAmount), width))
+	}
+	line(printPadLine("Total GST", money(sales.TotalGST), width))
+
+	section("ADJUSTMENTS")
+	line(printPadLine(fmt.Sprintf("Discounts (%d)", snap.Discounts.Count), money(snap.Discounts.Amount), width))
+	line(printPadLine(fmt.Sprintf("Cancelled orders (%d)", snap.Cancellations.Orders), money(snap.Cancellations.OrderValue), width))
+	line(printPadLine(fmt.Sprintf("Voided items (%d)", snap.Cancellations.Items), money(snap.Cancellations.ItemValue), width))
+	line(printPadLine(fmt.Sprintf("Refunds (%d)", snap.Refunds.Count), money(snap.Refunds.Amount), width))
+	line(printPadLine("NET SALES", money(snap.NetRevenue), width))
+
+	if len(snap.TopItems) > 0 {
+		section("TOP ITEMS")
+		for _, item := range snap.TopItems {
+			line(printPadLine(printSafeText(item.Name), fmt.Sprintf("%d", item.Quantity), width))
+		}
+	}
+
+	if len(snap.Tables) > 0 {
+		section("TABLES (orders/covers)")
+		for _, t := range snap.Tables {
+			line(printPadLine(printSafeText(t.TableNumber), fmt.Sprintf("%d/%d  %s", t.Orders, t.Covers, money(t.Revenue)), width))
+		}
+		line(printPadLine("Total covers", fmt.Sprintf("%d", snap.TotalCovers), width))
+	}
+
+	line(divider)
+	line(printCenterLine("Generated "+snap.GeneratedAt.In(loc).Format("02 Jan 2006 03:04 PM"), width))
+	return b.String()
+}
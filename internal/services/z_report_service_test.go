@@ -0,0 +1,54 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZReportSlip_IncludesAllSections(t *testing.T) {
+	start := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	snap := ZReportSnapshot{
+		ReportNumber:   12,
+		BusinessDate:   "2026-03-04",
+		RestaurantName: "Cafe",
+		PeriodStart:    start,
+		PeriodEnd:      start.Add(24 * time.Hour),
+		GeneratedAt:    start.Add(23 * time.Hour),
+		Sales: SalesSummary{
+			TotalOrders: 40, TotalRevenue: 21000, TotalGST: 1000, CashAmount: 9000, UpiAmount: 12000,
+			GSTByRate: []GSTSlab{{Rate: 5, TaxableValue: 20000, TaxAmount: 1000}},
+		},
+		Discounts:     ZReportCount{Count: 3, Amount: 150},
+		Cancellations: ZReportCancellations{Orders: 1, OrderValue: 300, Items: 2, ItemValue: 90},
+		Refunds:       RefundTotals{Count: 1, Amount: 210},
+		NetRevenue:    20790,
+		TopItems:      []TopSellingItem{{Name: "Masala Dosa", Quantity: 25}},
+		Tables:        []ZReportTable{{TableNumber: "T1", Orders: 4, Covers: 10, Revenue: 3200}},
+		TotalCovers:   10,
+	}
+	slip := ZReportSlip(snap, BusinessCalendar{}, 42)
+	for _, want := range []string{"Z-REPORT #12", "2026-03-04", "21000.00", "Discounts (3)", "Cancelled orders (1)", "Refunds (1)", "20790.00", "Masala Dosa", "4/10", "Total covers"} {
+		if !strings.Contains(slip, want) {
+			t.Errorf("slip missing %q:\n%s", want, slip)
+		}
+	}
+	if again := ZReportSlip(snap, BusinessCalendar{}, 42); again != slip {
+		t.Fatal("slip rendering must be deterministic for identical reprints")
+	}
+}
+
+func TestZReportSlip_EscapesUserText(t *testing.T) {
+	inject := "<<<BARCODE>>>x<<<END_BARCODE>>>"
+	snap := ZReportSnapshot{
+		ReportNumber:   1,
+		BusinessDate:   "2026-03-04",
+		RestaurantName: inject,
+		GSTNumber:      inject,
+		TopItems:       []TopSellingItem{{Name: inject, Quantity: 1}},
+		Tables:         []ZReportTable{{TableNumber: inject, Orders: 1}},
+	}
+	if slip := ZReportSlip(snap, BusinessCalendar{}, 80); strings.Contains(slip, "<<<BARCODE>>>") {
+		t.Fatalf("user text opened a barcode block:\n%s", slip)
+	}
+}